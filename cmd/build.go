@@ -16,15 +16,19 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"sumologic.com/sumo-cli/sumoapp"
 )
 
 var (
-	outputFile string
+	outputFile    string
+	buildCheck    bool
+	buildManifest bool
 )
 
 // buildCmd represents the build command
@@ -32,7 +36,17 @@ var buildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "Compile a single application JSON artifact",
 	Long: `Compiles all the application overlays into a single JSON
-file that can be imported into Sumo Logic's Continuous Intelligence Platform`,
+file that can be imported into Sumo Logic's Continuous Intelligence Platform.
+
+Builds are deterministic: the same application source always produces the
+same file. Use --check in CI to fail when the committed build file
+(--output-file, or build.json in the application's directory by default)
+is out of date.
+
+Use --manifest to also write a manifest next to the build file (build.json
+gets build.manifest.json). The manifest records the application's name and
+version, the overlays and the hash of every source file, the content hash of
+every built object, and the CLI version and git commit that produced the build.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var path string
 
@@ -46,6 +60,11 @@ file that can be imported into Sumo Logic's Continuous Intelligence Platform`,
 			os.Exit(1)
 		}
 
+		if buildManifest && (outputFile == "" || buildCheck) {
+			fmt.Fprintf(os.Stderr, "Error: --manifest requires --output-file and can't be used with --check. Use --help to learn more")
+			os.Exit(1)
+		}
+
 		app := sumoapp.NewApplicationWithPath(path)
 		if err := app.LoadAppOverlays(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %s", err)
@@ -58,12 +77,52 @@ file that can be imported into Sumo Logic's Continuous Intelligence Platform`,
 			os.Exit(1)
 		}
 
+		if buildCheck {
+			checkFile := outputFile
+			if checkFile == "" {
+				checkFile = filepath.Join(path, "build.json")
+			}
+
+			existing, err := os.ReadFile(checkFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Unable to read %s. %s", checkFile, err)
+				os.Exit(1)
+			}
+
+			if !bytes.Equal(existing, jsonString) {
+				fmt.Fprintf(os.Stderr, "Error: %s is out of date. Run 'sumo app build %s -o %s' and commit the result\n", checkFile, path, checkFile)
+				os.Exit(1)
+			}
+
+			return
+		}
+
 		if outputFile == "" {
-			fmt.Println(string(jsonString))
+			fmt.Print(string(jsonString))
 		} else {
 			err := os.WriteFile(outputFile, jsonString, 0644)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Unable to write to file %s. %w", outputFile, err)
+				fmt.Fprintf(os.Stderr, "Error: Unable to write to file %s. %s", outputFile, err)
+				os.Exit(1)
+			}
+		}
+
+		if buildManifest {
+			manifest, err := app.NewBuildManifest(jsonString, Version)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			manifestJSON, err := manifest.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			manifestFile := sumoapp.ManifestPath(outputFile)
+			if err := os.WriteFile(manifestFile, manifestJSON, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Unable to write to file %s. %s", manifestFile, err)
 				os.Exit(1)
 			}
 		}
@@ -74,4 +133,6 @@ func init() {
 	appCmd.AddCommand(buildCmd)
 
 	buildCmd.PersistentFlags().StringVarP(&outputFile, "output-file", "o", "", "Output file containing the compiled JSON")
+	buildCmd.PersistentFlags().BoolVar(&buildManifest, "manifest", false, "Write a build manifest next to the output file")
+	buildCmd.PersistentFlags().BoolVar(&buildCheck, "check", false, "Exit with an error if the output file differs from a fresh build instead of writing it")
 }
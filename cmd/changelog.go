@@ -0,0 +1,110 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	changelogOutput  string
+	changelogPrepend string
+	changelogDate    string
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog [old-build] [new-build]",
+	Short: "Write a changelog between two app builds",
+	Long: `Compare two app builds and write a human readable changelog of the
+dashboards, panels, saved searches, and variables that were added, removed,
+and modified, with a short summary of what changed in each. The changelog is
+headed with the application's name and the version of the new build.
+
+The changelog is written in Markdown by default, or as JSON with -o json. Use
+--prepend to add it to the top of a changelog file instead, below the file's
+title. The file is created when it doesn't exist. For example:
+
+  sumo app changelog v1.0.0/build.json build.json --prepend CHANGELOG.md`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects two. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		if changelogPrepend != "" && changelogOutput != "markdown" {
+			fmt.Fprintf(os.Stderr, "Error: --prepend only writes Markdown and can't be used with -o %s", changelogOutput)
+			os.Exit(1)
+		}
+
+		from, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Unable to read %s. %s", args[0], err)
+			os.Exit(1)
+		}
+
+		to, err := os.ReadFile(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Unable to read %s. %s", args[1], err)
+			os.Exit(1)
+		}
+
+		rules, err := sumoapp.ReadNormalizationRules(appPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		changelog, err := sumoapp.NewChangelog(from, to, rules)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		changelog.Date = changelogDate
+		if changelog.Date == "" {
+			changelog.Date = time.Now().Format("2006-01-02")
+		}
+
+		if changelogPrepend != "" {
+			if err := changelog.PrependToFile(changelogPrepend); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Unable to write to file %s. %s", changelogPrepend, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Added %d change(s) to %s\n", changelog.Len(), changelogPrepend)
+			return
+		}
+
+		if err := changelog.Write(os.Stdout, changelogOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	appCmd.AddCommand(changelogCmd)
+
+	changelogCmd.PersistentFlags().StringVarP(&changelogOutput, "output", "o", "markdown", "Output format: markdown or json")
+	changelogCmd.PersistentFlags().StringVar(&changelogPrepend, "prepend", "", "Add the changelog to the top of this Markdown file instead of printing it")
+	changelogCmd.PersistentFlags().StringVar(&changelogDate, "date", "", "Release date in the changelog heading (default today)")
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	checkIndexesOverlay    string
+	checkIndexesPartitions string
+	checkIndexesViews      string
+	checkIndexesOutput     string
+)
+
+// checkIndexesCmd represents the check-indexes command
+var checkIndexesCmd = &cobra.Command{
+	Use:   "check-indexes",
+	Short: "Check the application's queries only search defined partitions and views",
+	Long: `Check that every _index and _view reference in the queries of the
+application's saved searches, panels, variables, and monitors points to a
+partition or scheduled view defined as code. Queries are taken from the
+merged objects of an app overlay (final by default, or --app-overlay).
+
+Partitions are read from --partitions (./partitions by default) and views
+from --views (./views by default), as used by 'sumo partitions' and
+'sumo views'. Names are compared without case, a name with * wildcards
+must match at least one definition, and the sumologic_ indexes Sumo Logic
+provides are always defined. References built from {{variables}} are
+reported but not checked.
+
+The command exits with 1 when a reference points to an undefined index.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if checkIndexesOutput != "text" && checkIndexesOutput != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown output format '%s'. Expects text or json", checkIndexesOutput)
+			os.Exit(1)
+		}
+
+		app := sumoapp.NewApplicationWithPath(appPath)
+		if err := app.LoadAppOverlays(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		overlay, err := app.FindAppOverlay(checkIndexesOverlay)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		report, err := overlay.CheckIndexDefinitions(checkIndexesPartitions, checkIndexesViews)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if checkIndexesOutput == "json" {
+			reportJSON, err := report.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			fmt.Print(string(reportJSON))
+		} else {
+			report.Display(os.Stdout)
+		}
+
+		if report.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	appCmd.AddCommand(checkIndexesCmd)
+
+	checkIndexesCmd.Flags().StringVarP(&checkIndexesOverlay, "app-overlay", "s", "final", "App overlay to take the queries from")
+	checkIndexesCmd.Flags().StringVar(&checkIndexesPartitions, "partitions", "partitions", "File or directory of partition definitions")
+	checkIndexesCmd.Flags().StringVar(&checkIndexesViews, "views", "views", "File or directory of scheduled view definitions")
+	checkIndexesCmd.Flags().StringVarP(&checkIndexesOutput, "output", "o", "text", "Output format: text or json")
+}
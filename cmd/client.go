@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+
+	"github.com/spf13/viper"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+// newAPIClient returns a client for the Sumo Logic API of the configured
+// deployment, authenticated with the configured access ID and key
+func newAPIClient() *sumoapp.APIClient {
+	var apiURL string
+
+	accessId := viper.GetString("access-id")
+	accessKey := viper.GetString("access-key")
+	region := viper.GetString("deployment")
+
+	if region == "us1" {
+		apiURL = "https://api.sumologic.com/api"
+	} else {
+		apiURL = fmt.Sprintf("https://api.%s.sumologic.com/api", region)
+	}
+
+	//Search jobs are tied to the node that created them by a session
+	//cookie, so every request has to send the cookies back
+	jar, _ := cookiejar.New(nil)
+
+	return &sumoapp.APIClient{
+		Cfg: &sumoapp.Configuration{
+			Authentication: sumoapp.BasicAuth{
+				AccessId:  accessId,
+				AccessKey: accessKey,
+			},
+			BasePath:   apiURL,
+			HTTPClient: &http.Client{Jar: jar},
+		},
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	collectorsEnv    string
+	collectorsPrune  bool
+	collectorsOutput string
+)
+
+// collectorsCmd represents the collectors command
+var collectorsCmd = &cobra.Command{
+	Use:   "collectors",
+	Short: "Manage hosted collectors and their sources as code",
+	Long: `Manage the hosted collectors of your Sumo Logic org and their sources (HTTP,
+cloud syslog, AWS S3, and others) with YAML definitions. The definitions every
+environment starts from live in the base directory. Each file maps a key to
+one or more collectors:
+
+  base/web.yaml:
+    web:
+      name: web
+      category: prod/web
+      sources:
+        nginx:
+          sourcetype: HTTP
+          category: prod/web/nginx
+
+Any other directory is an environment that overrides the base definitions,
+like the overlays of an application. Only the collectors, sources, and fields
+that differ need to be listed:
+
+  staging/web.yaml:
+    web:
+      name: web-staging
+      sources:
+        nginx:
+          category: staging/web/nginx
+
+Collectors are matched with the hosted collectors in the org by name, and
+sources with the sources of their collector by name.`,
+}
+
+var collectorsExportCmd = &cobra.Command{
+	Use:   "export [dir]",
+	Short: "Write the org's hosted collectors to YAML files",
+	Long: `Write every hosted collector in the org, along with its sources, to its own
+YAML file in the base directory of dir (./collectors by default). Existing
+files of the same name are replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := collectorsDir(args)
+
+		collectors, err := sumoapp.ListHostedCollectors(context.Background(), newAPIClient())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if err := sumoapp.WriteCollectors(collectors, dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Exported %d collector(s) to %s\n", len(collectors), dir)
+	},
+}
+
+var collectorsPlanCmd = &cobra.Command{
+	Use:   "plan [dir]",
+	Short: "Show how the org's hosted collectors differ from their definitions",
+	Long: `Compare the collector definitions in dir (./collectors by default), merged with
+the overrides of --env, with the hosted collectors in the org. The plan lists
+the collectors and sources 'sumo collectors apply' would create, update, or,
+with --prune, delete, followed by the diff of each. Use -o json for machine
+readable output.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		plan, err := sumoapp.PlanCollectors(context.Background(), newAPIClient(), collectorsDir(args), collectorsEnv, collectorsPrune)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		switch collectorsOutput {
+		case "text":
+			plan.Display(os.Stdout)
+		case "json":
+			j, err := plan.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(j))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: Unknown output format '%s'. Expected one of: %s", collectorsOutput, strings.Join([]string{"text", "json"}, ", "))
+			os.Exit(1)
+		}
+	},
+}
+
+var collectorsApplyCmd = &cobra.Command{
+	Use:   "apply [dir]",
+	Short: "Make the org's hosted collectors match their definitions",
+	Long: `Show the plan for the collector definitions in dir (./collectors by default),
+merged with the overrides of --env, then create and update the collectors and
+sources that changed. Collectors and sources in the org without a definition
+are only deleted with --prune. Installed collectors are never touched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client := newAPIClient()
+
+		plan, err := sumoapp.PlanCollectors(ctx, client, collectorsDir(args), collectorsEnv, collectorsPrune)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		plan.Display(os.Stdout)
+		if !plan.HasChanges() {
+			return
+		}
+
+		fmt.Println()
+		if err := plan.Apply(ctx, client, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// collectorsDir returns the directory of collector definitions given as an
+// argument
+func collectorsDir(args []string) string {
+	switch len(args) {
+	case 0:
+		return "collectors"
+	case 1:
+		return args[0]
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: too many arguments. Expects none or one. Use --help to learn more")
+	os.Exit(1)
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(collectorsCmd)
+	collectorsCmd.AddCommand(collectorsExportCmd)
+	collectorsCmd.AddCommand(collectorsPlanCmd)
+	collectorsCmd.AddCommand(collectorsApplyCmd)
+
+	for _, c := range []*cobra.Command{collectorsPlanCmd, collectorsApplyCmd} {
+		c.Flags().StringVarP(&collectorsEnv, "env", "e", "", "Environment whose overrides are merged with the base definitions")
+		c.Flags().BoolVar(&collectorsPrune, "prune", false, "Delete collectors and sources in the org that have no definition")
+	}
+
+	collectorsPlanCmd.Flags().StringVarP(&collectorsOutput, "output", "o", "text", "Output format: text or json")
+}
@@ -58,6 +58,10 @@ var debugLoadCmd = &cobra.Command{
 			WriteYamlObject(overlay.Dashboards[key])
 		case "folder":
 			WriteYamlObject(overlay.Folders[key])
+		case "monitor":
+			WriteYamlObject(overlay.Monitors[key])
+		case "parser":
+			WriteYamlObject(overlay.Parsers[key])
 		}
 	},
 }
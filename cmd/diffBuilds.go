@@ -17,7 +17,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"sumologic.com/sumo-cli/sumoapp"
@@ -25,44 +24,47 @@ import (
 
 // diffOverlaysCmd represents the diff-overlays command
 var diffBuildsCmd = &cobra.Command{
-	Use:   "diff-builds [build A] [build B]",
-	Short: "Diff objects between two app builds (json files)",
+	Use:   "diff-builds [from] [to]",
+	Short: "Diff objects between two app builds, exports, or component files",
 	Long: `List all differences between two app builds. This command will compare
 all of the folders, dashbaords, panels, saved searches, and variables between two
 app builds JSON files and list all of the objects that are created, deleted, and modified,
-including what modifications are made.`,
+including what modifications are made.
+
+Each side can also be an exported dashboard or saved search, a component YAML
+file in an overlay's dashboards, folders, panels, saved-searches, or variables
+directory, the name of an app overlay (base, middle, or final) to compare with
+its merged objects, or - to read an export from stdin. Relative file paths
+are relative to the application (--app-path), not the current directory.
+Both sides are normalized into the same model first, so a YAML file can be
+compared with a JSON export. When a side only holds some objects, such as a
+single dashboard export or a component file, only those objects are
+compared. For example:
+
+  sumo app diff-builds final/dashboards/overview.yaml final
+  sumo app download-folder <folder ID> | sumo app diff-builds build.json -
+` + diffFlagsHelp,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) != 2 {
-			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects two. Use --help to learn more")
-			os.Exit(1)
+			diffFail(fmt.Errorf("wrong number of arguments. Expects two. Use --help to learn more"))
 		}
 
-		appBuild1 := args[0]
-		appBuild2 := args[1]
-
 		app := sumoapp.NewApplicationWithPath(appPath)
-		baseOverlay := app.NewAppOverlay("base")
-		build2DiffOverlay := app.NewAppOverlay("build2Diff")
-
-		baseOverlay.Child = build2DiffOverlay
-		build2DiffOverlay.Parent = baseOverlay
-
-		if err := app.ImportToOverlay(appBuild1, baseOverlay); err != nil {
-			msg := fmt.Sprintf("Error: could not load %s - %w", appBuild1, err.Error())
-			fmt.Fprintf(os.Stderr, msg)
-			os.Exit(1)
+		if err := app.LoadNormalizationRules(); err != nil {
+			diffFail(err)
 		}
 
-		if err := app.ImportToOverlay(appBuild2, build2DiffOverlay); err != nil {
-			msg := fmt.Sprintf("Error: could not load %s - %w", appBuild2, err.Error())
-			fmt.Fprintf(os.Stderr, msg)
-			os.Exit(1)
+		cs, err := app.CompareInputs(args[0], args[1])
+		if err != nil {
+			diffFail(err)
 		}
 
-		baseOverlay.Diff(build2DiffOverlay)
+		writeChanges(&cs)
 	},
 }
 
 func init() {
 	appCmd.AddCommand(diffBuildsCmd)
+
+	addDiffFlags(diffBuildsCmd)
 }
@@ -0,0 +1,110 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+// Exit codes of commands that compare content, so scripts and cron jobs
+// can tell "nothing changed" apart from "something changed" and failures
+const (
+	exitCodeNoChanges = 0
+	exitCodeChanges   = 1
+	exitCodeError     = 2
+)
+
+// Flags shared by the diff commands
+var (
+	diffOutput      string
+	diffExitCode    bool
+	diffTypes       []string
+	diffKeys        []string
+	diffIgnorePaths []string
+)
+
+const diffFlagsHelp = `
+Use --output to choose the format: text (the default), json or yaml for a
+structured change set, markdown for a summary table and collapsible
+per-object sections that can be posted as a pull request comment, or html
+for a self-contained report grouped by folder and dashboard.
+
+Narrow the changes down with --type (e.g. panel,dashboard), --key (glob
+patterns of object keys), and --ignore-path (dot separated field paths where
+'*' matches any one segment, e.g. Id,Layout.LayoutStructures.*.Structure).
+
+With --exit-code the command exits with 0 if there are no changes, 1 if there
+are changes, and 2 on errors.`
+
+// diffResult is the change set returned by the overlay Changes functions
+type diffResult interface {
+	ApplyFilter(f sumoapp.DiffFilter)
+	Len() int
+	Write(w io.Writer, format string) error
+}
+
+func addDiffFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&diffOutput, "output", "o", "text", "Output format: "+strings.Join(sumoapp.DiffOutputFormats, ", "))
+	cmd.PersistentFlags().BoolVar(&diffExitCode, "exit-code", false, "Exit with 1 if there are changes and 2 on errors")
+	cmd.PersistentFlags().StringSliceVar(&diffTypes, "type", nil, "Only show changes to these object types")
+	cmd.PersistentFlags().StringSliceVar(&diffKeys, "key", nil, "Only show changes to objects with keys matching these patterns")
+	cmd.PersistentFlags().StringSliceVar(&diffIgnorePaths, "ignore-path", nil, "Ignore changes to these field paths")
+}
+
+// diffFilter returns the filter described by the diff flags
+func diffFilter() sumoapp.DiffFilter {
+	filter := sumoapp.DiffFilter{
+		Types:       diffTypes,
+		Keys:        diffKeys,
+		IgnorePaths: diffIgnorePaths,
+	}
+
+	if err := filter.Validate(); err != nil {
+		diffFail(err)
+	}
+
+	return filter
+}
+
+// diffFail prints the error and exits with the error exit code
+func diffFail(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %s", err)
+
+	if diffExitCode {
+		os.Exit(exitCodeError)
+	}
+	os.Exit(1)
+}
+
+// writeChanges filters and writes the change set, then exits with the
+// code --exit-code asks for
+func writeChanges(cs diffResult) {
+	cs.ApplyFilter(diffFilter())
+
+	if err := cs.Write(os.Stdout, diffOutput); err != nil {
+		diffFail(err)
+	}
+
+	if diffExitCode && cs.Len() > 0 {
+		os.Exit(exitCodeChanges)
+	}
+}
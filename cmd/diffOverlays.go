@@ -17,7 +17,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"sumologic.com/sumo-cli/sumoapp"
@@ -30,11 +29,11 @@ var diffOverlaysCmd = &cobra.Command{
 	Long: `List all differences between two app overlays. This command will compare
 all of the folders, dashbaords, panels, saved searches, and variables between two
 app overlays and list all of the objects that are created, deleted, and modified,
-including what modifications are made.`,
+including what modifications are made.
+` + diffFlagsHelp,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) != 2 {
-			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects two. Use --help to learn more")
-			os.Exit(1)
+			diffFail(fmt.Errorf("wrong number of arguments. Expects two. Use --help to learn more"))
 		}
 
 		appOverlay1 := args[0]
@@ -42,29 +41,30 @@ including what modifications are made.`,
 
 		app := sumoapp.NewApplicationWithPath(appPath)
 		if err := app.LoadAppOverlays(); err != nil {
-			msg := fmt.Errorf("Unable to load app overlays: %w", err)
-			fmt.Println(msg)
-			os.Exit(1)
+			diffFail(fmt.Errorf("Unable to load app overlays: %w", err))
 		}
 
 		overlay1, err := app.FindAppOverlay(appOverlay1)
 		if err != nil {
-			msg := fmt.Errorf("Unable to load app overlay %s: %w", appOverlay1, err)
-			fmt.Println(msg)
-			os.Exit(1)
+			diffFail(fmt.Errorf("Unable to load app overlay %s: %w", appOverlay1, err))
 		}
 
 		overlay2, err := app.FindAppOverlay(appOverlay2)
 		if err != nil {
-			msg := fmt.Errorf("Unable to load app overlay %s: %w", appOverlay2, err)
-			fmt.Println(msg)
-			os.Exit(1)
+			diffFail(fmt.Errorf("Unable to load app overlay %s: %w", appOverlay2, err))
 		}
 
-		overlay1.Diff(overlay2)
+		cs, err := overlay1.Changes(overlay2)
+		if err != nil {
+			diffFail(err)
+		}
+
+		writeChanges(&cs)
 	},
 }
 
 func init() {
 	appCmd.AddCommand(diffOverlaysCmd)
+
+	addDiffFlags(diffOverlaysCmd)
 }
@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	diffRemoteFolderId string
+	diffRemoteBuild    string
+	diffRemoteOverlay  string
+)
+
+// diffRemoteCmd represents the diff-remote command
+var diffRemoteCmd = &cobra.Command{
+	Use:   "diff-remote",
+	Short: "Diff live content in Sumo Logic against the application",
+	Long: `List all differences between the content of a folder in your Sumo Logic
+organization and the application. The folder is exported and compared with a
+build file given with --build or, by default, with the application's final app
+overlay (use --app-overlay to pick another one).
+
+Changes are shown from the live content to the application, which is the
+effect pushing the application would have. Both sides are normalized with
+the application's normalization rules first, so server assigned fields such
+as folder, panel, and variable IDs are ignored. The permissions of the live
+folder, and of its folders and dashboards, are compared with the declared ones,
+so this is the plan of what 'sumo app push' would change.
+` + diffFlagsHelp,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			diffFail(fmt.Errorf("too many arguments. Expects none. Use --help to learn more"))
+		}
+
+		if diffRemoteFolderId == "" {
+			diffFail(fmt.Errorf("--folder is required. Use --help to learn more"))
+		}
+
+		var local []byte
+		if diffRemoteBuild != "" {
+			var err error
+			local, err = os.ReadFile(diffRemoteBuild)
+			if err != nil {
+				diffFail(err)
+			}
+		} else {
+			app := sumoapp.NewApplicationWithPath(appPath)
+			if err := app.LoadAppOverlays(); err != nil {
+				diffFail(fmt.Errorf("Unable to load app overlays: %w", err))
+			}
+
+			overlay, err := app.FindAppOverlay(diffRemoteOverlay)
+			if err != nil {
+				diffFail(err)
+			}
+
+			local, err = overlay.ToJSON()
+			if err != nil {
+				diffFail(err)
+			}
+		}
+
+		remoteFolder := sumoapp.NewFolder()
+		remoteFolder.Id = diffRemoteFolderId
+
+		client := newAPIClient()
+
+		remote, err := remoteFolder.Download(client)
+		if err != nil {
+			diffFail(fmt.Errorf("Unable to export folder %s: %w", diffRemoteFolderId, err))
+		}
+
+		remote, err = sumoapp.AddContentPermissions(context.Background(), client, diffRemoteFolderId, remote)
+		if err != nil {
+			diffFail(fmt.Errorf("Unable to read the permissions of folder %s: %w", diffRemoteFolderId, err))
+		}
+
+		rules, err := sumoapp.ReadNormalizationRules(appPath)
+		if err != nil {
+			diffFail(err)
+		}
+
+		cs, err := sumoapp.CompareContent(remote, local, rules)
+		if err != nil {
+			diffFail(err)
+		}
+
+		writeChanges(&cs)
+	},
+}
+
+func init() {
+	appCmd.AddCommand(diffRemoteCmd)
+
+	diffRemoteCmd.PersistentFlags().StringVarP(&diffRemoteFolderId, "folder", "f", "", "ID of the live folder to compare with")
+	diffRemoteCmd.PersistentFlags().StringVarP(&diffRemoteBuild, "build", "b", "", "Build file to compare with instead of an app overlay")
+	diffRemoteCmd.PersistentFlags().StringVarP(&diffRemoteOverlay, "app-overlay", "s", "final", "App overlay to compare with")
+	addDiffFlags(diffRemoteCmd)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	diffThreeWayStatuses []string
+)
+
+// diffThreeWayCmd represents the diff-three-way command
+var diffThreeWayCmd = &cobra.Command{
+	Use:   "diff-three-way [overlay name]",
+	Short: "Compare an app overlay's overrides with its parent and the merged result",
+	Long: `List every field set in an app overlay's files (final by default) with three
+values: the value inherited from the parent overlay, the value the overlay sets,
+and the value once the overlays are merged. Each field has a status:
+
+  added        the parent overlay doesn't have the field or object
+  override     the overlay changes the inherited value
+  redundant    the overlay sets the value it already inherits
+  ineffective  the merged value isn't the value the overlay sets. Merging
+               skips empty values such as "", 0, and false
+
+Use --status to list only some statuses, e.g. --status redundant --exit-code
+to fail a build when an overlay has overrides that can be removed.
+` + diffFlagsHelp,
+	Run: func(cmd *cobra.Command, args []string) {
+		overlayName := "final"
+
+		switch len(args) {
+		case 0:
+		case 1:
+			overlayName = args[0]
+		default:
+			diffFail(fmt.Errorf("too many arguments. Expects none or one. Use --help to learn more"))
+		}
+
+		app := sumoapp.NewApplicationWithPath(appPath)
+		if err := app.LoadAppOverlays(); err != nil {
+			diffFail(fmt.Errorf("Unable to load app overlays: %w", err))
+		}
+
+		overlay, err := app.FindAppOverlay(overlayName)
+		if err != nil {
+			diffFail(err)
+		}
+
+		d, err := overlay.ThreeWayDiff()
+		if err != nil {
+			diffFail(err)
+		}
+
+		if len(diffThreeWayStatuses) > 0 {
+			if err := d.KeepStatuses(diffThreeWayStatuses); err != nil {
+				diffFail(err)
+			}
+		}
+
+		writeChanges(d)
+	},
+}
+
+func init() {
+	appCmd.AddCommand(diffThreeWayCmd)
+
+	diffThreeWayCmd.PersistentFlags().StringSliceVar(&diffThreeWayStatuses, "status", nil, "Only list fields with these statuses")
+	addDiffFlags(diffThreeWayCmd)
+}
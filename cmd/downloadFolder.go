@@ -16,17 +16,17 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"sumologic.com/sumo-cli/sumoapp"
 )
 
 var (
 	downloadDestination string
+	downloadPermissions bool
 )
 
 // downloadCmd represents the push command
@@ -34,22 +34,16 @@ var downloadFoldersCmd = &cobra.Command{
 	Use:   "download-folder",
 	Short: "Download an application folder from your Sumo Logic account",
 	Args:  cobra.MinimumNArgs(1),
-	Long:  `Download an application folder from your Sumo Logic account.`,
+	Long: `Download an application folder from your Sumo Logic account.
+
+Use --permissions to add the permissions roles and users have on the folder,
+and on the folders and dashboards in it, to the download, so importing it keeps
+them in the app overlay. Reading permissions needs an access key that can
+manage the content.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var apiURL string
 
 		rootFolder := sumoapp.NewFolder()
 
-		accessId := viper.GetString("access-id")
-		accessKey := viper.GetString("access-key")
-		region := viper.GetString("deployment")
-
-		if region == "us1" {
-			apiURL = "https://api.sumologic.com/api"
-		} else {
-			apiURL = fmt.Sprintf("https://api.%s.sumologic.com/api", region)
-		}
-
 		if len(args) != 1 {
 			fmt.Fprintf(os.Stderr, "Error: Expects one argument. Use --help to learn more")
 			os.Exit(1)
@@ -57,23 +51,22 @@ var downloadFoldersCmd = &cobra.Command{
 
 		rootFolder.Id = args[0]
 
-		client := sumoapp.APIClient{
-			Cfg: &sumoapp.Configuration{
-				Authentication: sumoapp.BasicAuth{
-					AccessId:  accessId,
-					AccessKey: accessKey,
-				},
-				BasePath:   apiURL,
-				HTTPClient: &http.Client{},
-			},
-		}
+		client := newAPIClient()
 
-		fileBytes, err := rootFolder.Download(&client)
+		fileBytes, err := rootFolder.Download(client)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, err.Error())
 			os.Exit(1)
 		}
 
+		if downloadPermissions {
+			fileBytes, err = sumoapp.AddContentPermissions(context.Background(), client, rootFolder.Id, fileBytes)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Unable to read the permissions of folder %s: %s", rootFolder.Id, err)
+				os.Exit(1)
+			}
+		}
+
 		if downloadDestination == "-" {
 			fmt.Println(string(fileBytes))
 		} else {
@@ -86,4 +79,5 @@ func init() {
 	appCmd.AddCommand(downloadFoldersCmd)
 
 	downloadFoldersCmd.PersistentFlags().StringVarP(&downloadDestination, "output-file", "o", "-", "File to save the folder's output file to")
+	downloadFoldersCmd.PersistentFlags().BoolVar(&downloadPermissions, "permissions", false, "Add the permissions of the folder and its content to the download")
 }
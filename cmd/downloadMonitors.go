@@ -0,0 +1,66 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	downloadMonitorsDestination string
+)
+
+// downloadMonitorsCmd represents the download-monitors command
+var downloadMonitorsCmd = &cobra.Command{
+	Use:   "download-monitors [id]",
+	Short: "Download a monitors folder or monitor from your Sumo Logic account",
+	Long: `Download a folder or monitor from the Monitors Library of your Sumo Logic account
+with the Monitors API.
+
+Import the export with 'sumo app import' to add its monitors to an app overlay's
+monitors directory. The exported folder takes the place of the application's
+monitors folder, so its subfolders become the monitors' folder paths.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "Error: Expects one argument. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		fileBytes, err := sumoapp.DownloadMonitors(context.Background(), newAPIClient(), args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if downloadMonitorsDestination == "-" {
+			fmt.Println(string(fileBytes))
+		} else if err := os.WriteFile(downloadMonitorsDestination, fileBytes, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Unable to write to file %s. %s", downloadMonitorsDestination, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	appCmd.AddCommand(downloadMonitorsCmd)
+
+	downloadMonitorsCmd.PersistentFlags().StringVarP(&downloadMonitorsDestination, "output-file", "o", "-", "File to save the export to")
+}
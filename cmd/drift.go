@@ -0,0 +1,119 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	driftFolderId string
+	driftOutput   string
+)
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	Use:   "drift [build file]",
+	Short: "Detect changes made to deployed content outside of the application code",
+	Long: `Export the folder an application build was pushed to and compare it, object
+by object, with the build (./build.json by default). Objects that were changed,
+removed, or added in Sumo Logic since the build was pushed are reported.
+
+Both sides are normalized with the application's normalization rules, so
+server assigned IDs are ignored. Use --output json for machine readable output.
+
+Exit codes:
+  0  the deployed content is in sync with the build
+  1  the deployed content has drifted
+  2  an error occurred`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var buildPath string
+
+		switch len(args) {
+		case 0:
+			buildPath = "./build.json"
+		case 1:
+			buildPath = args[0]
+		default:
+			fmt.Fprintf(os.Stderr, "Error: too many arguments. Expects none or one. Use --help to learn more")
+			os.Exit(exitCodeError)
+		}
+
+		if driftFolderId == "" {
+			fmt.Fprintf(os.Stderr, "Error: --folder is required. Use --help to learn more")
+			os.Exit(exitCodeError)
+		}
+
+		if driftOutput != "text" && driftOutput != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown output format '%s'. Expects text or json", driftOutput)
+			os.Exit(exitCodeError)
+		}
+
+		localBuild, err := os.ReadFile(buildPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(exitCodeError)
+		}
+
+		deployedFolder := sumoapp.NewFolder()
+		deployedFolder.Id = driftFolderId
+
+		deployed, err := deployedFolder.Download(newAPIClient())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Unable to export folder %s: %s", driftFolderId, err)
+			os.Exit(exitCodeError)
+		}
+
+		rules, err := sumoapp.ReadNormalizationRules(appPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(exitCodeError)
+		}
+
+		report, err := sumoapp.DetectDrift(localBuild, deployed, rules)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(exitCodeError)
+		}
+
+		if driftOutput == "json" {
+			reportJSON, err := report.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(exitCodeError)
+			}
+
+			fmt.Print(string(reportJSON))
+		} else {
+			report.Display(os.Stdout)
+		}
+
+		if !report.InSync {
+			os.Exit(exitCodeChanges)
+		}
+	},
+}
+
+func init() {
+	appCmd.AddCommand(driftCmd)
+
+	driftCmd.PersistentFlags().StringVarP(&driftFolderId, "folder", "f", "", "ID of the deployed application folder")
+	driftCmd.PersistentFlags().StringVarP(&driftOutput, "output", "o", "text", "Output format: text or json")
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	ferPrune  bool
+	ferOutput string
+)
+
+// ferCmd represents the fer command
+var ferCmd = &cobra.Command{
+	Use:   "fer",
+	Short: "Manage field extraction rules as code",
+	Long: `Manage the field extraction rules (FERs) of your Sumo Logic org with YAML
+definitions. Each file maps a key to one or more rules:
+
+  parse-nginx:
+    name: Parse nginx
+    scope: _sourceCategory=nginx
+    parseexpression: parse "* * *" as method, path, status
+    enabled: true
+
+Rules are matched with the rules in the org by name. They're enabled unless
+they set enabled: false.`,
+}
+
+var ferExportCmd = &cobra.Command{
+	Use:   "export [dir]",
+	Short: "Write the org's field extraction rules to YAML files",
+	Long: `Write every field extraction rule in the org to its own YAML file in dir
+(./fers by default). Existing files of the same name are replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := ferDir(args)
+
+		rules, err := sumoapp.ListExtractionRules(context.Background(), newAPIClient())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if err := sumoapp.WriteExtractionRules(rules, dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Exported %d rule(s) to %s\n", len(rules), dir)
+	},
+}
+
+var ferPlanCmd = &cobra.Command{
+	Use:   "plan [dir]",
+	Short: "Show how the org's field extraction rules differ from their definitions",
+	Long: `Compare the rule definitions in dir (./fers by default), a directory of YAML
+files or a single file, with the rules in the org. The plan lists the rules
+'sumo fer apply' would create, update, or, with --prune, delete, followed by
+the diff of each rule. Use -o json for machine readable output.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		plan, err := sumoapp.PlanExtractionRules(context.Background(), newAPIClient(), ferDir(args), ferPrune)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		switch ferOutput {
+		case "text":
+			plan.Display(os.Stdout)
+		case "json":
+			j, err := plan.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(j))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: Unknown output format '%s'. Expected one of: %s", ferOutput, strings.Join([]string{"text", "json"}, ", "))
+			os.Exit(1)
+		}
+	},
+}
+
+var ferApplyCmd = &cobra.Command{
+	Use:   "apply [dir]",
+	Short: "Make the org's field extraction rules match their definitions",
+	Long: `Show the plan for the rule definitions in dir (./fers by default), then create
+and update the rules that changed. Rules in the org without a definition are
+only deleted with --prune. Rules that didn't change aren't touched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client := newAPIClient()
+
+		plan, err := sumoapp.PlanExtractionRules(ctx, client, ferDir(args), ferPrune)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		plan.Display(os.Stdout)
+		if !plan.HasChanges() {
+			return
+		}
+
+		fmt.Println()
+		if err := plan.Apply(ctx, client, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// ferDir returns the directory of rule definitions given as an argument
+func ferDir(args []string) string {
+	switch len(args) {
+	case 0:
+		return "fers"
+	case 1:
+		return args[0]
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: too many arguments. Expects none or one. Use --help to learn more")
+	os.Exit(1)
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(ferCmd)
+	ferCmd.AddCommand(ferExportCmd)
+	ferCmd.AddCommand(ferPlanCmd)
+	ferCmd.AddCommand(ferApplyCmd)
+
+	for _, c := range []*cobra.Command{ferPlanCmd, ferApplyCmd} {
+		c.Flags().BoolVar(&ferPrune, "prune", false, "Delete rules in the org that have no definition")
+	}
+
+	ferPlanCmd.Flags().StringVarP(&ferOutput, "output", "o", "text", "Output format: text or json")
+}
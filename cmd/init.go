@@ -0,0 +1,79 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	initAppName        string
+	initAppDescription string
+)
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init [path]",
+	Short: "Create a new application",
+	Long: `Create the directory layout of a new application. Each of the base,
+middle, and final app overlays gets a directory for its dashboards, folders,
+panels, saved searches, and variables. The application's name and description
+are written to base/init.yaml.
+
+The application is created in the --app-path directory unless a path is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var path string
+
+		switch len(args) {
+		case 0:
+			path = appPath
+		case 1:
+			path = args[0]
+		default:
+			fmt.Fprintf(os.Stderr, "Error: too many arguments. Expects none or one. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		name := initAppName
+		if name == "" {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			name = filepath.Base(absPath)
+		}
+
+		app := sumoapp.NewApplicationWithPath(path)
+		if err := app.Init(name, initAppDescription); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	appCmd.AddCommand(initCmd)
+
+	initCmd.PersistentFlags().StringVarP(&initAppName, "name", "n", "", "Name of the application (defaults to the directory name)")
+	initCmd.PersistentFlags().StringVar(&initAppDescription, "description", "", "Description of the application")
+}
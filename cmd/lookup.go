@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	lookupTable string
+	lookupMerge bool
+)
+
+// lookupCmd represents the lookup command
+var lookupCmd = &cobra.Command{
+	Use:   "lookup",
+	Short: "Manage lookup tables as code",
+	Long: `Manage the lookup tables of your Sumo Logic org with YAML schema definitions
+and CSV data files. Each YAML file maps a key to one or more tables:
+
+  hosts:
+    name: hosts
+    description: Owners of our hosts
+    parentfolderid: 0000000000AB1234
+    fields:
+      - fieldname: host
+        fieldtype: string
+      - fieldname: owner
+        fieldtype: string
+    primarykeys:
+      - host
+
+The rows of a table are kept in a CSV file next to its definition, named
+after the table's key (hosts.csv), or in the file set with data. The CSV
+file's header names the table's fields.
+
+Tables are matched with the tables in the org by name within their parent
+folder. Field types are string, boolean, int, long, and double. A table's
+fields and primary keys can't be changed once it's created.`,
+}
+
+var lookupPushCmd = &cobra.Command{
+	Use:   "push [dir]",
+	Short: "Create or update lookup tables and upload their rows",
+	Long: `Create the tables defined in dir (./lookups by default), a directory of YAML
+files or a single file, or update the description, TTL, and size limit
+action of the tables that already exist. Then upload each table's CSV file
+and wait for the upload job to finish.
+
+The uploaded rows replace the table's rows, or with --merge, are added to
+them, replacing rows with the same primary key. Use --table to push only one
+table. Every CSV file is checked against its table's fields before anything
+is changed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := sumoapp.PushLookupTables(context.Background(), newAPIClient(), lookupDir(args), lookupTable, lookupMerge, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var lookupPullCmd = &cobra.Command{
+	Use:   "pull [dir]",
+	Short: "Write the current rows of lookup tables to their CSV files",
+	Long: `Write the current rows of the tables defined in dir (./lookups by default) to
+their CSV files, replacing them. Rows are read with a cat search of each
+table and written with a column for each field. Use --table to pull only one
+table.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := sumoapp.PullLookupTables(context.Background(), newAPIClient(), lookupDir(args), lookupTable, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// lookupDir returns the directory of table definitions given as an
+// argument
+func lookupDir(args []string) string {
+	switch len(args) {
+	case 0:
+		return "lookups"
+	case 1:
+		return args[0]
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: too many arguments. Expects none or one. Use --help to learn more")
+	os.Exit(1)
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(lookupCmd)
+	lookupCmd.AddCommand(lookupPushCmd)
+	lookupCmd.AddCommand(lookupPullCmd)
+
+	lookupCmd.PersistentFlags().StringVarP(&lookupTable, "table", "t", "", "Key of the only table to push or pull")
+	lookupPushCmd.Flags().BoolVar(&lookupMerge, "merge", false, "Merge the uploaded rows with the table's rows instead of replacing them")
+}
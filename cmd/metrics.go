@@ -0,0 +1,179 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	metricsFrom         string
+	metricsTo           string
+	metricsQuantization time.Duration
+	metricsRollup       string
+	metricsOutput       string
+	metricsPanel        string
+	metricsOverlay      string
+	metricsVars         []string
+)
+
+// metricsCmd represents the metrics command
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Query Sumo Logic metrics",
+}
+
+var metricsQueryCmd = &cobra.Command{
+	Use:   "query [query]",
+	Short: "Run a metrics query and print its time series",
+	Long: `Run a metrics query with the Metrics Query API and print the time series
+it returns. For example:
+
+  sumo metrics query 'metric=CPU_Sys | avg by _sourceHost' --from -1h --quantization 5m
+
+--from and --to take the same times as 'sumo search'. Use --quantization and
+--rollup to choose how data points are aggregated, otherwise the API chooses.
+
+Use --panel instead of a query to run the metrics queries of a panel in the
+application's source (see --app-path and --app-overlay). The panel's queries
+run together, so they can refer to each other, over the panel's time range
+unless --from and --to are given. Variables are substituted like in
+'sumo app run panel'.
+
+Time series are written as a table by default, with a row per data point.
+Use -o csv or -o json for other formats.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 || (len(args) == 1) == (metricsPanel != "") {
+			fmt.Fprintf(os.Stderr, "Error: expects either a query or --panel. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		var (
+			queries    map[string]string
+			start, end time.Time
+			err        error
+		)
+		now := time.Now()
+
+		if metricsPanel == "" {
+			queries = map[string]string{"A": args[0]}
+
+			if start, err = sumoapp.ParseSearchTime(metricsFrom, now); err == nil {
+				end, err = sumoapp.ParseSearchTime(metricsTo, now)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+		} else {
+			vars := make(map[string]string)
+			for _, v := range metricsVars {
+				parts := strings.SplitN(v, "=", 2)
+				if len(parts) != 2 || parts[0] == "" {
+					fmt.Fprintf(os.Stderr, "Error: invalid --var '%s'. Expects name=value", v)
+					os.Exit(1)
+				}
+				vars[parts[0]] = parts[1]
+			}
+
+			app := sumoapp.NewApplicationWithPath(appPath)
+			if err := app.LoadAppOverlays(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			overlay, err := app.FindAppOverlay(metricsOverlay)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			panelQueries, err := overlay.PanelQueries(metricsPanel, "", vars)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			//Every query of a panel has the panel's time range
+			queries = make(map[string]string)
+			for _, q := range panelQueries {
+				if q.QueryType != sumoapp.MetricsQueryType {
+					continue
+				}
+
+				queries[q.QueryKey] = q.Query
+				start, end, err = q.TimeRange(flagValue(cmd, "from"), flagValue(cmd, "to"), now)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s", err)
+					os.Exit(1)
+				}
+			}
+
+			if len(queries) == 0 {
+				fmt.Fprintf(os.Stderr, "Error: panel '%s' has no metrics queries", metricsPanel)
+				os.Exit(1)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		result, err := sumoapp.QueryMetrics(ctx, newAPIClient(), queries, start, end, metricsQuantization, metricsRollup)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if err := result.Write(os.Stdout, metricsOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// flagValue returns the value of a flag the user set, or "" when the flag
+// wasn't set
+func flagValue(cmd *cobra.Command, name string) string {
+	if !cmd.Flags().Changed(name) {
+		return ""
+	}
+
+	return cmd.Flags().Lookup(name).Value.String()
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.AddCommand(metricsQueryCmd)
+
+	metricsQueryCmd.Flags().StringVar(&metricsFrom, "from", "-15m", "Start of the time range to query")
+	metricsQueryCmd.Flags().StringVar(&metricsTo, "to", "now", "End of the time range to query")
+	metricsQueryCmd.Flags().DurationVar(&metricsQuantization, "quantization", 0, "Aggregate data points into buckets of this size, such as 1m")
+	metricsQueryCmd.Flags().StringVar(&metricsRollup, "rollup", "", "How to aggregate data points in a bucket: Avg, Sum, Min, Max, or Count")
+	metricsQueryCmd.Flags().StringVarP(&metricsOutput, "output", "o", "table", "Output format: "+strings.Join(sumoapp.MetricsOutputFormats, ", "))
+	metricsQueryCmd.Flags().StringVar(&metricsPanel, "panel", "", "Run the metrics queries of this panel in the application's source")
+	metricsQueryCmd.Flags().StringVarP(&metricsOverlay, "app-overlay", "s", "final", "App overlay to take the panel from")
+	metricsQueryCmd.Flags().StringVarP(&appPath, "app-path", "p", ".", "The path to the application")
+	metricsQueryCmd.Flags().StringArrayVar(&metricsVars, "var", nil, "Set a variable's value as name=value. Can be given more than once")
+}
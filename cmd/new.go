@@ -0,0 +1,79 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	newAppOverlay string
+	newParent     string
+)
+
+// newCmd represents the new command
+var newCmd = &cobra.Command{
+	Use:   "new [" + strings.Join(sumoapp.ComponentKinds, "|") + "] [name]",
+	Short: "Generate a new application component",
+	Long: `Write a YAML stub for a new component to an app overlay.
+
+Dashboards, saved searches, and folders are added to the items of the folder
+given with --parent, or to the application's root folder if no parent is given.
+Panels and variables are added to the dashboard given with --parent. Monitors
+are put in the monitors folder path given with --parent, such as Latency/API.
+Parsers have no parent.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects a component kind and a name. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		kind := args[0]
+		name := args[1]
+
+		app := sumoapp.NewApplicationWithPath(appPath)
+		if err := app.LoadAppOverlays(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Unable to load app overlays: %s", err)
+			os.Exit(1)
+		}
+
+		overlay, err := app.FindAppOverlay(newAppOverlay)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		key, err := overlay.NewComponent(kind, name, newParent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Created %s '%s' in the %s app overlay\n", kind, key, newAppOverlay)
+	},
+}
+
+func init() {
+	appCmd.AddCommand(newCmd)
+
+	newCmd.PersistentFlags().StringVarP(&newAppOverlay, "app-overlay", "s", "base", "Which app overlay to write the component to")
+	newCmd.PersistentFlags().StringVarP(&newParent, "parent", "f", "", "Folder (or dashboard, for panels and variables, or monitors folder path, for monitors) to add the component to")
+}
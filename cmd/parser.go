@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	parserAppPath    string
+	parserAppOverlay string
+	parserOutput     string
+)
+
+// parserCmd represents the parser command
+var parserCmd = &cobra.Command{
+	Use:   "parser",
+	Short: "Work with the parsers of an application",
+	Long: `Parsers are kept in the parsers directory of an app overlay, one YAML file
+per parser. The code of a parser is a list of [stanzas] with KEY = value
+settings:
+
+  nginx-access:
+    name: Nginx access
+    code: |
+      [Default]
+      FORMAT = REGEX
+      REGEX = ^(?<client>\S+) \S+ \S+ \[(?<time>[^\]]+)\] "(?<method>\S+) (?<path>\S+)
+
+Parsers are imported, built, diffed, and pushed with the rest of the
+application. See 'sumo app push --help'.`,
+}
+
+var parserTestCmd = &cobra.Command{
+	Use:   "test [parser] [sample-log-file]",
+	Short: "Parse sample log lines with a parser and show the extracted fields",
+	Long: `Parse each line of a sample log file with a parser and show the fields it
+extracts. The parser is the key of a parser in an app overlay (final by default,
+or --app-overlay), or the path to a parser's YAML file.
+
+Parsers run locally, without a Sumo Logic org, so only the FORMAT of the
+[Default] stanza is applied, and only the JSON, REGEX, CSV, and KEY-VALUE formats
+are supported. Time parsing, mappings, and transforms aren't applied. The
+command exits with 1 when a line can't be parsed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects a parser and a sample log file. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		if parserOutput != "text" && parserOutput != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown output format '%s'. Expects text or json", parserOutput)
+			os.Exit(1)
+		}
+
+		parser, err := sumoapp.LoadParser(parserAppPath, parserAppOverlay, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		sample, err := os.Open(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+		defer sample.Close()
+
+		report, err := sumoapp.TestParser(parser, sample)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if parserOutput == "json" {
+			reportJSON, err := report.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			fmt.Print(string(reportJSON))
+		} else {
+			report.Display(os.Stdout)
+		}
+
+		if report.Parsed() < len(report.Results) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(parserCmd)
+	parserCmd.AddCommand(parserTestCmd)
+
+	parserCmd.PersistentFlags().StringVarP(&parserAppPath, "app-path", "p", ".", "The path to the application")
+	parserTestCmd.Flags().StringVarP(&parserAppOverlay, "app-overlay", "s", "final", "App overlay to take the parser from")
+	parserTestCmd.Flags().StringVarP(&parserOutput, "output", "o", "text", "Output format: text or json")
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	partitionsPrune  bool
+	partitionsOutput string
+)
+
+// partitionsCmd represents the partitions command
+var partitionsCmd = &cobra.Command{
+	Use:   "partitions",
+	Short: "Manage partitions as code",
+	Long: `Manage the partitions of your Sumo Logic org with YAML definitions. Each
+file maps a key to one or more partitions:
+
+  nginx:
+    name: nginx
+    routingexpression: _sourceCategory=nginx
+    analyticstier: continuous
+    retentionperiod: 30
+
+Partitions are matched with the active partitions in the org by name. The
+analytics tier defaults to continuous and can't be changed once the
+partition is created.`,
+}
+
+var partitionsExportCmd = &cobra.Command{
+	Use:   "export [dir]",
+	Short: "Write the org's partitions to YAML files",
+	Long: `Write every active partition in the org to its own YAML file in dir
+(./partitions by default). Existing files of the same name are replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := partitionsDir(args)
+
+		partitions, err := sumoapp.ListPartitions(context.Background(), newAPIClient())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if err := sumoapp.WritePartitions(partitions, dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Exported %d partition(s) to %s\n", len(partitions), dir)
+	},
+}
+
+var partitionsPlanCmd = &cobra.Command{
+	Use:   "plan [dir]",
+	Short: "Show how the org's partitions differ from their definitions",
+	Long: `Compare the partition definitions in dir (./partitions by default), a
+directory of YAML files or a single file, with the active partitions in the
+org. The plan lists the partitions 'sumo partitions apply' would create,
+update, or, with --prune, decommission, followed by the diff of each
+partition. Use -o json for machine readable output.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		plan, err := sumoapp.PlanPartitions(context.Background(), newAPIClient(), partitionsDir(args), partitionsPrune)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		switch partitionsOutput {
+		case "text":
+			plan.Display(os.Stdout)
+		case "json":
+			j, err := plan.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(j))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: Unknown output format '%s'. Expected one of: %s", partitionsOutput, strings.Join([]string{"text", "json"}, ", "))
+			os.Exit(1)
+		}
+	},
+}
+
+var partitionsApplyCmd = &cobra.Command{
+	Use:   "apply [dir]",
+	Short: "Make the org's partitions match their definitions",
+	Long: `Show the plan for the partition definitions in dir (./partitions by default),
+then create and update the partitions that changed. Partitions in the org
+without a definition are only decommissioned with --prune. Nothing is
+applied when the plan changes the analytics tier of a partition. Lowering a
+retention period doesn't delete data until the old period runs out.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client := newAPIClient()
+
+		plan, err := sumoapp.PlanPartitions(ctx, client, partitionsDir(args), partitionsPrune)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		plan.Display(os.Stdout)
+		if !plan.HasChanges() {
+			return
+		}
+
+		fmt.Println()
+		if err := plan.Apply(ctx, client, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// partitionsDir returns the directory of partition definitions given as an
+// argument
+func partitionsDir(args []string) string {
+	switch len(args) {
+	case 0:
+		return "partitions"
+	case 1:
+		return args[0]
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: too many arguments. Expects none or one. Use --help to learn more")
+	os.Exit(1)
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(partitionsCmd)
+	partitionsCmd.AddCommand(partitionsExportCmd)
+	partitionsCmd.AddCommand(partitionsPlanCmd)
+	partitionsCmd.AddCommand(partitionsApplyCmd)
+
+	for _, c := range []*cobra.Command{partitionsPlanCmd, partitionsApplyCmd} {
+		c.Flags().BoolVar(&partitionsPrune, "prune", false, "Decommission partitions in the org that have no definition")
+	}
+
+	partitionsPlanCmd.Flags().StringVarP(&partitionsOutput, "output", "o", "text", "Output format: text or json")
+}
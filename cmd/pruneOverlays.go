@@ -0,0 +1,87 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	pruneDryRun bool
+	pruneOutput string
+)
+
+// pruneOverlaysCmd represents the prune-overlays command
+var pruneOverlaysCmd = &cobra.Command{
+	Use:   "prune-overlays",
+	Short: "Remove overlay overrides that make no difference",
+	Long: `Compare every field set in the middle and final app overlays with the value
+it inherits from the parent overlay, and remove the overrides that set the
+value they already inherit. This happens when base content is upgraded to
+include a change an overlay was made for.
+
+Overlay files are rewritten without the redundant overrides, and files left
+without any objects are deleted. Use --dry-run to only report what would be
+removed. Overrides whose value is skipped by merging (see diff-three-way) are
+left alone.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			fmt.Fprintf(os.Stderr, "Error: too many arguments. Expects none. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		if pruneOutput != "text" && pruneOutput != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown output format '%s'. Expects text or json", pruneOutput)
+			os.Exit(1)
+		}
+
+		app := sumoapp.NewApplicationWithPath(appPath)
+		if err := app.LoadAppOverlays(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		report, err := app.PruneOverlays(pruneDryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if pruneOutput == "json" {
+			reportJSON, err := report.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			fmt.Print(string(reportJSON))
+			return
+		}
+
+		report.Display(os.Stdout)
+	},
+}
+
+func init() {
+	appCmd.AddCommand(pruneOverlaysCmd)
+
+	pruneOverlaysCmd.PersistentFlags().BoolVar(&pruneDryRun, "dry-run", false, "Report the redundant overrides without changing any files")
+	pruneOverlaysCmd.PersistentFlags().StringVarP(&pruneOutput, "output", "o", "text", "Output format: text or json")
+}
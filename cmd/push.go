@@ -16,10 +16,11 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -30,6 +31,9 @@ var (
 	appDestinationParent    string
 	appDestinationName      string
 	appDestinationOverwrite bool
+	pushManifestFile        string
+	pushMonitorsFolder      string
+	pushParsersFolder       string
 )
 
 // pushCmd represents the push command
@@ -38,23 +42,34 @@ var pushCmd = &cobra.Command{
 	Short: "Push an application build to your Sumo Logic account",
 	Args:  cobra.MinimumNArgs(1),
 	Long: `Push an application build (a json file, see 'sumo app build --help' for more information) to
-your Sumo Logic organization.`,
+your Sumo Logic organization.
+
+If the build has a manifest (build.manifest.json next to build.json, or the file
+given with --manifest), the build is checked against it before it's pushed.
+Once the push succeeds, the manifest is written to a push record next to the
+build (build.push.json) along with the deployment, parent folder, and time it
+was pushed, and a one line summary of it is printed. Deployed content isn't
+changed to record the build.
+
+Monitors in the build are pushed to the Monitors Library after the content, into
+a folder named after the application below --monitors-folder (the library's
+root folder by default). Folders and monitors are matched by name: missing ones
+are created and existing monitors are updated.
+
+Parsers in the build are pushed to the Parsers library last, into a folder named
+after the application below --parsers-folder, which replaces the parsers pushed
+before. Builds with parsers can only be pushed with --parsers-folder.
+
+Permissions declared on the application's root folder, folders, and dashboards
+are applied once the content is imported, so push waits for the import to finish
+when the build declares permissions. The View, Edit, and Manage permissions
+of roles and users on those items are made to match the declared ones. Items
+without declared permissions keep the permissions they have.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var apiURL string
 		var buildPath string
 
 		rootFolder := sumoapp.NewFolder()
 
-		accessId := viper.GetString("access-id")
-		accessKey := viper.GetString("access-key")
-		region := viper.GetString("deployment")
-
-		if region == "us1" {
-			apiURL = "https://api.sumologic.com/api"
-		} else {
-			apiURL = fmt.Sprintf("https://api.%s.sumologic.com/api", region)
-		}
-
 		switch len(args) {
 		case 0:
 			buildPath = "./build.json"
@@ -84,23 +99,105 @@ your Sumo Logic organization.`,
 			os.Exit(1)
 		}
 
-		client := sumoapp.APIClient{
-			Cfg: &sumoapp.Configuration{
-				Authentication: sumoapp.BasicAuth{
-					AccessId:  accessId,
-					AccessKey: accessKey,
-				},
-				BasePath:   apiURL,
-				HTTPClient: &http.Client{},
-			},
+		//The build's manifest is recorded next to the build once it's pushed,
+		//so it can be told exactly which build is deployed
+		manifest, err := sumoapp.FindBuildManifest(buildPath, pushManifestFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if manifest != nil {
+			if err := manifest.Verify(data); err != nil {
+				fmt.Fprintf(os.Stderr, err.Error())
+				os.Exit(1)
+			}
 		}
 
+		//Check the parsers can be pushed before anything is uploaded
+		parsers, err := sumoapp.ReadBuildParsers(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if parsers != nil && pushParsersFolder == "" {
+			fmt.Fprintf(os.Stderr, "Error: The build has parsers. Use --parsers-folder with the ID of the Parsers library folder to put them into")
+			os.Exit(1)
+		}
+
+		//Permissions are applied once the content is imported
+		permissions, err := rootFolder.TakePermissions()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		client := newAPIClient()
+
 		should_overwrite, _ := cmd.Flags().GetBool("overwrite")
 
-		if err := rootFolder.Upload(&client, appDestinationParent, should_overwrite); err != nil {
+		//The import has to finish before permissions can be applied to the
+		//imported content. Without permissions it's left to run on its own
+		if permissions.HasPermissions() {
+			if err := rootFolder.UploadAndWait(context.Background(), client, appDestinationParent, should_overwrite); err != nil {
+				fmt.Fprintf(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			if err := permissions.Apply(context.Background(), client, appDestinationParent, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+		} else if err := rootFolder.Upload(client, appDestinationParent, should_overwrite); err != nil {
 			fmt.Fprintf(os.Stderr, err.Error())
 			os.Exit(1)
 		}
+
+		monitors, err := sumoapp.ReadBuildMonitors(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if monitors != nil {
+			result, err := monitors.Upsert(context.Background(), client, pushMonitorsFolder)
+			if result != nil {
+				result.Display(os.Stdout)
+			}
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+		}
+
+		if parsers != nil {
+			if err := parsers.Upload(context.Background(), client, pushParsersFolder); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Pushed %d parser(s) to the Parsers library\n", len(parsers.Children))
+		}
+
+		if manifest != nil {
+			recordFile := sumoapp.PushRecordPath(buildPath)
+			record := manifest.NewPushRecord(viper.GetString("deployment"), appDestinationParent, time.Now())
+
+			recordJSON, err := record.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			if err := os.WriteFile(recordFile, recordJSON, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Unable to write to file %s. %s", recordFile, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Pushed build %s\nRecorded its manifest in %s\n", manifest.Summary(), recordFile)
+		}
 	},
 }
 
@@ -109,4 +206,7 @@ func init() {
 
 	pushCmd.PersistentFlags().StringVarP(&appDestinationParent, "parent-folder", "d", "", "ID of the folder to put the application into")
 	pushCmd.PersistentFlags().BoolP("overwrite", "w", false, "Whether to overwrite an existing destination folder")
+	pushCmd.PersistentFlags().StringVar(&pushManifestFile, "manifest", "", "Build manifest to check the build against and record with the push (defaults to the manifest next to the build file)")
+	pushCmd.PersistentFlags().StringVar(&pushMonitorsFolder, "monitors-folder", "", "ID of the Monitors Library folder to put the application's monitors into (defaults to the library's root folder)")
+	pushCmd.PersistentFlags().StringVar(&pushParsersFolder, "parsers-folder", "", "ID of the Parsers library folder to put the application's parsers into")
 }
@@ -26,6 +26,10 @@ import (
 
 var cfgFile string
 
+// Version is the version of the CLI. Release builds set it with
+// -ldflags "-X sumologic.com/sumo-cli/cmd.Version=<version>"
+var Version = "dev"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "sumo",
@@ -49,6 +53,8 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 
+	rootCmd.Version = Version
+
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
@@ -0,0 +1,208 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	runOverlay        string
+	runVars           []string
+	runFrom           string
+	runTo             string
+	runOutput         string
+	runQueryKey       string
+	runRequireResults bool
+)
+
+// runReport is the outcome of running queries from the application source
+type runReport interface {
+	Display(w io.Writer)
+	ToJSON() ([]byte, error)
+	Failed(requireResults bool) bool
+}
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the queries of saved searches and panels",
+	Long: `Run the queries defined in the application's source to check that they
+work and return data before the application is pushed. Queries are taken
+from the merged objects of an app overlay (final by default, or
+--app-overlay).
+
+{{variable}} references in a query are replaced with the default value of
+the variable, or of the saved search's query parameter. Use --var name=value
+to set a value instead. Queries run over the time range set in the source,
+or the last 15 minutes when there isn't one. --from and --to override it.
+
+Logs queries run as a search job, which is deleted afterwards, and metrics
+queries run with the Metrics Query API. The command reports the messages
+and records, or time series, each query returned, and exits with 1 when a
+query fails. Use --require-results to also fail when a query returns
+nothing.`,
+}
+
+var runSavedSearchCmd = &cobra.Command{
+	Use:   "saved-search [key]",
+	Short: "Run the query of a saved search",
+	Long:  `Run the query of a saved search in the application's source. See 'sumo app run --help'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects a saved search key. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		vars, err := parseRunFlags()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		app := sumoapp.NewApplicationWithPath(appPath)
+		if err := app.LoadAppOverlays(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		overlay, err := app.FindAppOverlay(runOverlay)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		query, err := overlay.SavedSearchQuery(args[0], vars)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		writeRunReport(sumoapp.RunQueries(ctx, newAPIClient(), runFrom, runTo, *query))
+	},
+}
+
+var runPanelCmd = &cobra.Command{
+	Use:   "panel [key]",
+	Short: "Run the queries of a panel",
+	Long: `Run the queries of a panel in the application's source, or only one of
+them with --query. Variables take the default values of the dashboards the
+panel is on, and the panel's time range is used, or else its dashboard's.
+See 'sumo app run --help'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects a panel key. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		vars, err := parseRunFlags()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		app := sumoapp.NewApplicationWithPath(appPath)
+		if err := app.LoadAppOverlays(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		overlay, err := app.FindAppOverlay(runOverlay)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		queries, err := overlay.PanelQueries(args[0], runQueryKey, vars)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		writeRunReport(sumoapp.RunQueries(ctx, newAPIClient(), runFrom, runTo, queries...))
+	},
+}
+
+// parseRunFlags checks the output format and reads the name=value pairs
+// given with --var
+func parseRunFlags() (map[string]string, error) {
+	if runOutput != "text" && runOutput != "json" {
+		return nil, fmt.Errorf("unknown output format '%s'. Expects text or json", runOutput)
+	}
+
+	vars := make(map[string]string)
+
+	for _, v := range runVars {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --var '%s'. Expects name=value", v)
+		}
+
+		vars[parts[0]] = parts[1]
+	}
+
+	return vars, nil
+}
+
+// writeRunReport writes the report in the chosen output format and exits
+// with 1 when a query failed
+func writeRunReport(report runReport) {
+	if runOutput == "json" {
+		reportJSON, err := report.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Print(string(reportJSON))
+	} else {
+		report.Display(os.Stdout)
+	}
+
+	if report.Failed(runRequireResults) {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	appCmd.AddCommand(runCmd)
+	runCmd.AddCommand(runSavedSearchCmd)
+	runCmd.AddCommand(runPanelCmd)
+
+	runCmd.PersistentFlags().StringVarP(&runOverlay, "app-overlay", "s", "final", "App overlay to take the queries from")
+	runCmd.PersistentFlags().StringArrayVar(&runVars, "var", nil, "Set a variable's value as name=value. Can be given more than once")
+	runCmd.PersistentFlags().StringVar(&runFrom, "from", "", "Start of the time range to search (default the source's time range)")
+	runCmd.PersistentFlags().StringVar(&runTo, "to", "", "End of the time range to search (default the source's time range)")
+	runCmd.PersistentFlags().StringVarP(&runOutput, "output", "o", "text", "Output format: text or json")
+	runCmd.PersistentFlags().BoolVar(&runRequireResults, "require-results", false, "Exit with 1 when a query returns nothing")
+
+	runPanelCmd.Flags().StringVar(&runQueryKey, "query", "", "Only run the query with this key, such as A")
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	schemaOutputDir string
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema [component]",
+	Short: "Print the JSON Schema for application overlay files",
+	Long: `Print the JSON Schema describing the YAML files of an application overlay
+component. Valid components are: ` + strings.Join(sumoapp.SchemaComponents(), ", ") + `.
+
+Point your editor's YAML language server at the schema to get completion and
+validation while editing overlay files. Use --output-dir to write the schema
+of every component to <component>.schema.json files in a directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if schemaOutputDir != "" {
+			if len(args) != 0 {
+				fmt.Fprintf(os.Stderr, "Error: a component can't be given with --output-dir. Use --help to learn more")
+				os.Exit(1)
+			}
+
+			if err := os.MkdirAll(schemaOutputDir, 0755); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+
+			for _, component := range sumoapp.SchemaComponents() {
+				schema, err := sumoapp.ComponentSchema(component)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s", err)
+					os.Exit(1)
+				}
+
+				filePath := filepath.Join(schemaOutputDir, component+".schema.json")
+				if err := os.WriteFile(filePath, schema, 0644); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: Unable to write to file %s. %s", filePath, err)
+					os.Exit(1)
+				}
+			}
+
+			return
+		}
+
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects one component. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		schema, err := sumoapp.ComponentSchema(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Println(string(schema))
+	},
+}
+
+func init() {
+	appCmd.AddCommand(schemaCmd)
+
+	schemaCmd.PersistentFlags().StringVarP(&schemaOutputDir, "output-dir", "o", "", "Directory to write the schema of every component to")
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	searchFrom          string
+	searchTo            string
+	searchOutput        string
+	searchMode          string
+	searchFields        []string
+	searchLimit         int
+	searchByReceiptTime bool
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Run a log search and stream its results",
+	Long: `Run a log search with the Search Job API and stream its results to stdout
+as they become available. For example:
+
+  sumo search '_sourceCategory=prod/app error' --from -15m --to now
+
+--from and --to take now, times relative to now such as -15m, -1h30m, -2d,
+and -1w, RFC 3339 times, dates, or 13 digit epoch milliseconds.
+
+Searches return the messages they match, which are streamed while the search
+runs. Searches with an aggregating operator such as count or sum return
+records instead, which are written once the search is done. Use --mode to
+choose messages or records yourself.
+
+Results are written as JSON lines by default. Use -o csv or -o table for
+other formats and --fields to choose the fields written. Tables of messages
+show the message time and raw message unless --fields is given.
+
+The search job is deleted when the search is done, fails, or is interrupted
+with Ctrl-C.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "Error: wrong number of arguments. Expects a query. Use --help to learn more")
+			os.Exit(1)
+		}
+
+		if err := runSearch(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runSearch runs query with the search flags and writes its results to
+// stdout. The search job is deleted when the search ends or is interrupted
+func runSearch(query string) error {
+	now := time.Now()
+
+	from, err := sumoapp.ParseSearchTime(searchFrom, now)
+	if err != nil {
+		return err
+	}
+
+	to, err := sumoapp.ParseSearchTime(searchTo, now)
+	if err != nil {
+		return err
+	}
+
+	writer, err := sumoapp.NewSearchResultWriter(os.Stdout, searchOutput, searchFields)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	job, err := sumoapp.NewSearchJob(ctx, newAPIClient(), query, from, to, searchByReceiptTime)
+	if err != nil {
+		return err
+	}
+
+	streamErr := job.Stream(ctx, searchMode, searchLimit, writer.Write)
+
+	if err := job.Delete(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to delete search job %s. %s\n", job.Id, err)
+	}
+
+	for _, warning := range job.Warnings {
+		fmt.Fprintln(os.Stderr, "Warning:", warning)
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("search interrupted")
+	}
+
+	return streamErr
+}
+
+// addSearchFlags adds the flags that choose a search's time range and how
+// its results are written
+func addSearchFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&searchFrom, "from", "-15m", "Start of the time range to search")
+	cmd.PersistentFlags().StringVar(&searchTo, "to", "now", "End of the time range to search")
+	cmd.PersistentFlags().StringVarP(&searchOutput, "output", "o", "jsonl", "Output format: "+strings.Join(sumoapp.SearchOutputFormats, ", "))
+	cmd.PersistentFlags().StringVar(&searchMode, "mode", sumoapp.SearchModeAuto, "Results to return: auto, messages, or records")
+	cmd.PersistentFlags().StringSliceVar(&searchFields, "fields", nil, "Comma separated fields to write (default all fields)")
+	cmd.PersistentFlags().IntVar(&searchLimit, "limit", 0, "Stop after this many results (default no limit)")
+	cmd.PersistentFlags().BoolVar(&searchByReceiptTime, "by-receipt-time", false, "Search by the time messages were received instead of their message time")
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	addSearchFlags(searchCmd)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright © 2021 Carl Caum <carl@carlcaum.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sumologic.com/sumo-cli/sumoapp"
+)
+
+var (
+	viewsPrune  bool
+	viewsOutput string
+)
+
+// viewsCmd represents the views command
+var viewsCmd = &cobra.Command{
+	Use:   "views",
+	Short: "Manage scheduled views as code",
+	Long: `Manage the scheduled views of your Sumo Logic org with YAML definitions. Each
+file maps a key to one or more views:
+
+  nginx-errors:
+    indexname: nginx_errors
+    query: _sourceCategory=nginx error | count by _sourceHost
+    starttime: "2022-01-01T00:00:00Z"
+    retentionperiod: 90
+
+Views are matched with the views in the org by index name. The parsing mode
+defaults to Manual. A view's query, start time, and parsing mode can't be
+changed once it's created.`,
+}
+
+var viewsExportCmd = &cobra.Command{
+	Use:   "export [dir]",
+	Short: "Write the org's scheduled views to YAML files",
+	Long: `Write every scheduled view in the org to its own YAML file in dir (./views
+by default). Existing files of the same name are replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := viewsDir(args)
+
+		views, err := sumoapp.ListScheduledViews(context.Background(), newAPIClient())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		if err := sumoapp.WriteScheduledViews(views, dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Exported %d view(s) to %s\n", len(views), dir)
+	},
+}
+
+var viewsPlanCmd = &cobra.Command{
+	Use:   "plan [dir]",
+	Short: "Show how the org's scheduled views differ from their definitions",
+	Long: `Compare the view definitions in dir (./views by default), a directory of YAML
+files or a single file, with the scheduled views in the org. The plan lists
+the views 'sumo views apply' would create, update, or, with --prune,
+disable, followed by the diff of each view. Use -o json for machine
+readable output.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		plan, err := sumoapp.PlanScheduledViews(context.Background(), newAPIClient(), viewsDir(args), viewsPrune)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		switch viewsOutput {
+		case "text":
+			plan.Display(os.Stdout)
+		case "json":
+			j, err := plan.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(j))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: Unknown output format '%s'. Expected one of: %s", viewsOutput, strings.Join([]string{"text", "json"}, ", "))
+			os.Exit(1)
+		}
+	},
+}
+
+var viewsApplyCmd = &cobra.Command{
+	Use:   "apply [dir]",
+	Short: "Make the org's scheduled views match their definitions",
+	Long: `Show the plan for the view definitions in dir (./views by default), then
+create and update the views that changed. Views in the org without a
+definition are only disabled with --prune. Nothing is applied when the plan
+changes the query, start time, or parsing mode of a view; define a view
+with a new index name instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		client := newAPIClient()
+
+		plan, err := sumoapp.PlanScheduledViews(ctx, client, viewsDir(args), viewsPrune)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+
+		plan.Display(os.Stdout)
+		if !plan.HasChanges() {
+			return
+		}
+
+		fmt.Println()
+		if err := plan.Apply(ctx, client, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// viewsDir returns the directory of view definitions given as an argument
+func viewsDir(args []string) string {
+	switch len(args) {
+	case 0:
+		return "views"
+	case 1:
+		return args[0]
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: too many arguments. Expects none or one. Use --help to learn more")
+	os.Exit(1)
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(viewsCmd)
+	viewsCmd.AddCommand(viewsExportCmd)
+	viewsCmd.AddCommand(viewsPlanCmd)
+	viewsCmd.AddCommand(viewsApplyCmd)
+
+	for _, c := range []*cobra.Command{viewsPlanCmd, viewsApplyCmd} {
+		c.Flags().BoolVar(&viewsPrune, "prune", false, "Disable views in the org that have no definition")
+	}
+
+	viewsPlanCmd.Flags().StringVarP(&viewsOutput, "output", "o", "text", "Output format: text or json")
+}
@@ -0,0 +1,116 @@
+package sumoapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// apiErrorResponse covers the error bodies returned by the Sumo Logic APIs.
+// The v1 APIs return a single message while the v2 APIs return a list
+type apiErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Errors  []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Meta    struct {
+			Reason string `json:"reason"`
+		} `json:"meta"`
+	} `json:"errors"`
+}
+
+// requestJSON calls an API endpoint under the configured base path with an
+// optional JSON body and decodes the JSON response into result when result
+// isn't nil. Responses with an error status are returned as a
+// GenericSwaggerError with the API's error message
+func (a *APIClient) requestJSON(ctx context.Context, method string, path string, query url.Values, body interface{}, result interface{}) error {
+	_, err := a.requestJSONWithHeaders(ctx, method, path, query, nil, body, result)
+	return err
+}
+
+// requestJSONWithHeaders is requestJSON with extra request headers, such
+// as If-Match. It returns the response's headers
+func (a *APIClient) requestJSONWithHeaders(ctx context.Context, method string, path string, query url.Values, headers map[string]string, body interface{}, result interface{}) (http.Header, error) {
+	headerParams := map[string]string{
+		"Accept": "application/json",
+	}
+
+	for name, value := range headers {
+		headerParams[name] = value
+	}
+
+	if body != nil {
+		headerParams["Content-Type"] = "application/json"
+	}
+
+	r, err := a.prepareRequest(a.Cfg.BasePath+path, strings.ToUpper(method), body, headerParams, query, nil, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.doJSON(ctx, r, method, path, result)
+}
+
+// requestFile calls an API endpoint with a file as a multipart form, and
+// decodes the JSON response into result like requestJSON
+func (a *APIClient) requestFile(ctx context.Context, method string, path string, query url.Values, fileName string, fileBytes []byte, result interface{}) error {
+	headerParams := map[string]string{
+		"Accept": "application/json",
+	}
+
+	r, err := a.prepareRequest(a.Cfg.BasePath+path, strings.ToUpper(method), nil, headerParams, query, nil, fileName, fileBytes)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.doJSON(ctx, r, method, path, result)
+	return err
+}
+
+// doJSON sends a prepared request and decodes its JSON response, turning
+// error statuses into errors with the API's message
+func (a *APIClient) doJSON(ctx context.Context, r *http.Request, method string, path string, result interface{}) (http.Header, error) {
+	response, err := a.callAPI(r.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= 300 {
+		newErr := GenericSwaggerError{
+			body:  responseBody,
+			error: response.Status,
+		}
+
+		var v apiErrorResponse
+		if err := json.Unmarshal(responseBody, &v); err == nil {
+			newErr.model = v
+			switch {
+			case len(v.Errors) > 0 && v.Errors[0].Meta.Reason != "":
+				newErr.error = v.Errors[0].Message + ": " + v.Errors[0].Meta.Reason
+			case len(v.Errors) > 0:
+				newErr.error = v.Errors[0].Message
+			case v.Message != "":
+				newErr.error = v.Message
+			}
+		}
+
+		return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, newErr)
+	}
+
+	if result == nil || len(responseBody) == 0 {
+		return response.Header, nil
+	}
+
+	return response.Header, a.decode(result, responseBody, response.Header.Get("Content-Type"))
+}
@@ -67,7 +67,7 @@ func processChild(obj interface{}, parent *folder, overlay *appOverlay) error {
 		safeName := sanitizeName(folderObj.Name)
 		overlay.Folders[safeName] = folderObj
 
-		parent.Items["folders"] = append(parent.Items["folders"], safeName)
+		addItem(parent, "folders", safeName)
 		processChildren(folderObj, overlay)
 
 	case DashboardType:
@@ -87,7 +87,11 @@ func processChild(obj interface{}, parent *folder, overlay *appOverlay) error {
 		//Add each of the variables to the application
 		for _, variableObj := range dashboardObj.Variables {
 			overlay.Variables[variableObj.Name] = variableObj
-			dashboardObj.IncludeVariables = append(dashboardObj.IncludeVariables, variableObj.Name)
+
+			//Builds already list the variables they include
+			if !containsString(dashboardObj.IncludeVariables, variableObj.Name) {
+				dashboardObj.IncludeVariables = append(dashboardObj.IncludeVariables, variableObj.Name)
+			}
 		}
 
 		//Remove these objects from the user facing code files
@@ -97,7 +101,7 @@ func processChild(obj interface{}, parent *folder, overlay *appOverlay) error {
 
 		overlay.Dashboards[safeName] = &dashboardObj
 
-		parent.Items["dashboards"] = append(parent.Items["dashboards"], safeName)
+		addItem(parent, "dashboards", safeName)
 
 	case SavedSearchType:
 		var searchObj savedSearch
@@ -110,7 +114,7 @@ func processChild(obj interface{}, parent *folder, overlay *appOverlay) error {
 
 		overlay.SavedSearches[safeName] = &searchObj
 
-		parent.Items["savedSearches"] = append(parent.Items["savedSearches"], safeName)
+		addItem(parent, "savedSearches", safeName)
 
 	default:
 		errMessage := fmt.Sprintf("Unknown child type: %s", childType)
@@ -119,6 +123,16 @@ func processChild(obj interface{}, parent *folder, overlay *appOverlay) error {
 
 	return nil
 }
+
+// addItem adds a child to a folder's items. Folders in builds, unlike
+// folders exported from Sumo Logic, already list their items, so
+// importing a build doesn't add them twice
+func addItem(parent *folder, itemType string, name string) {
+	if !containsString(parent.Items[itemType], name) {
+		parent.Items[itemType] = append(parent.Items[itemType], name)
+	}
+}
+
 func (a *application) BasePath() string {
 	return a.path
 }
@@ -132,8 +146,6 @@ func (a *application) ImportToOverlay(pathToFileToImport string, overlay *appOve
 	var data []byte
 	const maxCapacity = 512 * 1024
 
-	rootFolder := NewFolder()
-
 	//Read the JSON file or stdnin and load it into objects
 	if pathToFileToImport == "-" {
 		ioReader = os.Stdin
@@ -158,13 +170,35 @@ func (a *application) ImportToOverlay(pathToFileToImport string, overlay *appOve
 		return err
 	}
 
-	fmt.Println(string(data))
+	return a.ImportBytesToOverlay(data, overlay)
+}
+
+// ImportBytesToOverlay breaks an exported folder, such as a build file or
+// the output of Download, into components in the overlay. Exports of the
+// Monitors Library and of the Parsers library add their monitors or
+// parsers to the overlay
+func (a *application) ImportBytesToOverlay(data []byte, overlay *appOverlay) error {
+	//Monitors exported from the Monitors Library only add monitors
+	var export struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &export); err == nil && (export.Type == MonitorFolderType || export.Type == MonitorType) {
+		return a.importMonitorsExport(data, overlay)
+	}
+
+	//Parsers exported from the Parsers library only add parsers
+	if export.Type == ParserFolderType || export.Type == ParserType {
+		return a.importParsersExport(data, overlay)
+	}
+
+	rootFolder := NewFolder()
+
 	if err := json.Unmarshal(data, rootFolder); err != nil {
 		if jsonErr, ok := err.(*json.SyntaxError); ok {
 			problemPart := data[jsonErr.Offset-10 : jsonErr.Offset+10]
 			err = fmt.Errorf("%w ~ error near '%s' (offset %d)", err, problemPart, jsonErr.Offset)
-			return err
 		}
+		return err
 	}
 
 	overlay.RootFolder = rootFolder
@@ -177,12 +211,38 @@ func (a *application) ImportToOverlay(pathToFileToImport string, overlay *appOve
 		return err
 	}
 
+	//Builds keep the application's monitors and parsers next to its content
+	monitors, err := ReadBuildMonitors(data)
+	if err != nil {
+		return err
+	}
+
+	if monitors != nil {
+		importMonitors(monitors, "", overlay)
+	}
+
+	parsers, err := ReadBuildParsers(data)
+	if err != nil {
+		return err
+	}
+
+	if parsers != nil {
+		for _, p := range parsers.Children {
+			importParser(p, overlay)
+		}
+	}
+
+	//Strip the server assigned fields so importing the same content
+	//again doesn't change the overlay files
+	overlay.Normalize(a.NormalizationRules())
+
 	//The root folder of the imported file is essentially the application. The root folder's
 	//items (dashboards, folders, saved searches), name, and description need to moved to the
 	//application object
 	a.Name = rootFolder.Name
 	a.Description = rootFolder.Description
 	a.Items = rootFolder.Items
+	a.Permissions = rootFolder.Permissions
 
 	return nil
 }
@@ -197,7 +257,9 @@ func (a *application) ImportWithWriteOption(pathToFileToImport string, appoverla
 		return fmt.Errorf("Could not find app overlay %s", appoverlay)
 	}
 
-	a.ImportToOverlay(pathToFileToImport, overlay)
+	if err := a.ImportToOverlay(pathToFileToImport, overlay); err != nil {
+		return err
+	}
 
 	if writeObjects {
 		if err := overlay.WriteObjects(); err != nil {
@@ -214,6 +276,10 @@ func (a *application) NewAppOverlay(name string) *appOverlay {
 }
 
 func (a *application) LoadAppOverlays() error {
+	if err := a.LoadNormalizationRules(); err != nil {
+		return err
+	}
+
 	baseOverlay := a.NewAppOverlay("base")
 	midOverlay := a.NewAppOverlay("middle")
 	finalOverlay := a.NewAppOverlay("final")
@@ -231,6 +297,8 @@ func (a *application) LoadAppOverlays() error {
 			return err
 		}
 
+		overlay.Normalize(a.normalization)
+
 		a.appOverlays = append(a.appOverlays, overlay)
 	}
 
@@ -249,8 +317,9 @@ func (a *application) FindAppOverlay(name string) (*appOverlay, error) {
 }
 
 func (a *application) ToJSON() ([]byte, error) {
-	//Compile into JSON return
-	jsonByteString, err := json.Marshal(a)
+	//Compile into canonical JSON so identical sources
+	//always produce byte for byte identical builds
+	jsonByteString, err := canonicalJSON(a)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,59 @@
+package sumoapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// sortedKeys returns the keys of a map with string keys in sorted order.
+// Ranging over a Go map has no stable order, so anything that produces
+// output from a map should iterate over its sorted keys instead
+func sortedKeys(m interface{}) []string {
+	mapValue := reflect.ValueOf(m)
+
+	keys := make([]string, 0, mapValue.Len())
+	for _, key := range mapValue.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// canonicalJSON marshals v with two space indentation, without escaping
+// HTML characters (queries are full of '<', '>' and '&') and with a
+// trailing newline. Identical objects always produce identical bytes
+func canonicalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeYamlFile marshals v to the file at filePath, creating the file's
+// directory if needed
+func writeYamlFile(filePath string, v interface{}) error {
+	y, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, y, 0644)
+}
@@ -0,0 +1,328 @@
+package sumoapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ChangelogFormats lists the formats a changelog can be written in
+var ChangelogFormats = []string{"markdown", "json"}
+
+// changelogTypes are the object types listed in a changelog, in order
+var changelogTypes = []struct {
+	objectType string
+	title      string
+}{
+	{"dashboard", "Dashboard"},
+	{"panel", "Panel"},
+	{"saved-search", "Saved search"},
+	{"monitor", "Monitor"},
+	{"parser", "Parser"},
+	{"variable", "Variable"},
+}
+
+// Changes to an object in a changelog
+const (
+	ChangelogAdded    = "added"
+	ChangelogRemoved  = "removed"
+	ChangelogModified = "modified"
+)
+
+// changelogHeadings are the Markdown headings of each change
+var changelogHeadings = map[string]string{
+	ChangelogAdded:    "Added",
+	ChangelogRemoved:  "Removed",
+	ChangelogModified: "Modified",
+}
+
+type changelogEntry struct {
+	ObjectType string   `json:"objectType"`
+	Key        string   `json:"key"`
+	Name       string   `json:"name,omitempty"`
+	Change     string   `json:"change"`
+	Summary    string   `json:"summary"`
+	Details    []string `json:"details,omitempty"`
+}
+
+// appChangelog lists the dashboards, panels, saved searches, monitors,
+// parsers, and variables added, removed, and modified between two builds
+// of an application
+type appChangelog struct {
+	Application string           `json:"application"`
+	FromVersion string           `json:"fromVersion"`
+	ToVersion   string           `json:"toVersion"`
+	Date        string           `json:"date"`
+	Entries     []changelogEntry `json:"entries"`
+}
+
+type buildVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// NewChangelog compares two builds of the application and summarizes what
+// changed between them. The version of each build is read from the build
+func NewChangelog(from []byte, to []byte, rules *normalizationRules) (*appChangelog, error) {
+	var fromVersion, toVersion buildVersion
+	if err := json.Unmarshal(from, &fromVersion); err != nil {
+		return nil, fmt.Errorf("Expected a JSON build: %w", err)
+	}
+
+	if err := json.Unmarshal(to, &toVersion); err != nil {
+		return nil, fmt.Errorf("Expected a JSON build: %w", err)
+	}
+
+	fromOverlay, toOverlay, err := importBuilds(from, to, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := fromOverlay.Changes(toOverlay)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &appChangelog{
+		Application: toVersion.Name,
+		FromVersion: fromVersion.Version,
+		ToVersion:   toVersion.Version,
+		Entries:     make([]changelogEntry, 0),
+	}
+
+	groups := groupFieldChanges(cs.FieldChanges())
+
+	for _, t := range changelogTypes {
+		for _, g := range groups {
+			if g.objectType != t.objectType {
+				continue
+			}
+
+			entry := changelogEntry{
+				ObjectType: g.objectType,
+				Key:        g.key,
+				Change:     objectChangeType(g.changes),
+			}
+
+			//Removed objects are only named in the old build
+			objects := toOverlay
+			if entry.Change == ChangelogRemoved {
+				objects = fromOverlay
+			}
+			entry.Name = objects.objectName(g.objectType, g.key)
+
+			switch entry.Change {
+			case ChangelogAdded:
+				entry.Summary = fmt.Sprintf("Added %s", describeObject(t.title, entry.Name, entry.Key))
+			case ChangelogRemoved:
+				entry.Summary = fmt.Sprintf("Removed %s", describeObject(t.title, entry.Name, entry.Key))
+			default:
+				entry.Details = describeFieldChanges(g.changes)
+				entry.Summary = fmt.Sprintf("Changed %s: %s", describeObject(t.title, entry.Name, entry.Key), strings.Join(entry.Details, ", "))
+			}
+
+			log.Entries = append(log.Entries, entry)
+		}
+	}
+
+	return log, nil
+}
+
+// objectName returns the title or name of an object in the overlay, or ""
+// when the overlay doesn't have it
+func (s *appOverlay) objectName(objectType string, key string) string {
+	for _, list := range s.objectLists() {
+		if list.objectType != objectType {
+			continue
+		}
+
+		obj := reflect.ValueOf(list.objects).MapIndex(reflect.ValueOf(key))
+		if !obj.IsValid() || obj.IsNil() {
+			return ""
+		}
+
+		for _, field := range []string{"Title", "DisplayName", "Name"} {
+			if name := obj.Elem().FieldByName(field); name.IsValid() && name.String() != "" {
+				return name.String()
+			}
+		}
+	}
+
+	return ""
+}
+
+func describeObject(title string, name string, key string) string {
+	if name == "" || name == key {
+		return fmt.Sprintf("%s %s", strings.ToLower(title), key)
+	}
+
+	return fmt.Sprintf("%s %q (%s)", strings.ToLower(title), name, key)
+}
+
+// describeFieldChanges summarizes the fields changed on an object, in the
+// order they were changed, without repeating a field
+func describeFieldChanges(changes []fieldChange) []string {
+	details := make([]string, 0)
+
+	for _, c := range changes {
+		var detail string
+		segments := strings.Split(c.Path, ".")
+
+		switch {
+		case c.Layout != nil && len(segments) > 2:
+			detail = "panel " + segments[2] + " " + c.Layout.Describe()
+		case c.Layout != nil:
+			detail = "panel " + c.Layout.Describe()
+		case isQueryPath(segments) && segments[0] == "Queries":
+			detail = "query " + segments[1]
+		case isQueryPath(segments):
+			detail = "query"
+		default:
+			detail = humanizeField(segments[0])
+		}
+
+		if !containsString(details, detail) {
+			details = append(details, detail)
+		}
+	}
+
+	return details
+}
+
+// humanizeField turns a field name such as VisualSettings into
+// "visual settings"
+func humanizeField(field string) string {
+	switch field {
+	case "IncludeVariables":
+		return "variables"
+	case "Items":
+		return "contents"
+	}
+
+	var words strings.Builder
+	for i, r := range field {
+		if i > 0 && unicode.IsUpper(r) {
+			words.WriteRune(' ')
+		}
+		words.WriteRune(unicode.ToLower(r))
+	}
+
+	return words.String()
+}
+
+// Len returns the number of changed objects
+func (l *appChangelog) Len() int {
+	return len(l.Entries)
+}
+
+// Write writes the changelog to w in one of the ChangelogFormats
+func (l *appChangelog) Write(w io.Writer, format string) error {
+	switch format {
+	case "markdown":
+		l.writeMarkdown(w)
+		return nil
+	case "json":
+		j, err := canonicalJSON(l)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(j)
+		return err
+	}
+
+	return fmt.Errorf("Unknown output format '%s'. Expected one of: %s", format, strings.Join(ChangelogFormats, ", "))
+}
+
+func (l *appChangelog) writeMarkdown(w io.Writer) {
+	version := l.ToVersion
+	if version == "" {
+		version = "Unreleased"
+	}
+
+	heading := version
+	if l.Application != "" {
+		heading = l.Application + " " + version
+	}
+	if l.Date != "" {
+		heading += " (" + l.Date + ")"
+	}
+
+	fmt.Fprintf(w, "## %s\n", heading)
+	fmt.Fprintln(w)
+
+	if l.FromVersion != "" {
+		fmt.Fprintf(w, "Changes since %s.\n", l.FromVersion)
+		fmt.Fprintln(w)
+	}
+
+	if len(l.Entries) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+
+	for _, change := range []string{ChangelogAdded, ChangelogRemoved, ChangelogModified} {
+		entries := make([]changelogEntry, 0)
+		for _, e := range l.Entries {
+			if e.Change == change {
+				entries = append(entries, e)
+			}
+		}
+
+		if len(entries) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "### %s\n", changelogHeadings[change])
+		fmt.Fprintln(w)
+		for _, e := range entries {
+			fmt.Fprintf(w, "- %s\n", e.Summary)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// PrependToFile adds the changelog in Markdown to the top of a changelog
+// file, below its title when it starts with one. The file is created when
+// it doesn't exist
+func (l *appChangelog) PrependToFile(path string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var entry strings.Builder
+	l.writeMarkdown(&entry)
+
+	content := string(existing)
+	title := ""
+	if strings.HasPrefix(content, "# ") {
+		end := strings.Index(content, "\n")
+		if end < 0 {
+			end = len(content)
+			content += "\n"
+		}
+
+		title = content[:end+1]
+		content = strings.TrimLeft(content[end+1:], "\n")
+	} else if content == "" {
+		title = "# Changelog\n"
+	}
+
+	var file strings.Builder
+	if title != "" {
+		file.WriteString(title)
+		file.WriteString("\n")
+	}
+	file.WriteString(strings.TrimRight(entry.String(), "\n"))
+	file.WriteString("\n")
+	if content != "" {
+		file.WriteString("\n")
+		file.WriteString(content)
+	}
+
+	return os.WriteFile(path, []byte(file.String()), 0644)
+}
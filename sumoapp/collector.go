@@ -0,0 +1,630 @@
+package sumoapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/imdario/mergo"
+)
+
+// HostedCollectorType is the only type of collector managed as code.
+// Installed collectors are configured on the hosts they run on
+const HostedCollectorType = "Hosted"
+
+// collectorsPageSize is how many collectors are listed per request
+const collectorsPageSize = 1000
+
+// CollectorsBaseDir is the directory of the collector definitions every
+// environment starts from
+const CollectorsBaseDir = "base"
+
+type sourceFilter struct {
+	FilterType string `json:"filterType"`
+	Name       string `json:"name"`
+	Regexp     string `json:"regexp"`
+	Mask       string `json:"mask,omitempty" yaml:",omitempty"`
+}
+
+type sourcePath struct {
+	Type           string `json:"type"`
+	BucketName     string `json:"bucketName,omitempty" yaml:",omitempty"`
+	PathExpression string `json:"pathExpression,omitempty" yaml:",omitempty"`
+}
+
+type sourceAuthentication struct {
+	Type    string `json:"type"`
+	AwsId   string `json:"awsId,omitempty" yaml:",omitempty"`
+	AwsKey  string `json:"awsKey,omitempty" yaml:",omitempty"`
+	RoleARN string `json:"roleARN,omitempty" yaml:",omitempty"`
+}
+
+type sourceResource struct {
+	ServiceType    string               `json:"serviceType"`
+	Path           sourcePath           `json:"path"`
+	Authentication sourceAuthentication `json:"authentication"`
+}
+
+// sourceThirdPartyRef describes where polling sources, such as AWS S3
+// sources, read their data from
+type sourceThirdPartyRef struct {
+	Resources []sourceResource `json:"resources"`
+}
+
+// collectorSource is a source of a hosted collector, such as an HTTP,
+// cloud syslog, or AWS S3 source. Sources are keyed by name in their
+// collector's definition. The flags are pointers so an environment can
+// turn off a flag of the base definition. Fields are merged by name, and
+// an environment removes a field of the base definition by setting it to
+// an empty value
+type collectorSource struct {
+	Id                         int64                `json:"id,omitempty" yaml:"-" diff:"-"`
+	Name                       string               `json:"name" yaml:"-"`
+	SourceType                 string               `json:"sourceType"`
+	Description                string               `json:"description,omitempty" yaml:",omitempty"`
+	Category                   string               `json:"category,omitempty" yaml:",omitempty"`
+	HostName                   string               `json:"hostName,omitempty" yaml:",omitempty"`
+	TimeZone                   string               `json:"timeZone,omitempty" yaml:",omitempty"`
+	ForceTimeZone              *bool                `json:"forceTimeZone"`
+	AutomaticDateParsing       *bool                `json:"automaticDateParsing"`
+	MultilineProcessingEnabled *bool                `json:"multilineProcessingEnabled"`
+	UseAutolineMatching        *bool                `json:"useAutolineMatching"`
+	ManualPrefixRegexp         string               `json:"manualPrefixRegexp,omitempty" yaml:",omitempty"`
+	MessagePerRequest          *bool                `json:"messagePerRequest"`
+	ContentType                string               `json:"contentType,omitempty" yaml:",omitempty"`
+	ScanInterval               int64                `json:"scanInterval,omitempty" yaml:",omitempty"`
+	Paused                     *bool                `json:"paused"`
+	CutoffRelativeTime         string               `json:"cutoffRelativeTime,omitempty" yaml:",omitempty"`
+	Filters                    []sourceFilter       `json:"filters,omitempty" yaml:",omitempty"`
+	Fields                     map[string]string    `json:"fields,omitempty" yaml:",omitempty"`
+	ThirdPartyRef              *sourceThirdPartyRef `json:"thirdPartyRef,omitempty" yaml:",omitempty"`
+}
+
+// hostedCollector is a hosted collector and its sources. Collectors are
+// matched with the collectors in the org by name. Like the fields of a
+// source, an environment removes a field by setting it to an empty value
+type hostedCollector struct {
+	Id            int64                       `json:"id,omitempty" yaml:"-" diff:"-"`
+	CollectorType string                      `json:"collectorType" yaml:"-"`
+	Name          string                      `json:"name"`
+	Description   string                      `json:"description,omitempty" yaml:",omitempty"`
+	Category      string                      `json:"category,omitempty" yaml:",omitempty"`
+	TimeZone      string                      `json:"timeZone,omitempty" yaml:",omitempty"`
+	Fields        map[string]string           `json:"fields,omitempty" yaml:",omitempty"`
+	Sources       map[string]*collectorSource `json:"-" diff:"-"`
+}
+
+// setDefaults sets the flags a source doesn't set to the defaults of the
+// Collector Management API
+func (s *collectorSource) setDefaults() {
+	s.ForceTimeZone = defaultBool(s.ForceTimeZone, boolPtr(false))
+	s.MessagePerRequest = defaultBool(s.MessagePerRequest, boolPtr(false))
+	s.Paused = defaultBool(s.Paused, boolPtr(false))
+	s.AutomaticDateParsing = defaultBool(s.AutomaticDateParsing, boolPtr(true))
+	s.MultilineProcessingEnabled = defaultBool(s.MultilineProcessingEnabled, boolPtr(true))
+	s.UseAutolineMatching = defaultBool(s.UseAutolineMatching, boolPtr(true))
+}
+
+// defaultBool returns flag, or value when flag isn't set
+func defaultBool(flag *bool, value *bool) *bool {
+	if flag != nil {
+		return flag
+	}
+
+	return value
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
+func copyBool(flag *bool) *bool {
+	if flag == nil {
+		return nil
+	}
+
+	return boolPtr(*flag)
+}
+
+func (s *collectorSource) Merge(src *collectorSource) error {
+	newSource := src.Copy()
+	overrides := s.Copy()
+
+	if err := mergo.Merge(newSource, s, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	if err := mergo.Merge(s, newSource, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	//mergo skips false values, even behind pointers, so the flags the
+	//overrides set are kept as they are
+	s.ForceTimeZone = defaultBool(overrides.ForceTimeZone, s.ForceTimeZone)
+	s.AutomaticDateParsing = defaultBool(overrides.AutomaticDateParsing, s.AutomaticDateParsing)
+	s.MultilineProcessingEnabled = defaultBool(overrides.MultilineProcessingEnabled, s.MultilineProcessingEnabled)
+	s.UseAutolineMatching = defaultBool(overrides.UseAutolineMatching, s.UseAutolineMatching)
+	s.MessagePerRequest = defaultBool(overrides.MessagePerRequest, s.MessagePerRequest)
+	s.Paused = defaultBool(overrides.Paused, s.Paused)
+	s.Fields = mergeFields(src.Fields, overrides.Fields)
+
+	return nil
+}
+
+func (s *collectorSource) Copy() *collectorSource {
+	src := &collectorSource{}
+	mergo.Merge(src, s)
+
+	//mergo shares slices, maps and pointers with s, so they are copied here
+	src.Filters = append([]sourceFilter(nil), s.Filters...)
+	src.Fields = copyStringMap(s.Fields)
+	src.ForceTimeZone = copyBool(s.ForceTimeZone)
+	src.AutomaticDateParsing = copyBool(s.AutomaticDateParsing)
+	src.MultilineProcessingEnabled = copyBool(s.MultilineProcessingEnabled)
+	src.UseAutolineMatching = copyBool(s.UseAutolineMatching)
+	src.MessagePerRequest = copyBool(s.MessagePerRequest)
+	src.Paused = copyBool(s.Paused)
+	if s.ThirdPartyRef != nil {
+		src.ThirdPartyRef = &sourceThirdPartyRef{
+			Resources: append([]sourceResource(nil), s.ThirdPartyRef.Resources...),
+		}
+	}
+
+	return src
+}
+
+// Merge applies the collector's overrides to the collector of the base
+// environment. Sources are merged by name, so an environment only has to
+// list the sources and fields it changes
+func (c *hostedCollector) Merge(col *hostedCollector) error {
+	newCollector := col.Copy()
+
+	for name, src := range c.Sources {
+		if parentSource, ok := newCollector.Sources[name]; ok {
+			if err := src.Merge(parentSource); err != nil {
+				return err
+			}
+		}
+
+		newCollector.Sources[name] = src
+	}
+
+	sources := newCollector.Sources
+	newCollector.Sources = nil
+	overrides := *c
+	overrides.Sources = nil
+
+	if err := mergo.Merge(newCollector, &overrides, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	newCollector.Sources = sources
+	newCollector.Fields = mergeFields(col.Fields, overrides.Fields)
+	*c = *newCollector
+
+	return nil
+}
+
+func (c *hostedCollector) Copy() *hostedCollector {
+	col := &hostedCollector{
+		Id:            c.Id,
+		CollectorType: c.CollectorType,
+		Name:          c.Name,
+		Description:   c.Description,
+		Category:      c.Category,
+		TimeZone:      c.TimeZone,
+		Fields:        copyStringMap(c.Fields),
+		Sources:       make(map[string]*collectorSource),
+	}
+
+	for name, src := range c.Sources {
+		col.Sources[name] = src.Copy()
+	}
+
+	return col
+}
+
+// mergeFields returns the base fields with the overrides applied. An
+// override with an empty value removes the field
+func mergeFields(base map[string]string, overrides map[string]string) map[string]string {
+	fields := copyStringMap(base)
+
+	for name, value := range overrides {
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+
+		if value == "" {
+			delete(fields, name)
+		} else {
+			fields[name] = value
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return fields
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	newMap := make(map[string]string, len(m))
+	for k, v := range m {
+		newMap[k] = v
+	}
+
+	return newMap
+}
+
+// Validate checks the collector and its sources have everything the
+// Collector Management API needs
+func (c *hostedCollector) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("The collector has no name")
+	}
+
+	for _, name := range sortedKeys(c.Sources) {
+		src := c.Sources[name]
+		if src == nil {
+			return fmt.Errorf("Source '%s' is empty", name)
+		}
+
+		if src.SourceType == "" {
+			return fmt.Errorf("Source '%s' has no sourcetype", name)
+		}
+
+		if src.SourceType == "Polling" && (src.ThirdPartyRef == nil || len(src.ThirdPartyRef.Resources) == 0) {
+			return fmt.Errorf("Polling source '%s' has no thirdpartyref resources", name)
+		}
+	}
+
+	return nil
+}
+
+// readCollectorDir reads the collector definitions in every YAML file of
+// a directory, keyed like the files key them. A missing directory has no
+// definitions
+func readCollectorDir(dir string) (map[string]*hostedCollector, error) {
+	collectors := make(map[string]*hostedCollector)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, file := range files {
+		var curList map[string]*hostedCollector
+
+		path := filepath.Join(dir, file.Name())
+		if filepath.Ext(path) != ".yaml" {
+			continue
+		}
+
+		if err := readYamlFile(path, &curList); err != nil {
+			return nil, err
+		}
+
+		for key, c := range curList {
+			if _, ok := collectors[key]; ok {
+				return nil, fmt.Errorf("Collector '%s' is defined more than once in %s", key, dir)
+			}
+
+			if c == nil {
+				c = &hostedCollector{}
+			}
+
+			collectors[key] = c
+		}
+	}
+
+	return collectors, nil
+}
+
+// ReadCollectors reads the collector definitions of the base environment
+// in dir, merged with the overrides of env when env isn't empty. Every
+// environment is a directory of dir, like the overlays of an application.
+// The collectors are returned keyed by name
+func ReadCollectors(dir string, env string) (map[string]*hostedCollector, error) {
+	basePath := filepath.Join(dir, CollectorsBaseDir)
+	if _, err := os.Stat(basePath); err != nil {
+		return nil, fmt.Errorf("Could not find the base collector definitions: %w", err)
+	}
+
+	collectors, err := readCollectorDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if env != "" && env != CollectorsBaseDir {
+		envPath := filepath.Join(dir, env)
+		if _, err := os.Stat(envPath); err != nil {
+			return nil, fmt.Errorf("Could not find the collector definitions of environment '%s': %w", env, err)
+		}
+
+		overrides, err := readCollectorDir(envPath)
+		if err != nil {
+			return nil, err
+		}
+
+		//Collectors that are only defined in the environment are added,
+		//the others are merged with their base definition
+		for key, c := range overrides {
+			if base, ok := collectors[key]; ok {
+				if err := c.Merge(base); err != nil {
+					return nil, err
+				}
+			}
+
+			collectors[key] = c
+		}
+	}
+
+	byName := make(map[string]*hostedCollector)
+	for _, key := range sortedKeys(collectors) {
+		c := collectors[key]
+		c.CollectorType = HostedCollectorType
+
+		for name, src := range c.Sources {
+			if src != nil {
+				src.Name = name
+				src.setDefaults()
+			}
+		}
+
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid collector '%s': %w", key, err)
+		}
+
+		if _, ok := byName[c.Name]; ok {
+			return nil, fmt.Errorf("More than one collector is named '%s'. Collectors are matched by name, so names must be unique", c.Name)
+		}
+
+		byName[c.Name] = c
+	}
+
+	return byName, nil
+}
+
+// ListHostedCollectors returns the org's hosted collectors and their
+// sources, keyed by name
+func ListHostedCollectors(ctx context.Context, a *APIClient) (map[string]*hostedCollector, error) {
+	collectors := make(map[string]*hostedCollector)
+
+	for offset := 0; ; offset += collectorsPageSize {
+		query := url.Values{}
+		query.Add("filter", "hosted")
+		query.Add("limit", strconv.Itoa(collectorsPageSize))
+		query.Add("offset", strconv.Itoa(offset))
+
+		var page struct {
+			Collectors []*hostedCollector `json:"collectors"`
+		}
+		if err := a.requestJSON(ctx, "GET", "/v1/collectors", query, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, c := range page.Collectors {
+			var sources struct {
+				Sources []*collectorSource `json:"sources"`
+			}
+			if err := a.requestJSON(ctx, "GET", fmt.Sprintf("/v1/collectors/%d/sources", c.Id), nil, nil, &sources); err != nil {
+				return nil, err
+			}
+
+			//The API leaves out the flags some source types don't use, so
+			//they're set like the flags of the definitions
+			c.Sources = make(map[string]*collectorSource)
+			for _, src := range sources.Sources {
+				src.setDefaults()
+				c.Sources[src.Name] = src
+			}
+
+			collectors[c.Name] = c
+		}
+
+		if len(page.Collectors) < collectorsPageSize {
+			return collectors, nil
+		}
+	}
+}
+
+// WriteCollectors writes each collector, with its sources, to its own file
+// in the base environment of dir
+func WriteCollectors(collectors map[string]*hostedCollector, dir string) error {
+	names := make(map[string]string)
+
+	for _, name := range sortedKeys(collectors) {
+		key := sanitizeName(name)
+		if other, ok := names[key]; ok {
+			return fmt.Errorf("Collectors '%s' and '%s' would be written to the same file %s.yaml", other, name, key)
+		}
+		names[key] = name
+
+		filePath := filepath.Join(dir, CollectorsBaseDir, key+".yaml")
+		if err := writeYamlFile(filePath, map[string]*hostedCollector{key: collectors[name]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectorPlan is a plan for the org's hosted collectors and the sources
+// of the collectors that are defined. Sources are keyed by their
+// collector's name and their own name, separated by a slash
+type collectorPlan struct {
+	Collectors *resourcePlan `json:"collectors"`
+	Sources    *resourcePlan `json:"sources"`
+	current    map[string]*hostedCollector
+	desired    map[string]*hostedCollector
+	sourceRefs map[string][2]string
+}
+
+// PlanCollectors compares the collectors defined in dir for env with the
+// hosted collectors in the org. With prune, collectors that aren't
+// defined, and sources of defined collectors that aren't, are deleted
+func PlanCollectors(ctx context.Context, a *APIClient, dir string, env string, prune bool) (*collectorPlan, error) {
+	desired, err := ReadCollectors(dir, env)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := ListHostedCollectors(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &collectorPlan{
+		current:    current,
+		desired:    desired,
+		sourceRefs: make(map[string][2]string),
+	}
+
+	p.Collectors, err = newResourcePlan("collector", current, desired, prune)
+	if err != nil {
+		return nil, err
+	}
+
+	//Only the sources of defined collectors are compared, the sources of
+	//other collectors go with them
+	currentSources := make(map[string]*collectorSource)
+	desiredSources := make(map[string]*collectorSource)
+	for name, c := range desired {
+		for srcName, src := range c.Sources {
+			key := name + "/" + srcName
+			desiredSources[key] = src
+			p.sourceRefs[key] = [2]string{name, srcName}
+		}
+
+		if cur, ok := current[name]; ok {
+			for srcName, src := range cur.Sources {
+				key := name + "/" + srcName
+				currentSources[key] = src
+				p.sourceRefs[key] = [2]string{name, srcName}
+			}
+		}
+	}
+
+	p.Sources, err = newResourcePlan("source", currentSources, desiredSources, prune)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// HasChanges reports whether applying the plan changes anything
+func (p *collectorPlan) HasChanges() bool {
+	return p.Collectors.HasChanges() || p.Sources.HasChanges()
+}
+
+func (p *collectorPlan) ToJSON() ([]byte, error) {
+	return canonicalJSON(p)
+}
+
+// Display writes the plans for the collectors and their sources
+func (p *collectorPlan) Display(w io.Writer) {
+	p.Collectors.Display(w)
+	fmt.Fprintln(w)
+	p.Sources.Display(w)
+}
+
+// Apply creates and updates the collectors, then their sources, and
+// deletes collectors last so their sources don't have to be deleted one
+// by one
+func (p *collectorPlan) Apply(ctx context.Context, a *APIClient, w io.Writer) error {
+	ids := make(map[string]int64)
+	for name, c := range p.current {
+		ids[name] = c.Id
+	}
+
+	collectorActions := p.Collectors.Actions
+	deletes := make([]planAction, 0)
+	p.Collectors.Actions = make([]planAction, 0)
+	for _, action := range collectorActions {
+		if action.Action == PlanDelete {
+			deletes = append(deletes, action)
+		} else {
+			p.Collectors.Actions = append(p.Collectors.Actions, action)
+		}
+	}
+
+	err := p.Collectors.apply(w, func(action planAction) error {
+		body := *p.desired[action.Name]
+		body.Id = 0
+
+		if action.Action == PlanCreate {
+			var created struct {
+				Collector hostedCollector `json:"collector"`
+			}
+			if err := a.requestJSON(ctx, "POST", "/v1/collectors", nil, map[string]interface{}{"collector": body}, &created); err != nil {
+				return err
+			}
+
+			ids[action.Name] = created.Collector.Id
+			return nil
+		}
+
+		body.Id = ids[action.Name]
+		return updateWithETag(ctx, a, fmt.Sprintf("/v1/collectors/%d", body.Id), map[string]interface{}{"collector": body})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = p.Sources.apply(w, func(action planAction) error {
+		ref := p.sourceRefs[action.Name]
+		collectorId := ids[ref[0]]
+		sourcesPath := fmt.Sprintf("/v1/collectors/%d/sources", collectorId)
+
+		switch action.Action {
+		case PlanCreate:
+			body := *p.desired[ref[0]].Sources[ref[1]]
+			body.Id = 0
+			return a.requestJSON(ctx, "POST", sourcesPath, nil, map[string]interface{}{"source": body}, nil)
+		case PlanUpdate:
+			body := *p.desired[ref[0]].Sources[ref[1]]
+			body.Id = p.current[ref[0]].Sources[ref[1]].Id
+			return updateWithETag(ctx, a, fmt.Sprintf("%s/%d", sourcesPath, body.Id), map[string]interface{}{"source": body})
+		case PlanDelete:
+			return a.requestJSON(ctx, "DELETE", fmt.Sprintf("%s/%d", sourcesPath, p.current[ref[0]].Sources[ref[1]].Id), nil, nil, nil)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.Collectors.Actions = deletes
+	defer func() {
+		p.Collectors.Actions = collectorActions
+	}()
+
+	return p.Collectors.apply(w, func(action planAction) error {
+		return a.requestJSON(ctx, "DELETE", fmt.Sprintf("/v1/collectors/%d", ids[action.Name]), nil, nil, nil)
+	})
+}
+
+// updateWithETag replaces the object at path with body. The Collector
+// Management API only accepts updates to the version of the object they
+// were based on, so its current ETag is sent in the If-Match header
+func updateWithETag(ctx context.Context, a *APIClient, path string, body interface{}) error {
+	headers, err := a.requestJSONWithHeaders(ctx, "GET", path, nil, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.requestJSONWithHeaders(ctx, "PUT", path, nil, map[string]string{"If-Match": headers.Get("ETag")}, body, nil)
+	return err
+}
@@ -0,0 +1,172 @@
+package sumoapp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testBaseCollectors = `web:
+  name: web
+  category: prod/web
+  fields:
+    team: web
+    tier: frontend
+  sources:
+    access:
+      sourcetype: HTTP
+      category: prod/web/access
+      paused: true
+      messageperrequest: true
+      fields:
+        format: nginx
+    errors:
+      sourcetype: HTTP
+      category: prod/web/errors
+`
+
+func TestReadCollectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		check func(t *testing.T, web *hostedCollector)
+	}{
+		{
+			name: "base",
+			check: func(t *testing.T, web *hostedCollector) {
+				access := web.Sources["access"]
+				if web.CollectorType != HostedCollectorType || access.Name != "access" || !*access.Paused || !*access.AutomaticDateParsing || *access.ForceTimeZone {
+					t.Errorf("base collector = %+v, access = %+v, want the names and API defaults set", web, access)
+				}
+			},
+		},
+		{
+			name: "changed fields",
+			env:  "web:\n  category: staging/web\n  sources:\n    access:\n      category: staging/web/access\n",
+			check: func(t *testing.T, web *hostedCollector) {
+				access := web.Sources["access"]
+				if web.Name != "web" || web.Category != "staging/web" || access.Category != "staging/web/access" || access.SourceType != "HTTP" {
+					t.Errorf("merged collector = %+v, access = %+v", web, access)
+				}
+
+				if errors := web.Sources["errors"]; errors == nil || errors.Category != "prod/web/errors" {
+					t.Errorf("errors source = %+v, want it inherited as is", errors)
+				}
+			},
+		},
+		{
+			name: "added source",
+			env:  "web:\n  sources:\n    metrics:\n      sourcetype: HTTP\n      contenttype: Carbon2\n",
+			check: func(t *testing.T, web *hostedCollector) {
+				if len(web.Sources) != 3 || web.Sources["metrics"].ContentType != "Carbon2" {
+					t.Errorf("sources = %v, want access, errors, and metrics", web.Sources)
+				}
+			},
+		},
+		{
+			name: "merged collector fields",
+			env:  "web:\n  fields:\n    tier: edge\n    env: staging\n",
+			check: func(t *testing.T, web *hostedCollector) {
+				want := map[string]string{"team": "web", "tier": "edge", "env": "staging"}
+				if !reflect.DeepEqual(web.Fields, want) {
+					t.Errorf("Fields = %v, want %v", web.Fields, want)
+				}
+			},
+		},
+		{
+			name: "turned off flags",
+			env:  "web:\n  sources:\n    access:\n      paused: false\n      messageperrequest: false\n      automaticdateparsing: false\n",
+			check: func(t *testing.T, web *hostedCollector) {
+				access := web.Sources["access"]
+				if *access.Paused || *access.MessagePerRequest || *access.AutomaticDateParsing {
+					t.Errorf("access = %+v, want the flags the environment turns off off", access)
+				}
+			},
+		},
+		{
+			name: "removed fields",
+			env:  "web:\n  fields:\n    tier: \"\"\n  sources:\n    access:\n      fields:\n        format: \"\"\n",
+			check: func(t *testing.T, web *hostedCollector) {
+				if want := map[string]string{"team": "web"}; !reflect.DeepEqual(web.Fields, want) {
+					t.Errorf("collector Fields = %v, want %v", web.Fields, want)
+				}
+
+				if fields := web.Sources["access"].Fields; fields != nil {
+					t.Errorf("access Fields = %v, want none", fields)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"base/web.yaml": testBaseCollectors}
+			env := ""
+			if tt.env != "" {
+				files["staging/web.yaml"] = tt.env
+				env = "staging"
+			}
+
+			collectors, err := ReadCollectors(writeAppFiles(t, t.TempDir(), files), env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(collectors) != 1 || collectors["web"] == nil {
+				t.Fatalf("ReadCollectors() = %v, want web", collectors)
+			}
+
+			tt.check(t, collectors["web"])
+		})
+	}
+}
+
+func TestReadCollectorsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		env     string
+		wantErr string
+	}{
+		{
+			name:    "no base",
+			files:   map[string]string{"staging/web.yaml": testBaseCollectors},
+			wantErr: "base collector definitions",
+		},
+		{
+			name:    "unknown environment",
+			files:   map[string]string{"base/web.yaml": testBaseCollectors},
+			env:     "prod",
+			wantErr: "environment 'prod'",
+		},
+		{
+			name:    "duplicate key",
+			files:   map[string]string{"base/web.yaml": testBaseCollectors, "base/more.yaml": testBaseCollectors},
+			wantErr: "defined more than once",
+		},
+		{
+			name:    "duplicate name",
+			files:   map[string]string{"base/web.yaml": testBaseCollectors, "base/more.yaml": "other:\n  name: web\n"},
+			wantErr: "More than one collector is named 'web'",
+		},
+		{
+			name:    "source without type",
+			files:   map[string]string{"base/web.yaml": "web:\n  name: web\n  sources:\n    access:\n      category: prod\n"},
+			wantErr: "has no sourcetype",
+		},
+		{
+			name:    "polling source without resources",
+			files:   map[string]string{"base/web.yaml": "web:\n  name: web\n  sources:\n    s3:\n      sourcetype: Polling\n"},
+			wantErr: "has no thirdpartyref resources",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadCollectors(writeAppFiles(t, t.TempDir(), tt.files), tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadCollectors() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
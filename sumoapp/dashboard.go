@@ -37,17 +37,53 @@ func (d *dashboard) Copy() *dashboard {
 		Description:      d.Description,
 		Title:            d.Title,
 		Theme:            d.Theme,
-		TopologyLabelMap: d.TopologyLabelMap,
+		TopologyLabelMap: d.TopologyLabelMap.Copy(),
 		RefreshInterval:  d.RefreshInterval,
-		TimeRange:        d.TimeRange, //TODO: TimeRange is a pointer and needs its own Copy() function. This just copies the pointer
+		TimeRange:        d.TimeRange.Copy(),
 		Layout:           d.Layout,
 		Panels:           d.Panels,
 		Variables:        d.Variables,
 		RootPanel:        d.RootPanel,
 		IncludeVariables: d.IncludeVariables,
+		Permissions:      d.Permissions,
 	}
 }
 
+// Copy returns a deep copy of the time range. Merging into a copy that
+// shares the boundaries would change the parent overlay's time range too
+func (t *timerange) Copy() *timerange {
+	if t == nil {
+		return nil
+	}
+
+	newTimerange := &timerange{Type: t.Type}
+
+	if t.From != nil {
+		from := *t.From
+		newTimerange.From = &from
+	}
+
+	if t.To != nil {
+		to := *t.To
+		newTimerange.To = &to
+	}
+
+	return newTimerange
+}
+
+func (l labelMap) Copy() labelMap {
+	if l.Data == nil {
+		return l
+	}
+
+	data := make(map[string]string, len(l.Data))
+	for k, v := range l.Data {
+		data[k] = v
+	}
+
+	return labelMap{Data: data}
+}
+
 func (d *dashboard) Populate(overlay *appOverlay) error {
 	//Populate the dashboard with the panels and variables
 	//It is very important the the panels and variables have been
@@ -78,6 +114,11 @@ func (d *dashboard) Populate(overlay *appOverlay) error {
 		d.Panels = append(d.Panels, p)
 	}
 
+	//The appended structures are now part of the layout. Clearing them
+	//keeps child overlays that inherit this dashboard from appending
+	//them a second time
+	d.Layout.AppendLayoutStructures = make([]layoutStructure, 0)
+
 	for _, variableName := range d.IncludeVariables {
 		v, ok := overlay.Variables[variableName]
 		if !ok {
@@ -85,8 +126,6 @@ func (d *dashboard) Populate(overlay *appOverlay) error {
 			return err
 		}
 
-		d.Layout.AppendLayoutStructures = make([]layoutStructure, 0)
-
 		d.Variables = append(d.Variables, v)
 	}
 
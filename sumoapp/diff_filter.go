@@ -0,0 +1,128 @@
+package sumoapp
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/r3labs/diff"
+)
+
+// DiffFilter selects the changes of a change set that matter. Empty
+// fields don't filter anything
+type DiffFilter struct {
+	// Types keeps changes to these object types only
+	Types []string
+	// Keys keeps changes to objects whose key matches one of these glob patterns
+	Keys []string
+	// IgnorePaths drops changes to fields matching one of these dot
+	// separated paths. A '*' segment matches any single segment, and a
+	// path also matches every field below it. Matching is case-insensitive
+	IgnorePaths []string
+}
+
+// Validate checks the filter only refers to known object types and has
+// valid key patterns
+func (f *DiffFilter) Validate() error {
+	for _, t := range f.Types {
+		if objectTypeIndex(t) == len(objectTypeOrder) {
+			return fmt.Errorf("Unknown object type '%s'. Expected one of: %s", t, strings.Join(objectTypeOrder, ", "))
+		}
+	}
+
+	for _, k := range f.Keys {
+		if _, err := path.Match(k, ""); err != nil {
+			return fmt.Errorf("Invalid key pattern '%s': %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+func (f *DiffFilter) keep(c diff.Change) bool {
+	if len(f.Types) > 0 && !containsString(f.Types, c.Path[0]) {
+		return false
+	}
+
+	if len(f.Keys) > 0 {
+		matched := false
+		for _, k := range f.Keys {
+			if ok, _ := path.Match(k, c.Path[1]); ok {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	//Changes to whole objects have no field path to ignore
+	if len(c.Path) <= 2 {
+		return true
+	}
+
+	for _, ignorePath := range f.IgnorePaths {
+		if fieldPathMatches(strings.Split(ignorePath, "."), c.Path[2:]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fieldPathMatches reports whether pattern matches fieldPath or one of
+// the paths above it
+func fieldPathMatches(pattern []string, fieldPath []string) bool {
+	if len(pattern) > len(fieldPath) {
+		return false
+	}
+
+	for i, segment := range pattern {
+		if segment != "*" && !strings.EqualFold(segment, fieldPath[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApplyFilter removes the changes the filter doesn't keep
+func (cs *changeSet) ApplyFilter(f DiffFilter) {
+	changelogs := []*diff.Changelog{
+		&cs.ChangelogVar,
+		&cs.ChangelogPanel,
+		&cs.ChangelogSavedSearches,
+		&cs.ChangelogDashboard,
+		&cs.ChangelogFolder,
+		&cs.ChangelogMonitor,
+		&cs.ChangelogParser,
+	}
+
+	for _, changelog := range changelogs {
+		filtered := make(diff.Changelog, 0, len(*changelog))
+		for _, c := range *changelog {
+			if f.keep(c) {
+				filtered = append(filtered, c)
+			}
+		}
+
+		*changelog = filtered
+	}
+}
+
+// Len returns the number of changes in the change set
+func (cs *changeSet) Len() int {
+	return len(cs.all())
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
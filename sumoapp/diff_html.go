@@ -0,0 +1,255 @@
+package sumoapp
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// htmlPageTemplate is the layout shared by the HTML reports. The page is
+// self-contained so it can be attached to a release or opened offline
+const htmlPageTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{template "title" .}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.3em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; margin-top: 2em; }
+h3 { font-size: 1.1em; margin-top: 1.5em; }
+table { border-collapse: collapse; width: 100%; margin: .5em 0 1em; table-layout: fixed; }
+th, td { border: 1px solid #d0d7de; padding: .4em .6em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+pre { margin: 0; white-space: pre-wrap; word-break: break-word; font-size: .85em; }
+code { font-size: .9em; }
+details { margin: .5em 0; }
+summary { cursor: pointer; font-weight: 600; }
+.from { background: #ffebe9; }
+.to { background: #e6ffec; }
+.del { background: #ffebe9; display: block; }
+.ins { background: #e6ffec; display: block; }
+.same { color: #57606a; display: block; }
+.badge { display: inline-block; padding: 0 .5em; border-radius: 1em; font-size: .8em; font-weight: normal; background: #ddf4ff; }
+.badge.added { background: #dafbe1; }
+.badge.removed { background: #ffebe9; }
+.summary td, .summary th { width: auto; }
+</style>
+</head>
+<body>
+{{template "content" .}}
+</body>
+</html>
+`
+
+const changeSetHTMLTemplate = `{{define "title"}}{{.Title}}{{end}}
+{{define "content"}}
+<h1>{{.Title}}</h1>
+<p>Found {{.Summary.Total}} change(s) in {{.Summary.Objects}} object(s)</p>
+{{if .Summary.ByType}}<table class="summary">
+<tr><th>Object type</th><th>Changes</th></tr>
+{{range $type, $count := .Summary.ByType}}<tr><td>{{$type}}</td><td>{{$count}}</td></tr>
+{{end}}</table>{{end}}
+{{range .Folders}}
+<h2>{{if .Key}}Folder <code>{{.Key}}</code>{{else}}Application root folder{{end}}</h2>
+{{range .Objects}}{{template "object" .}}{{end}}
+{{range .Dashboards}}
+<h3>Dashboard <code>{{.Key}}</code></h3>
+{{range .Objects}}{{template "object" .}}{{end}}
+{{end}}
+{{end}}
+{{end}}
+
+{{define "object"}}
+<details open>
+<summary>{{.ObjectType}} <code>{{.Key}}</code> <span class="badge {{.Change}}">{{.Change}}</span></summary>
+<table>
+<tr><th style="width: 20%">Field</th><th>Before</th><th>After</th></tr>
+{{range .Fields}}<tr>
+<td><code>{{.Path}}</code><br><small>{{.ChangeType}}</small></td>
+{{if .Layout}}<td colspan="2">{{.Layout}}</td>
+{{else if .QueryDiff}}<td colspan="2"><pre>{{range .QueryDiff}}{{if eq .Op "-"}}<span class="del">- {{.Text}}</span>{{else if eq .Op "+"}}<span class="ins">+ {{.Text}}</span>{{else}}<span class="same">  {{.Text}}</span>{{end}}{{end}}</pre></td>
+{{else}}<td class="{{if .From}}from{{end}}"><pre>{{.From}}</pre></td>
+<td class="{{if .To}}to{{end}}"><pre>{{.To}}</pre></td>
+{{end}}</tr>
+{{end}}</table>
+</details>
+{{end}}`
+
+const threeWayHTMLTemplate = `{{define "title"}}Overrides in app overlay {{.Overlay}}{{end}}
+{{define "content"}}
+<h1>Overrides in app overlay <code>{{.Overlay}}</code></h1>
+<p>Found {{.Summary.Total}} override(s) of app overlay <code>{{.Parent}}</code></p>
+{{if .Fields}}<table>
+<tr><th>Object</th><th>Field</th><th>Status</th><th>{{.Parent}}</th><th>{{.Overlay}}</th><th>Merged</th></tr>
+{{range .Fields}}<tr>
+<td>{{.ObjectType}} <code>{{.Key}}</code></td>
+<td><code>{{.Path}}</code></td>
+<td><span class="badge {{.Status}}">{{.Status}}</span></td>
+<td><pre>{{inline .Parent}}</pre></td>
+<td><pre>{{inline .Override}}</pre></td>
+<td><pre>{{inline .Merged}}</pre></td>
+</tr>
+{{end}}</table>{{end}}
+{{end}}`
+
+var (
+	changeSetHTML = template.Must(template.Must(template.New("page").Parse(htmlPageTemplate)).Parse(changeSetHTMLTemplate))
+	threeWayHTML  = template.Must(template.Must(template.New("page").Funcs(template.FuncMap{"inline": inlineValue}).Parse(htmlPageTemplate)).Parse(threeWayHTMLTemplate))
+)
+
+type htmlField struct {
+	Path       string
+	ChangeType string
+	From       string
+	To         string
+	Layout     string
+	QueryDiff  []queryLine
+}
+
+type htmlObject struct {
+	ObjectType string
+	Key        string
+	Change     string
+	Fields     []htmlField
+}
+
+type htmlDashboard struct {
+	Key     string
+	Objects []htmlObject
+}
+
+type htmlFolder struct {
+	Key        string
+	Objects    []htmlObject
+	Dashboards []*htmlDashboard
+}
+
+type htmlReport struct {
+	Title   string
+	Summary diffSummary
+	Folders []*htmlFolder
+}
+
+// writeHTMLDiff writes the change set as an HTML page. Changes are grouped
+// by the folder and dashboard the changed objects are in
+func writeHTMLDiff(w io.Writer, cs *changeSet) error {
+	report := htmlReport{
+		Title:   "Application diff",
+		Summary: cs.Structured().Summary,
+		Folders: make([]*htmlFolder, 0),
+	}
+
+	folders := make(map[string]*htmlFolder)
+	dashboards := make(map[string]*htmlDashboard)
+
+	for _, g := range groupFieldChanges(cs.FieldChanges()) {
+		objectId := g.objectType + "/" + g.key
+
+		dashboardId := ""
+		switch g.objectType {
+		case "dashboard":
+			dashboardId = objectId
+		case "panel", "variable":
+			if container := cs.containers[objectId]; strings.HasPrefix(container, "dashboard/") {
+				dashboardId = container
+			}
+		}
+
+		folderId := cs.containers[objectId]
+		switch {
+		case dashboardId != "":
+			folderId = cs.containers[dashboardId]
+		case g.objectType == "folder":
+			folderId = objectId
+		}
+
+		folderKey := strings.TrimPrefix(folderId, "folder/")
+		f, ok := folders[folderKey]
+		if !ok {
+			f = &htmlFolder{Key: folderKey}
+			folders[folderKey] = f
+			report.Folders = append(report.Folders, f)
+		}
+
+		obj := newHTMLObject(g)
+
+		if dashboardId == "" {
+			f.Objects = append(f.Objects, obj)
+			continue
+		}
+
+		d, ok := dashboards[dashboardId]
+		if !ok {
+			d = &htmlDashboard{Key: strings.TrimPrefix(dashboardId, "dashboard/")}
+			dashboards[dashboardId] = d
+			f.Dashboards = append(f.Dashboards, d)
+		}
+		d.Objects = append(d.Objects, obj)
+	}
+
+	//The root folder comes first, then folders, dashboards, and the
+	//objects in a dashboard with the dashboard itself first
+	sort.SliceStable(report.Folders, func(i, j int) bool {
+		return report.Folders[i].Key < report.Folders[j].Key
+	})
+
+	for _, f := range report.Folders {
+		sort.SliceStable(f.Dashboards, func(i, j int) bool {
+			return f.Dashboards[i].Key < f.Dashboards[j].Key
+		})
+
+		for _, d := range f.Dashboards {
+			sort.SliceStable(d.Objects, func(i, j int) bool {
+				return d.Objects[i].ObjectType == "dashboard" && d.Objects[j].ObjectType != "dashboard"
+			})
+		}
+	}
+
+	return changeSetHTML.Execute(w, report)
+}
+
+func newHTMLObject(g objectChanges) htmlObject {
+	obj := htmlObject{
+		ObjectType: g.objectType,
+		Key:        g.key,
+		Change:     objectChangeType(g.changes),
+		Fields:     make([]htmlField, 0, len(g.changes)),
+	}
+
+	for _, c := range g.changes {
+		field := htmlField{
+			Path:       c.Path,
+			ChangeType: c.ChangeType,
+			From:       htmlValue(c.From),
+			To:         htmlValue(c.To),
+			QueryDiff:  c.QueryDiff,
+		}
+
+		if field.Path == "" {
+			field.Path = "(object)"
+		}
+
+		if c.Layout != nil {
+			field.Layout = "Panel " + c.Layout.Describe()
+		}
+
+		obj.Fields = append(obj.Fields, field)
+	}
+
+	return obj
+}
+
+// htmlValue renders a changed value as YAML. The template escapes it
+func htmlValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return writeYamlObject(v, "")
+}
@@ -0,0 +1,276 @@
+package sumoapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// diffInput is one side of a comparison loaded into an overlay. Partial
+// inputs, such as a single dashboard export or a component file, only
+// hold some of an application's objects
+type diffInput struct {
+	overlay *appOverlay
+	partial bool
+}
+
+// CompareInputs diffs two inputs, each of which can be:
+//
+//   - an exported folder or build file
+//   - an exported dashboard or saved search
+//   - an exported monitors folder or monitor
+//   - an exported parsers folder or parser
+//   - a component YAML file in an overlay's dashboards, folders, monitors,
+//     panels, parsers, saved-searches, or variables directory
+//   - the name of one of the application's overlays, merged
+//   - "-" to read an export from stdin
+//
+// Relative file paths are relative to the application's path. Both inputs
+// are normalized into the same model, so JSON exports can be compared with
+// YAML files and overlays. When an input only holds some objects, the
+// comparison is limited to those objects
+func (a *application) CompareInputs(from string, to string) (changeSet, error) {
+	if from == "-" && to == "-" {
+		return changeSet{}, fmt.Errorf("Only one input can be read from stdin")
+	}
+
+	fromInput, err := a.loadDiffInput(from)
+	if err != nil {
+		return changeSet{}, fmt.Errorf("Could not load %s: %w", from, err)
+	}
+
+	toInput, err := a.loadDiffInput(to)
+	if err != nil {
+		return changeSet{}, fmt.Errorf("Could not load %s: %w", to, err)
+	}
+
+	fromOverlay := fromInput.overlay.diffModel()
+	toOverlay := toInput.overlay.diffModel()
+
+	if fromInput.partial || toInput.partial {
+		keys := make(map[string]bool)
+		for _, input := range []*diffInput{fromInput, toInput} {
+			if input.partial {
+				input.overlay.addObjectKeys(keys)
+			}
+		}
+
+		fromOverlay.keepObjects(keys)
+		toOverlay.keepObjects(keys)
+	}
+
+	return fromOverlay.Changes(toOverlay)
+}
+
+func (a *application) loadDiffInput(spec string) (*diffInput, error) {
+	if spec == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		return a.loadExportInput(data)
+	}
+
+	path := spec
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(a.path, path)
+	}
+
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		ext := filepath.Ext(path)
+		if ext == ".yaml" || ext == ".yml" {
+			return a.loadComponentInput(path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return a.loadExportInput(data)
+	}
+
+	//Anything that isn't a file is an overlay name
+	if !containsString(AppOverlayNames, spec) {
+		return nil, fmt.Errorf("No such file in %s or app overlay. App overlays are: %s", a.path, strings.Join(AppOverlayNames, ", "))
+	}
+
+	if len(a.appOverlays) == 0 {
+		if err := a.LoadAppOverlays(); err != nil {
+			return nil, err
+		}
+	}
+
+	overlay, err := a.FindAppOverlay(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &diffInput{overlay: overlay}, nil
+}
+
+// loadExportInput imports an exported folder, dashboard, saved search,
+// monitors folder, or parsers folder. Dashboards and saved searches are imported as the only
+// child of a folder
+func (a *application) loadExportInput(data []byte) (*diffInput, error) {
+	var export struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("Expected a JSON export: %w", err)
+	}
+
+	input := &diffInput{}
+
+	switch export.Type {
+	case FolderType:
+	case MonitorFolderType, MonitorType, ParserFolderType, ParserType:
+		input.partial = true
+	case DashboardType, SavedSearchType:
+		folderData, err := json.Marshal(map[string]interface{}{
+			"type":     FolderType,
+			"children": []json.RawMessage{data},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		data = folderData
+		input.partial = true
+	default:
+		return nil, fmt.Errorf("Unsupported export type '%s'. Expected a folder, dashboard, saved search, monitors, or parsers export", export.Type)
+	}
+
+	//Import into a separate application so the export doesn't replace
+	//this application's name and items
+	importApp := NewApplication()
+	importApp.normalization = a.NormalizationRules()
+
+	input.overlay = importApp.NewAppOverlay("import")
+	if err := importApp.ImportBytesToOverlay(data, input.overlay); err != nil {
+		return nil, err
+	}
+
+	return input, nil
+}
+
+// loadComponentInput loads a component file. The kind of component is
+// taken from the directory the file is in
+func (a *application) loadComponentInput(path string) (*diffInput, error) {
+	overlay := a.NewAppOverlay("component")
+
+	var objects interface{}
+	switch dir := filepath.Base(filepath.Dir(path)); dir {
+	case "dashboards":
+		objects = &overlay.Dashboards
+	case "folders":
+		objects = &overlay.Folders
+	case "monitors":
+		objects = &overlay.Monitors
+	case "panels":
+		objects = &overlay.Panels
+	case "parsers":
+		objects = &overlay.Parsers
+	case "saved-searches":
+		objects = &overlay.SavedSearches
+	case "variables":
+		objects = &overlay.Variables
+	default:
+		return nil, fmt.Errorf("Component files must be in one of these directories: %s", strings.Join(ComponentDirectories, ", "))
+	}
+
+	if err := readYamlFile(path, objects); err != nil {
+		return nil, err
+	}
+
+	overlay.Normalize(a.NormalizationRules())
+
+	return &diffInput{overlay: overlay, partial: true}, nil
+}
+
+// diffModel returns a copy of the overlay without the fields that are
+// only set when an overlay is loaded for a build, so loaded overlays
+// compare equal to imported exports
+func (s *appOverlay) diffModel() *appOverlay {
+	model := NewAppOverlay(s.Name, s.Application)
+	model.Path = s.Path
+
+	for name, v := range s.Variables {
+		model.Variables[name] = v
+	}
+
+	for name, p := range s.Panels {
+		model.Panels[name] = p
+	}
+
+	for name, ss := range s.SavedSearches {
+		search := ss.Copy()
+		search.Type = ""
+		model.SavedSearches[name] = search
+	}
+
+	for name, d := range s.Dashboards {
+		dash := d.Copy()
+		dash.Type = ""
+		model.Dashboards[name] = dash
+	}
+
+	for name, m := range s.Monitors {
+		mon := m.Copy()
+		mon.Type = ""
+		model.Monitors[name] = mon
+	}
+
+	for name, p := range s.Parsers {
+		par := p.Copy()
+		par.Type = ""
+		model.Parsers[name] = par
+	}
+
+	for name, f := range s.Folders {
+		fold := f.Copy()
+		fold.Type = ""
+		fold.Children = nil
+		model.Folders[name] = fold
+	}
+
+	//Only the root folder's permissions are compared
+	if s.RootFolder != nil {
+		model.RootFolder = &folder{Permissions: s.RootFolder.Permissions}
+	}
+
+	return model
+}
+
+// addObjectKeys adds the "type/key" of every object in the overlay to keys
+func (s *appOverlay) addObjectKeys(keys map[string]bool) {
+	for _, list := range s.objectLists() {
+		for _, key := range sortedKeys(list.objects) {
+			keys[list.objectType+"/"+key] = true
+		}
+	}
+}
+
+// keepObjects removes the objects whose "type/key" isn't in keys
+func (s *appOverlay) keepObjects(keys map[string]bool) {
+	for _, list := range s.objectLists() {
+		objects := reflect.ValueOf(list.objects)
+
+		for _, key := range objects.MapKeys() {
+			if !keys[list.objectType+"/"+key.String()] {
+				objects.SetMapIndex(key, reflect.Value{})
+			}
+		}
+	}
+
+	if !keys["folder/"+RootFolderKey] {
+		s.RootFolder = nil
+	}
+}
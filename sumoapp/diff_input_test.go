@@ -0,0 +1,216 @@
+package sumoapp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadExportInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		export      string
+		wantErr     bool
+		wantPartial bool
+		wantSearch  bool
+	}{
+		{
+			name:        "saved search",
+			export:      `{"type": "SavedSearchWithScheduleSyncDefinition", "name": "Errors", "description": "", "search": {"queryText": "error | count"}}`,
+			wantPartial: true,
+			wantSearch:  true,
+		},
+		{
+			name:   "folder",
+			export: `{"type": "FolderSyncDefinition", "name": "App", "description": "", "children": []}`,
+		},
+		{
+			name:    "unsupported type",
+			export:  `{"type": "LookupTableSyncDefinition", "name": "Hosts"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			export:  `name: Errors`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := NewApplication().loadExportInput([]byte(tt.export))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadExportInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if input.partial != tt.wantPartial {
+				t.Errorf("partial = %v, want %v", input.partial, tt.wantPartial)
+			}
+
+			if got := len(input.overlay.SavedSearches) == 1; got != tt.wantSearch {
+				t.Errorf("saved searches = %v, want one: %v", input.overlay.SavedSearches, tt.wantSearch)
+			}
+		})
+	}
+}
+
+func TestLoadComponentInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		content string
+		wantErr bool
+		check   func(s *appOverlay) bool
+	}{
+		{
+			name:    "panel",
+			dir:     "panels",
+			content: "errors:\n  key: errors\n  title: Errors\n",
+			check:   func(s *appOverlay) bool { return s.Panels["errors"] != nil && s.Panels["errors"].Title == "Errors" },
+		},
+		{
+			name:    "variable",
+			dir:     "variables",
+			content: "host:\n  name: host\n",
+			check:   func(s *appOverlay) bool { return s.Variables["host"] != nil },
+		},
+		{
+			name:    "unknown directory",
+			dir:     "widgets",
+			content: "errors:\n  title: Errors\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			dir:     "panels",
+			content: "errors:\n  colour: red\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), tt.dir)
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(dir, "component.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			input, err := NewApplication().loadComponentInput(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadComponentInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !input.partial {
+				t.Errorf("component inputs should be partial")
+			}
+
+			if !tt.check(input.overlay) {
+				t.Errorf("component wasn't loaded into the overlay")
+			}
+		})
+	}
+}
+
+func TestKeepObjects(t *testing.T) {
+	s := NewAppOverlay("base", NewApplication())
+	s.Panels["a"] = &panel{Key: "a"}
+	s.Panels["b"] = &panel{Key: "b"}
+	s.Dashboards["a"] = &dashboard{Name: "a"}
+	s.RootFolder = &folder{}
+
+	s.keepObjects(map[string]bool{"panel/a": true})
+
+	if _, ok := s.Panels["a"]; !ok {
+		t.Errorf("panel a was removed")
+	}
+
+	if _, ok := s.Panels["b"]; ok {
+		t.Errorf("panel b was kept")
+	}
+
+	if _, ok := s.Dashboards["a"]; ok {
+		t.Errorf("dashboard a was kept, though only panel a was")
+	}
+
+	if s.RootFolder != nil {
+		t.Errorf("the root folder was kept")
+	}
+}
+
+func TestCompareInputs(t *testing.T) {
+	dir := writeAppFiles(t, t.TempDir(), map[string]string{
+		"base/init.yaml":                      testInitYaml,
+		"base/panels/errors.yaml":             testBasePanels,
+		"base/saved-searches/top-errors.yaml": "top-errors:\n  name: Top Errors\n  description: \"\"\n  search:\n    querytext: error | count\n",
+		"changed/panels/errors.yaml":          strings.Replace(testBasePanels, "title: Errors", "title: Server Errors", 1),
+	})
+
+	tests := []struct {
+		name        string
+		from        string
+		to          string
+		wantChanges int
+		wantErr     bool
+	}{
+		{
+			name: "saved search component and its overlay",
+			from: "base/saved-searches/top-errors.yaml",
+			to:   "base",
+		},
+		{
+			name: "panel component and its overlay",
+			from: "base/panels/errors.yaml",
+			to:   "base",
+		},
+		{
+			name: "absolute path",
+			from: filepath.Join(dir, "base", "panels", "errors.yaml"),
+			to:   "base",
+		},
+		{
+			name:        "changed component",
+			from:        "base/panels/errors.yaml",
+			to:          "changed/panels/errors.yaml",
+			wantChanges: 1,
+		},
+		{
+			name:    "missing file",
+			from:    "base/panels/missing.yaml",
+			to:      "base",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApplicationWithPath(dir)
+
+			cs, err := app.CompareInputs(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CompareInputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if cs.Len() != tt.wantChanges {
+				t.Errorf("CompareInputs() found %d change(s), want %d: %+v", cs.Len(), tt.wantChanges, cs.FieldChanges())
+			}
+		})
+	}
+}
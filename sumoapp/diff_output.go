@@ -0,0 +1,301 @@
+package sumoapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/r3labs/diff"
+	"gopkg.in/yaml.v2"
+)
+
+// DiffOutputFormats lists the formats a changeSet can be written in
+var DiffOutputFormats = []string{"text", "json", "yaml", "markdown", "html"}
+
+// objectTypeOrder is the order object types are listed in diff output
+var objectTypeOrder = []string{"variable", "panel", "saved-search", "dashboard", "folder", "monitor", "parser"}
+
+// fieldChange is a single change to a field of an object. An empty Path
+// means the whole object was created or deleted
+type fieldChange struct {
+	ObjectType string      `json:"objectType" yaml:"objectType"`
+	Key        string      `json:"key" yaml:"key"`
+	Path       string      `json:"path" yaml:"path"`
+	ChangeType string      `json:"changeType" yaml:"changeType"`
+	From       interface{} `json:"from" yaml:"from"`
+	To         interface{} `json:"to" yaml:"to"`
+	// Layout is set for changes to a panel's position on a dashboard
+	Layout *layoutChange `json:"layout,omitempty" yaml:"layout,omitempty"`
+	// QueryDiff is set for changes to a query, diffed by pipe stage
+	QueryDiff []queryLine `json:"queryDiff,omitempty" yaml:"queryDiff,omitempty"`
+}
+
+type diffSummary struct {
+	Total   int            `json:"total" yaml:"total"`
+	Objects int            `json:"objects" yaml:"objects"`
+	ByType  map[string]int `json:"byType" yaml:"byType"`
+}
+
+type structuredChangeSet struct {
+	Summary diffSummary   `json:"summary" yaml:"summary"`
+	Changes []fieldChange `json:"changes" yaml:"changes"`
+}
+
+// FieldChanges flattens the change set into individual field changes,
+// ordered by object type, object key, and field path
+func (cs *changeSet) FieldChanges() []fieldChange {
+	changes := make([]fieldChange, 0)
+
+	for _, c := range cs.all() {
+		changes = append(changes, newFieldChange(c))
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.ObjectType != b.ObjectType {
+			return objectTypeIndex(a.ObjectType) < objectTypeIndex(b.ObjectType)
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Path < b.Path
+	})
+
+	return changes
+}
+
+func newFieldChange(c diff.Change) fieldChange {
+	fc := fieldChange{
+		ObjectType: c.Path[0],
+		ChangeType: c.Type,
+		From:       c.From,
+		To:         c.To,
+	}
+
+	if len(c.Path) > 1 {
+		fc.Key = c.Path[1]
+	}
+
+	if len(c.Path) > 2 {
+		fc.Path = strings.Join(c.Path[2:], ".")
+		fc.addSemanticDiff(c.Path[2:])
+	}
+
+	return fc
+}
+
+func objectTypeIndex(objectType string) int {
+	for i, t := range objectTypeOrder {
+		if t == objectType {
+			return i
+		}
+	}
+
+	return len(objectTypeOrder)
+}
+
+// Structured returns the change set with a summary, in the shape it's
+// written as JSON and YAML
+func (cs *changeSet) Structured() structuredChangeSet {
+	changes := cs.FieldChanges()
+
+	summary := diffSummary{
+		Total:  len(changes),
+		ByType: make(map[string]int),
+	}
+
+	objects := make(map[string]bool)
+	for _, c := range changes {
+		summary.ByType[c.ObjectType]++
+		objects[c.ObjectType+"/"+c.Key] = true
+	}
+	summary.Objects = len(objects)
+
+	return structuredChangeSet{
+		Summary: summary,
+		Changes: changes,
+	}
+}
+
+// Write writes the change set to w in one of the DiffOutputFormats
+func (cs *changeSet) Write(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		displayDiff(w, cs)
+		return nil
+	case "json":
+		j, err := canonicalJSON(cs.Structured())
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(j)
+		return err
+	case "yaml":
+		y, err := yaml.Marshal(toYamlValue(cs.Structured()))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(y)
+		return err
+	case "markdown":
+		return writeMarkdownDiff(w, cs)
+	case "html":
+		return writeHTMLDiff(w, cs)
+	}
+
+	return fmt.Errorf("Unknown output format '%s'. Expected one of: %s", format, strings.Join(DiffOutputFormats, ", "))
+}
+
+// toYamlValue converts v to generic values through JSON, so objects are
+// written with the same field names in YAML output as in JSON output
+func toYamlValue(v interface{}) interface{} {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var generic yaml.MapSlice
+	if err := yaml.Unmarshal(j, &generic); err != nil {
+		return v
+	}
+
+	return generic
+}
+
+type objectChanges struct {
+	objectType string
+	key        string
+	changes    []fieldChange
+}
+
+// groupFieldChanges groups the field changes by object, keeping their order
+func groupFieldChanges(changes []fieldChange) []objectChanges {
+	groups := make([]objectChanges, 0)
+
+	for _, c := range changes {
+		last := len(groups) - 1
+		if last >= 0 && groups[last].objectType == c.ObjectType && groups[last].key == c.Key {
+			groups[last].changes = append(groups[last].changes, c)
+			continue
+		}
+
+		groups = append(groups, objectChanges{
+			objectType: c.ObjectType,
+			key:        c.Key,
+			changes:    []fieldChange{c},
+		})
+	}
+
+	return groups
+}
+
+func writeMarkdownDiff(w io.Writer, cs *changeSet) error {
+	changes := cs.FieldChanges()
+	groups := groupFieldChanges(changes)
+
+	fmt.Fprintln(w, "### Application diff")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Found %d change(s) in %d object(s)\n", len(changes), len(groups))
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Type | Object | Change | Fields changed |")
+	fmt.Fprintln(w, "|---|---|---|---|")
+	for _, g := range groups {
+		fmt.Fprintf(w, "| %s | `%s` | %s | %d |\n", g.objectType, markdownEscape(g.key), objectChangeType(g.changes), len(g.changes))
+	}
+
+	for _, g := range groups {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "<details>")
+		fmt.Fprintf(w, "<summary>%s <code>%s</code>: %d change(s)</summary>\n", g.objectType, htmlEscaper.Replace(g.key), len(g.changes))
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Field | Change | From | To |")
+		fmt.Fprintln(w, "|---|---|---|---|")
+		for _, c := range g.changes {
+			path := c.Path
+			if path == "" {
+				path = "(object)"
+			}
+			changeType := c.ChangeType
+			if c.Layout != nil {
+				changeType = c.Layout.Describe()
+			}
+			fmt.Fprintf(w, "| `%s` | %s | %s | %s |\n", markdownEscape(path), changeType, markdownValue(c.From), markdownValue(c.To))
+		}
+		fmt.Fprintln(w)
+
+		//Query changes are easier to review as a diff than as two
+		//table cells
+		for _, c := range g.changes {
+			if c.QueryDiff == nil {
+				continue
+			}
+
+			fmt.Fprintf(w, "`%s`:\n", markdownEscape(c.Path))
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "```diff")
+			fmt.Fprint(w, unifiedQueryDiff(c.QueryDiff))
+			fmt.Fprintln(w, "```")
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, "</details>")
+	}
+
+	return nil
+}
+
+// objectChangeType describes what happened to an object as a whole
+func objectChangeType(changes []fieldChange) string {
+	if len(changes) == 1 && changes[0].Path == "" {
+		switch changes[0].ChangeType {
+		case diff.CREATE:
+			return "added"
+		case diff.DELETE:
+			return "removed"
+		}
+	}
+
+	return "modified"
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// markdownValue renders a changed value for a markdown table cell.
+// Values that aren't strings are rendered as compact JSON
+func markdownValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	var s string
+	if str, ok := v.(string); ok {
+		s = str
+	} else {
+		j, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(j)
+		}
+	}
+
+	if s == "" {
+		return `""`
+	}
+
+	//Table cells can't hold code spans with newlines, so fall back to
+	//HTML which can
+	s = strings.ReplaceAll(htmlEscaper.Replace(s), "\n", "<br>")
+	return "<code>" + markdownEscape(s) + "</code>"
+}
@@ -0,0 +1,250 @@
+package sumoapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/r3labs/diff"
+)
+
+// layoutPosition is the position of a panel in a dashboard's grid. Layout
+// structures store it as a JSON string
+type layoutPosition struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// layoutChange describes a change to where a panel sits on a dashboard
+type layoutChange struct {
+	From    *layoutPosition `json:"from"`
+	To      *layoutPosition `json:"to"`
+	Moved   bool            `json:"moved"`
+	Resized bool            `json:"resized"`
+}
+
+// queryLine is one pipe stage of a query diff. Op is "=" for unchanged
+// stages, "-" for removed stages, and "+" for added stages
+type queryLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// isLayoutPath reports whether a field path points at a layout structure
+// or its Structure field
+func isLayoutPath(path []string) bool {
+	if len(path) < 3 || path[0] != "Layout" || path[1] != "LayoutStructures" {
+		return false
+	}
+
+	return len(path) == 3 || (len(path) == 4 && path[3] == "Structure")
+}
+
+// isQueryPath reports whether a field path points at a query string. Panel
+// queries are in QueryString and monitor queries in Query. A field
+// extraction rule's parse expression is diffed like a query too
+func isQueryPath(path []string) bool {
+	if len(path) == 3 && path[0] == "Queries" && (path[2] == "QueryString" || path[2] == "Query") {
+		return true
+	}
+
+	if len(path) == 1 && path[0] == "ParseExpression" {
+		return true
+	}
+
+	return len(path) == 2 && path[0] == "Search" && path[1] == "QueryText"
+}
+
+// parseLayoutPosition reads the position out of a layout structure or
+// its Structure JSON string
+func parseLayoutPosition(v interface{}) *layoutPosition {
+	var structure string
+
+	switch value := v.(type) {
+	case string:
+		structure = value
+	case layoutStructure:
+		structure = value.Structure
+	case *layoutStructure:
+		structure = value.Structure
+	default:
+		return nil
+	}
+
+	var position layoutPosition
+	if err := json.Unmarshal([]byte(structure), &position); err != nil {
+		return nil
+	}
+
+	return &position
+}
+
+func newLayoutChange(from interface{}, to interface{}) *layoutChange {
+	lc := &layoutChange{
+		From: parseLayoutPosition(from),
+		To:   parseLayoutPosition(to),
+	}
+
+	if lc.From == nil && lc.To == nil {
+		return nil
+	}
+
+	if lc.From != nil && lc.To != nil {
+		lc.Moved = lc.From.X != lc.To.X || lc.From.Y != lc.To.Y
+		lc.Resized = lc.From.Width != lc.To.Width || lc.From.Height != lc.To.Height
+	}
+
+	return lc
+}
+
+func (p *layoutPosition) String() string {
+	return fmt.Sprintf("%dx%d at x=%d,y=%d", p.Width, p.Height, p.X, p.Y)
+}
+
+// Describe summarizes the layout change in a short sentence
+func (lc *layoutChange) Describe() string {
+	switch {
+	case lc.From == nil:
+		return fmt.Sprintf("placed %s", lc.To)
+	case lc.To == nil:
+		return fmt.Sprintf("removed from %s", lc.From)
+	case lc.Moved && lc.Resized:
+		return fmt.Sprintf("moved and resized from %s to %s", lc.From, lc.To)
+	case lc.Moved:
+		return fmt.Sprintf("moved from x=%d,y=%d to x=%d,y=%d", lc.From.X, lc.From.Y, lc.To.X, lc.To.Y)
+	case lc.Resized:
+		return fmt.Sprintf("resized from %dx%d to %dx%d", lc.From.Width, lc.From.Height, lc.To.Width, lc.To.Height)
+	}
+
+	return "unchanged position, other layout settings changed"
+}
+
+// splitQueryStages splits a query into its pipe stages, ignoring pipes
+// inside quotes. Stages after the first keep their leading pipe
+func splitQueryStages(query string) []string {
+	stages := make([]string, 0)
+
+	var (
+		current strings.Builder
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range query {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '|':
+			if stage := strings.TrimSpace(current.String()); stage != "" || len(stages) > 0 {
+				stages = append(stages, stage)
+			}
+			current.Reset()
+		}
+
+		current.WriteRune(r)
+	}
+
+	if stage := strings.TrimSpace(current.String()); stage != "" {
+		stages = append(stages, stage)
+	}
+
+	//Normalize the whitespace inside each stage so reformatting a query
+	//isn't reported as a change
+	for i, stage := range stages {
+		stages[i] = strings.Join(strings.Fields(stage), " ")
+	}
+
+	return stages
+}
+
+// diffQuery diffs two queries pipe stage by pipe stage
+func diffQuery(from string, to string) []queryLine {
+	a := splitQueryStages(from)
+	b := splitQueryStages(to)
+
+	//Longest common subsequence table of the stages
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]queryLine, 0)
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, queryLine{Op: "=", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, queryLine{Op: "-", Text: a[i]})
+			i++
+		default:
+			lines = append(lines, queryLine{Op: "+", Text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		lines = append(lines, queryLine{Op: "-", Text: a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		lines = append(lines, queryLine{Op: "+", Text: b[j]})
+	}
+
+	return lines
+}
+
+// unifiedQueryDiff renders a query diff like a unified diff body
+func unifiedQueryDiff(lines []queryLine) string {
+	var b strings.Builder
+
+	for _, line := range lines {
+		op := line.Op
+		if op == "=" {
+			op = " "
+		}
+
+		b.WriteString(op + " " + line.Text + "\n")
+	}
+
+	return b.String()
+}
+
+// addSemanticDiff describes layout and query changes in terms of what
+// they mean, rather than as opaque strings
+func (fc *fieldChange) addSemanticDiff(fieldPath []string) {
+	switch {
+	case isLayoutPath(fieldPath):
+		fc.Layout = newLayoutChange(fc.From, fc.To)
+	case isQueryPath(fieldPath) && fc.ChangeType == diff.UPDATE:
+		from, fromOk := fc.From.(string)
+		to, toOk := fc.To.(string)
+		if fromOk && toOk {
+			fc.QueryDiff = diffQuery(from, to)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package sumoapp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitQueryStages(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "empty",
+			query: "",
+			want:  []string{},
+		},
+		{
+			name:  "single stage",
+			query: "_sourceCategory=prod error",
+			want:  []string{"_sourceCategory=prod error"},
+		},
+		{
+			name:  "pipe stages",
+			query: "_sourceCategory=prod | parse \"a=*\" as a | count by a",
+			want:  []string{"_sourceCategory=prod", "| parse \"a=*\" as a", "| count by a"},
+		},
+		{
+			name:  "pipe in double quotes",
+			query: "error | where msg = \"a|b\" | count",
+			want:  []string{"error", "| where msg = \"a|b\"", "| count"},
+		},
+		{
+			name:  "pipe in single quotes",
+			query: "error | where msg = 'a|b'",
+			want:  []string{"error", "| where msg = 'a|b'"},
+		},
+		{
+			name:  "escaped quote",
+			query: "error | where msg = \"a\\\"|b\" | count",
+			want:  []string{"error", "| where msg = \"a\\\"|b\"", "| count"},
+		},
+		{
+			name:  "whitespace is normalized",
+			query: "  error\n|   count   by\t_sourceHost ",
+			want:  []string{"error", "| count by _sourceHost"},
+		},
+		{
+			name:  "leading pipe",
+			query: "| count",
+			want:  []string{"| count"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitQueryStages(tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitQueryStages(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []queryLine
+	}{
+		{
+			name: "unchanged",
+			from: "error | count",
+			to:   "error  |  count",
+			want: []queryLine{{"=", "error"}, {"=", "| count"}},
+		},
+		{
+			name: "added stage",
+			from: "error | count",
+			to:   "error | where x > 1 | count",
+			want: []queryLine{{"=", "error"}, {"+", "| where x > 1"}, {"=", "| count"}},
+		},
+		{
+			name: "removed stage",
+			from: "error | where x > 1 | count",
+			to:   "error | count",
+			want: []queryLine{{"=", "error"}, {"-", "| where x > 1"}, {"=", "| count"}},
+		},
+		{
+			name: "changed stage",
+			from: "error | count by a",
+			to:   "error | count by b",
+			want: []queryLine{{"=", "error"}, {"-", "| count by a"}, {"+", "| count by b"}},
+		},
+		{
+			name: "from empty",
+			from: "",
+			to:   "error | count",
+			want: []queryLine{{"+", "error"}, {"+", "| count"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffQuery(tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffQuery(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedQueryDiff(t *testing.T) {
+	lines := []queryLine{{"=", "error"}, {"-", "| count by a"}, {"+", "| count by b"}}
+	want := "  error\n- | count by a\n+ | count by b\n"
+
+	if got := unifiedQueryDiff(lines); got != want {
+		t.Errorf("unifiedQueryDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestNewLayoutChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     interface{}
+		to       interface{}
+		describe string
+	}{
+		{
+			name:     "moved",
+			from:     `{"x":0,"y":0,"width":6,"height":4}`,
+			to:       `{"x":6,"y":0,"width":6,"height":4}`,
+			describe: "moved from x=0,y=0 to x=6,y=0",
+		},
+		{
+			name:     "resized",
+			from:     `{"x":0,"y":0,"width":6,"height":4}`,
+			to:       `{"x":0,"y":0,"width":12,"height":4}`,
+			describe: "resized from 6x4 to 12x4",
+		},
+		{
+			name:     "moved and resized",
+			from:     layoutStructure{Key: "p", Structure: `{"x":0,"y":0,"width":6,"height":4}`},
+			to:       &layoutStructure{Key: "p", Structure: `{"x":0,"y":4,"width":12,"height":4}`},
+			describe: "moved and resized from 6x4 at x=0,y=0 to 12x4 at x=0,y=4",
+		},
+		{
+			name:     "placed",
+			from:     nil,
+			to:       `{"x":0,"y":0,"width":6,"height":4}`,
+			describe: "placed 6x4 at x=0,y=0",
+		},
+		{
+			name:     "removed",
+			from:     `{"x":0,"y":0,"width":6,"height":4}`,
+			to:       nil,
+			describe: "removed from 6x4 at x=0,y=0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := newLayoutChange(tt.from, tt.to)
+			if lc == nil {
+				t.Fatalf("newLayoutChange() = nil")
+			}
+
+			if got := lc.Describe(); got != tt.describe {
+				t.Errorf("Describe() = %q, want %q", got, tt.describe)
+			}
+		})
+	}
+
+	if lc := newLayoutChange("not json", 42); lc != nil {
+		t.Errorf("newLayoutChange() of values without positions = %v, want nil", lc)
+	}
+}
+
+func TestIsQueryPath(t *testing.T) {
+	tests := []struct {
+		path []string
+		want bool
+	}{
+		{[]string{"Queries", "A", "QueryString"}, true},
+		{[]string{"Queries", "A", "Query"}, true},
+		{[]string{"Search", "QueryText"}, true},
+		{[]string{"ParseExpression"}, true},
+		{[]string{"Queries", "A", "QueryType"}, false},
+		{[]string{"Title"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := isQueryPath(tt.path); got != tt.want {
+			t.Errorf("isQueryPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package sumoapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/r3labs/diff"
+	"gopkg.in/yaml.v2"
+)
+
+// Statuses of an overridden field in a three-way diff
+const (
+	// OverrideAdded is a field, or object, the parent overlay doesn't have
+	OverrideAdded = "added"
+	// OverrideChanged is a field the overlay sets to a new value
+	OverrideChanged = "override"
+	// OverrideRedundant is a field the overlay sets to the value it already inherits
+	OverrideRedundant = "redundant"
+	// OverrideIneffective is a field whose merged value isn't the value the
+	// overlay sets. Merging skips empty values, such as "", 0, and false
+	OverrideIneffective = "ineffective"
+)
+
+// overrideField is a field set in an overlay's files along with the value
+// it inherits from the parent overlay and the value it has once merged.
+// Parent is the parent overlay's merged value, not the base overlay's, so
+// for final it's the value middle ends up with
+type overrideField struct {
+	ObjectType string      `json:"objectType" yaml:"objectType"`
+	Key        string      `json:"key" yaml:"key"`
+	Path       string      `json:"path" yaml:"path"`
+	Status     string      `json:"status" yaml:"status"`
+	Parent     interface{} `json:"parent" yaml:"parent"`
+	Override   interface{} `json:"override" yaml:"override"`
+	Merged     interface{} `json:"merged" yaml:"merged"`
+	file       string
+	fieldPath  []string
+}
+
+type threeWaySummary struct {
+	Total    int            `json:"total" yaml:"total"`
+	ByStatus map[string]int `json:"byStatus" yaml:"byStatus"`
+}
+
+// threeWayDiff lists every field an overlay overrides
+type threeWayDiff struct {
+	Overlay string          `json:"overlay" yaml:"overlay"`
+	Parent  string          `json:"parent" yaml:"parent"`
+	Summary threeWaySummary `json:"summary" yaml:"summary"`
+	Fields  []overrideField `json:"fields" yaml:"fields"`
+}
+
+// ThreeWayDiff compares every field set in the overlay's own files with the
+// value it inherits from the parent overlay and the value it has after the
+// overlays are merged. The application's overlays must be loaded first
+func (s *appOverlay) ThreeWayDiff() (*threeWayDiff, error) {
+	if !s.HasParent() {
+		return nil, fmt.Errorf("App overlay '%s' has no parent overlay to compare with", s.Name)
+	}
+
+	d := &threeWayDiff{
+		Overlay: s.Name,
+		Parent:  s.Parent.Name,
+		Fields:  make([]overrideField, 0),
+	}
+
+	components := []struct {
+		objectType string
+		dir        string
+		parent     interface{}
+		merged     interface{}
+	}{
+		{"variable", "variables", s.Parent.Variables, s.Variables},
+		{"panel", "panels", s.Parent.Panels, s.Panels},
+		{"saved-search", "saved-searches", s.Parent.SavedSearches, s.SavedSearches},
+		{"dashboard", "dashboards", s.Parent.Dashboards, s.Dashboards},
+		{"folder", "folders", s.Parent.Folders, s.Folders},
+		{"monitor", "monitors", s.Parent.Monitors, s.Monitors},
+		{"parser", "parsers", s.Parent.Parsers, s.Parsers},
+	}
+
+	for _, c := range components {
+		overrides, files, err := readOverrideFiles(filepath.Join(s.Path, c.dir))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range sortedKeys(overrides) {
+			parent, hasParent, err := genericMapValue(c.parent, key)
+			if err != nil {
+				return nil, err
+			}
+
+			merged, _, err := genericMapValue(c.merged, key)
+			if err != nil {
+				return nil, err
+			}
+
+			typed, err := typedOverride(c.merged, overrides[key])
+			if err != nil {
+				return nil, fmt.Errorf("Unable to decode %s '%s' in %s: %w", c.objectType, key, files[key], err)
+			}
+
+			for _, fieldPath := range leafPaths(overrides[key], nil) {
+				override, ok := lookupPath(typed, fieldPath)
+				if !ok {
+					override, _ = lookupPath(overrides[key], fieldPath)
+				}
+				mergedValue, _ := lookupPath(merged, fieldPath)
+
+				field := overrideField{
+					ObjectType: c.objectType,
+					Key:        key,
+					Path:       strings.Join(fieldPath, "."),
+					Override:   stringKeyed(override),
+					Merged:     stringKeyed(mergedValue),
+					file:       files[key],
+					fieldPath:  fieldPath,
+				}
+
+				parentValue, inherited := lookupPath(parent, fieldPath)
+				if hasParent && inherited {
+					field.Parent = stringKeyed(parentValue)
+				}
+
+				switch {
+				case !hasParent || !inherited:
+					field.Status = OverrideAdded
+				case reflect.DeepEqual(parentValue, override):
+					field.Status = OverrideRedundant
+				case !reflect.DeepEqual(mergedValue, override):
+					field.Status = OverrideIneffective
+				default:
+					field.Status = OverrideChanged
+				}
+
+				d.Fields = append(d.Fields, field)
+			}
+		}
+	}
+
+	d.summarize()
+
+	return d, nil
+}
+
+// readOverrideFiles reads the objects in an overlay's component directory
+// as written, without merging them with the parent overlay. It also
+// returns the file each object was read from
+func readOverrideFiles(dir string) (map[string]interface{}, map[string]string, error) {
+	objects := make(map[string]interface{})
+	objectFiles := make(map[string]string)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+		if filepath.Ext(path) != ".yaml" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var fileObjects map[string]interface{}
+		if err := yaml.Unmarshal(data, &fileObjects); err != nil {
+			return nil, nil, fmt.Errorf("Unable to decode %s: %w", path, err)
+		}
+
+		for key, obj := range fileObjects {
+			objects[key] = obj
+			objectFiles[key] = path
+		}
+	}
+
+	return objects, objectFiles, nil
+}
+
+// genericMapValue returns the object at key in a map of components the way
+// it's written in YAML files, so it can be compared with override files
+func genericMapValue(objects interface{}, key string) (interface{}, bool, error) {
+	value := reflect.ValueOf(objects).MapIndex(reflect.ValueOf(key))
+	if !value.IsValid() {
+		return nil, false, nil
+	}
+
+	generic, err := genericValue(value.Interface())
+	if err != nil {
+		return nil, false, err
+	}
+
+	return generic, true, nil
+}
+
+// typedOverride decodes an object read from an overlay file into the type
+// of the objects in the map, and returns it the way genericMapValue would.
+// Lists are leaves that are compared as a whole, and an overlay's list
+// items only set some fields, so they're given the same empty fields as
+// the inherited and merged lists before they're compared
+func typedOverride(objects interface{}, override interface{}) (interface{}, error) {
+	data, err := yaml.Marshal(override)
+	if err != nil {
+		return nil, err
+	}
+
+	objectType := reflect.TypeOf(objects).Elem()
+	if objectType.Kind() == reflect.Ptr {
+		objectType = objectType.Elem()
+	}
+
+	obj := reflect.New(objectType)
+	if err := yaml.Unmarshal(data, obj.Interface()); err != nil {
+		return nil, err
+	}
+
+	return genericValue(obj.Interface())
+}
+
+// genericValue returns v the way it's written in YAML files
+func genericValue(v interface{}) (interface{}, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+// leafPaths returns the path of every value below v that isn't a map.
+// Lists are leaves because merging replaces them as a whole
+func leafPaths(v interface{}, prefix []string) [][]string {
+	m, ok := v.(map[interface{}]interface{})
+	if !ok || len(m) == 0 {
+		if prefix == nil {
+			return nil
+		}
+		return [][]string{prefix}
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, fmt.Sprint(k))
+	}
+	sort.Strings(keys)
+
+	paths := make([][]string, 0)
+	for _, k := range keys {
+		path := append(append([]string{}, prefix...), k)
+		paths = append(paths, leafPaths(m[k], path)...)
+	}
+
+	return paths
+}
+
+// lookupPath returns the value at path in a generic YAML value
+func lookupPath(v interface{}, path []string) (interface{}, bool) {
+	for _, segment := range path {
+		m, ok := v.(map[interface{}]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		v, ok = m[segment]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// stringKeyed converts generic YAML maps to maps with string keys so they
+// can be written as JSON
+func stringKeyed(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[fmt.Sprint(k)] = stringKeyed(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = stringKeyed(item)
+		}
+		return list
+	}
+
+	return v
+}
+
+func (d *threeWayDiff) summarize() {
+	d.Summary = threeWaySummary{
+		Total:    len(d.Fields),
+		ByStatus: make(map[string]int),
+	}
+
+	for _, f := range d.Fields {
+		d.Summary.ByStatus[f.Status]++
+	}
+}
+
+// KeepStatuses removes the fields whose status isn't one of statuses
+func (d *threeWayDiff) KeepStatuses(statuses []string) error {
+	known := []string{OverrideAdded, OverrideChanged, OverrideRedundant, OverrideIneffective}
+	for _, status := range statuses {
+		if !containsString(known, status) {
+			return fmt.Errorf("Unknown status '%s'. Expected one of: %s", status, strings.Join(known, ", "))
+		}
+	}
+
+	kept := make([]overrideField, 0, len(d.Fields))
+	for _, f := range d.Fields {
+		if containsString(statuses, f.Status) {
+			kept = append(kept, f)
+		}
+	}
+
+	d.Fields = kept
+	d.summarize()
+
+	return nil
+}
+
+// ApplyFilter removes the fields the filter doesn't keep
+func (d *threeWayDiff) ApplyFilter(f DiffFilter) {
+	kept := make([]overrideField, 0, len(d.Fields))
+	for _, field := range d.Fields {
+		path := append([]string{field.ObjectType, field.Key}, strings.Split(field.Path, ".")...)
+		if f.keep(diff.Change{Path: path}) {
+			kept = append(kept, field)
+		}
+	}
+
+	d.Fields = kept
+	d.summarize()
+}
+
+// Len returns the number of overridden fields
+func (d *threeWayDiff) Len() int {
+	return len(d.Fields)
+}
+
+// Write writes the three-way diff to w in one of the DiffOutputFormats
+func (d *threeWayDiff) Write(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		d.display(w)
+		return nil
+	case "json":
+		j, err := canonicalJSON(d)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(j)
+		return err
+	case "yaml":
+		y, err := yaml.Marshal(toYamlValue(d))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(y)
+		return err
+	case "markdown":
+		d.writeMarkdown(w)
+		return nil
+	case "html":
+		return threeWayHTML.Execute(w, d)
+	}
+
+	return fmt.Errorf("Unknown output format '%s'. Expected one of: %s", format, strings.Join(DiffOutputFormats, ", "))
+}
+
+func (d *threeWayDiff) display(w io.Writer) {
+	fmt.Fprintf(w, "Found %d override(s) in app overlay %s (parent: %s)\n", len(d.Fields), d.Overlay, d.Parent)
+
+	lastObject := ""
+	for _, f := range d.Fields {
+		if object := f.ObjectType + " " + f.Key; object != lastObject {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "In", object)
+			lastObject = object
+		}
+
+		fmt.Fprintf(w, "At %s (%s)\n", f.Path, f.Status)
+		fmt.Fprintf(w, "  %-9s %s\n", d.Parent+":", inlineValue(f.Parent))
+		fmt.Fprintf(w, "  %-9s %s\n", d.Overlay+":", inlineValue(f.Override))
+		fmt.Fprintf(w, "  %-9s %s\n", "merged:", inlineValue(f.Merged))
+	}
+}
+
+func (d *threeWayDiff) writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "### Overrides in app overlay `%s`\n", d.Overlay)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Found %d override(s), %d redundant\n", len(d.Fields), d.Summary.ByStatus[OverrideRedundant])
+
+	if len(d.Fields) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "| Type | Object | Field | Status | %s | %s | Merged |\n", d.Parent, d.Overlay)
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|")
+	for _, f := range d.Fields {
+		fmt.Fprintf(w, "| %s | `%s` | `%s` | %s | %s | %s | %s |\n", f.ObjectType, markdownEscape(f.Key), markdownEscape(f.Path), f.Status, markdownValue(f.Parent), markdownValue(f.Override), markdownValue(f.Merged))
+	}
+}
+
+// inlineValue renders a value on a single line for text output
+func inlineValue(v interface{}) string {
+	if v == nil {
+		return "(not set)"
+	}
+
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(j)
+}
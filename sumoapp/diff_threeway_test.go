@@ -0,0 +1,115 @@
+package sumoapp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestThreeWayDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		path     string
+		want     string
+	}{
+		{
+			name:     "new value",
+			override: "errors:\n  title: Server Errors\n",
+			path:     "title",
+			want:     OverrideChanged,
+		},
+		{
+			name:     "inherited value",
+			override: "errors:\n  title: Errors\n",
+			path:     "title",
+			want:     OverrideRedundant,
+		},
+		{
+			name:     "empty value",
+			override: "errors:\n  title: \"\"\n",
+			path:     "title",
+			want:     OverrideIneffective,
+		},
+		{
+			name:     "new object",
+			override: "warnings:\n  key: warnings\n  title: Warnings\n",
+			path:     "title",
+			want:     OverrideAdded,
+		},
+		{
+			name:     "inherited list with fields left out",
+			override: "errors:\n  queries:\n  - querystring: error | count\n    querytype: Logs\n    querykey: A\n",
+			path:     "queries",
+			want:     OverrideRedundant,
+		},
+		{
+			name:     "new list with fields left out",
+			override: "errors:\n  queries:\n  - querystring: error | count by host\n    querykey: A\n",
+			path:     "queries",
+			want:     OverrideChanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeAppFiles(t, t.TempDir(), map[string]string{
+				"base/init.yaml":           testInitYaml,
+				"base/panels/errors.yaml":  testBasePanels,
+				"middle/panels/panel.yaml": tt.override,
+			})
+
+			app := NewApplicationWithPath(dir)
+			if err := app.LoadAppOverlays(); err != nil {
+				t.Fatal(err)
+			}
+
+			middle, err := app.FindAppOverlay("middle")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d, err := middle.ThreeWayDiff()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var status string
+			for _, f := range d.Fields {
+				if f.Path == tt.path {
+					status = f.Status
+				}
+			}
+
+			if status != tt.want {
+				t.Errorf("status of %s = %q, want %q in %+v", tt.path, status, tt.want, d.Fields)
+			}
+		})
+	}
+}
+
+func TestLeafPaths(t *testing.T) {
+	v := map[interface{}]interface{}{
+		"title": "Errors",
+		"timerange": map[interface{}]interface{}{
+			"from": map[interface{}]interface{}{"relativetime": "-1h"},
+		},
+		"queries":       []interface{}{map[interface{}]interface{}{"querykey": "A"}},
+		"coloringrules": map[interface{}]interface{}{},
+	}
+
+	got := make([]string, 0)
+	for _, path := range leafPaths(v, nil) {
+		got = append(got, filepath.ToSlash(filepath.Join(path...)))
+	}
+
+	want := []string{"coloringrules", "queries", "timerange/from/relativetime", "title"}
+	if len(got) != len(want) {
+		t.Fatalf("leafPaths() = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("leafPaths() = %v, want %v", got, want)
+		}
+	}
+}
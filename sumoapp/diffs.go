@@ -2,6 +2,7 @@ package sumoapp
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/r3labs/diff"
@@ -35,13 +36,62 @@ func taggedDiff(tag string, a, b interface{}) (diff.Changelog, error) {
 	return result, nil
 }
 
-func displayDiff(cs *changeSet) {
+// CompareBuilds diffs two builds, or exported folders, object by object.
+// Both sides are normalized with rules first so only changes to the
+// content itself are reported. The default rules are used if rules is nil
+func CompareBuilds(from []byte, to []byte, rules *normalizationRules) (changeSet, error) {
+	fromOverlay, toOverlay, err := importBuilds(from, to, rules)
+	if err != nil {
+		return changeSet{}, err
+	}
+
+	return fromOverlay.Changes(toOverlay)
+}
+
+// CompareContent diffs a build with a folder exported from the content
+// library, in either order. Content exports don't hold monitors or
+// parsers, so the build's monitors and parsers are left out of the
+// comparison
+func CompareContent(from []byte, to []byte, rules *normalizationRules) (changeSet, error) {
+	cs, err := CompareBuilds(from, to, rules)
+	if err != nil {
+		return changeSet{}, err
+	}
+
+	cs.ChangelogMonitor = nil
+	cs.ChangelogParser = nil
+
+	return cs, nil
+}
+
+// importBuilds imports two builds into overlays of a new application
+func importBuilds(from []byte, to []byte, rules *normalizationRules) (*appOverlay, *appOverlay, error) {
+	app := NewApplication()
+	app.normalization = rules
+	fromOverlay := app.NewAppOverlay("from")
+	toOverlay := app.NewAppOverlay("to")
+
+	if err := app.ImportBytesToOverlay(from, fromOverlay); err != nil {
+		return nil, nil, err
+	}
+
+	if err := app.ImportBytesToOverlay(to, toOverlay); err != nil {
+		return nil, nil, err
+	}
+
+	return fromOverlay, toOverlay, nil
+}
+
+// all returns the changes to every type of object in a single changelog
+func (cs *changeSet) all() diff.Changelog {
 	allChanges := []diff.Changelog{
 		cs.ChangelogVar,
 		cs.ChangelogPanel,
 		cs.ChangelogSavedSearches,
 		cs.ChangelogDashboard,
 		cs.ChangelogFolder,
+		cs.ChangelogMonitor,
+		cs.ChangelogParser,
 	}
 
 	var changelogs diff.Changelog
@@ -49,16 +99,67 @@ func displayDiff(cs *changeSet) {
 		changelogs = append(changelogs, c...)
 	}
 
-	fmt.Println("Found", len(changelogs), "changes")
-	fmt.Println()
-	displayDiffSection(cs.ChangelogVar)
-	displayDiffSection(cs.ChangelogPanel)
-	displayDiffSection(cs.ChangelogSavedSearches)
-	displayDiffSection(cs.ChangelogDashboard)
-	displayDiffSection(cs.ChangelogFolder)
+	return changelogs
 }
 
-func displayDiffSection(changes diff.Changelog) {
+// objectContainers maps "type/key" of each object in the overlay to the
+// "type/key" of the dashboard or folder that contains it. Objects in the
+// application's root folder aren't in the map
+func (s *appOverlay) objectContainers() map[string]string {
+	containers := make(map[string]string)
+
+	for _, fName := range sortedKeys(s.Folders) {
+		items := s.Folders[fName].Items
+		itemTypes := []struct {
+			objectType string
+			items      []string
+		}{
+			{"folder", items["folders"]},
+			{"dashboard", items["dashboards"]},
+			{"saved-search", items["savedSearches"]},
+		}
+
+		for _, itemType := range itemTypes {
+			for _, item := range itemType.items {
+				containers[itemType.objectType+"/"+item] = "folder/" + fName
+			}
+		}
+	}
+
+	//Panels and variables can be shared by dashboards. They're placed in
+	//the first dashboard that uses them
+	dashboardNames := sortedKeys(s.Dashboards)
+	for i := len(dashboardNames) - 1; i >= 0; i-- {
+		dName := dashboardNames[i]
+		d := s.Dashboards[dName]
+
+		for _, ls := range d.Layout.LayoutStructures {
+			containers["panel/"+ls.Key] = "dashboard/" + dName
+		}
+
+		for _, v := range d.IncludeVariables {
+			containers["variable/"+v] = "dashboard/" + dName
+		}
+	}
+
+	return containers
+}
+
+func displayDiff(w io.Writer, cs *changeSet) {
+	changelogs := cs.all()
+
+	fmt.Fprintln(w, "Found", len(changelogs), "changes")
+	fmt.Fprintln(w)
+	displayDiffSection(w, cs.ChangelogVar)
+	displayDiffSection(w, cs.ChangelogPanel)
+	displayDiffSection(w, cs.ChangelogSavedSearches)
+	displayDiffSection(w, cs.ChangelogDashboard)
+	displayDiffSection(w, cs.ChangelogFolder)
+	displayDiffSection(w, cs.ChangelogMonitor)
+	displayDiffSection(w, cs.ChangelogParser)
+}
+
+func displayDiffSection(w io.Writer, changes diff.Changelog) {
 	if len(changes) == 0 {
 		return
 	}
@@ -66,22 +167,42 @@ func displayDiffSection(changes diff.Changelog) {
 
 	groupedByFilename := groupChangesByFilename(changes)
 
-	fmt.Println("=========== ", category, " ============")
-	for fn, cs := range groupedByFilename {
-		fmt.Println("In", category, fn, ":", len(cs), "change(s)")
+	fmt.Fprintln(w, "=========== ", category, " ============")
+	for _, fn := range sortedKeys(groupedByFilename) {
+		cs := groupedByFilename[fn]
+		fmt.Fprintln(w, "In", category, fn, ":", len(cs), "change(s)")
 		for _, c := range cs {
 			if len(c.Path) > 2 {
-				fmt.Println("At", strings.Join(c.Path[2:], "."))
+				fmt.Fprintln(w, "At", strings.Join(c.Path[2:], "."))
 			}
-			if c.Type == "update" {
-				fmt.Println(writeDeleted(c.From))
-				fmt.Println(writeCreated(c.To), ColorReset)
+
+			fc := newFieldChange(c)
+			if fc.Layout != nil {
+				fmt.Fprintln(w, "Panel", fc.Layout.Describe())
+			} else if fc.QueryDiff != nil {
+				writeQueryDiff(w, fc.QueryDiff)
+			} else if c.Type == "update" {
+				fmt.Fprintln(w, writeDeleted(c.From))
+				fmt.Fprintln(w, writeCreated(c.To), ColorReset)
 			} else if c.Type == "create" {
-				fmt.Println(writeCreated(c.To), ColorReset)
+				fmt.Fprintln(w, writeCreated(c.To), ColorReset)
 			} else if c.Type == "delete" {
-				fmt.Println(writeDeleted(c.From), ColorReset)
+				fmt.Fprintln(w, writeDeleted(c.From), ColorReset)
 			}
-			fmt.Println()
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func writeQueryDiff(w io.Writer, lines []queryLine) {
+	for _, line := range lines {
+		switch line.Op {
+		case "-":
+			fmt.Fprintln(w, ColorRed+"- "+line.Text+ColorReset)
+		case "+":
+			fmt.Fprintln(w, ColorGreen+"+ "+line.Text+ColorReset)
+		default:
+			fmt.Fprintln(w, "  "+line.Text)
 		}
 	}
 }
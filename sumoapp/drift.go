@@ -0,0 +1,111 @@
+package sumoapp
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/r3labs/diff"
+)
+
+const (
+	DriftModified  = "modified"
+	DriftMissing   = "missing"
+	DriftUnmanaged = "unmanaged"
+)
+
+type driftedObject struct {
+	Type    string   `json:"type"`
+	Key     string   `json:"key"`
+	Status  string   `json:"status"`
+	Changes int      `json:"changes"`
+	Paths   []string `json:"paths,omitempty"`
+}
+
+type driftReport struct {
+	InSync  bool            `json:"inSync"`
+	Objects []driftedObject `json:"objects"`
+}
+
+// DetectDrift compares a local build with the content exported from the
+// folder it was deployed to, after normalizing both with rules. Objects
+// that only exist locally are reported as missing, objects that only
+// exist in the deployed folder as unmanaged, and objects that exist in
+// both but differ as modified
+func DetectDrift(localBuild []byte, deployed []byte, rules *normalizationRules) (*driftReport, error) {
+	cs, err := CompareContent(localBuild, deployed, rules)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to compare the build with the deployed content: %w", err)
+	}
+
+	return newDriftReport(&cs), nil
+}
+
+func newDriftReport(cs *changeSet) *driftReport {
+	report := &driftReport{
+		InSync:  true,
+		Objects: make([]driftedObject, 0),
+	}
+
+	byObject := make(map[string]*driftedObject)
+	for _, c := range cs.all() {
+		objectId := c.Path[0] + "/" + c.Path[1]
+		obj, ok := byObject[objectId]
+		if !ok {
+			obj = &driftedObject{
+				Type:   c.Path[0],
+				Key:    c.Path[1],
+				Status: DriftModified,
+			}
+			byObject[objectId] = obj
+		}
+
+		if len(c.Path) == 2 {
+			//The whole object was created or deleted
+			switch c.Type {
+			case diff.CREATE:
+				obj.Status = DriftUnmanaged
+			case diff.DELETE:
+				obj.Status = DriftMissing
+			}
+		} else {
+			obj.Paths = append(obj.Paths, strings.Join(c.Path[2:], "."))
+		}
+
+		obj.Changes++
+	}
+
+	for _, objectId := range sortedKeys(byObject) {
+		report.Objects = append(report.Objects, *byObject[objectId])
+	}
+
+	report.InSync = len(report.Objects) == 0
+
+	return report
+}
+
+func (r *driftReport) ToJSON() ([]byte, error) {
+	return canonicalJSON(r)
+}
+
+// Display writes a human readable summary of the drift
+func (r *driftReport) Display(w io.Writer) {
+	if r.InSync {
+		fmt.Fprintln(w, "Deployed content is in sync with the build")
+		return
+	}
+
+	fmt.Fprintln(w, "Found", len(r.Objects), "drifted object(s)")
+	fmt.Fprintln(w)
+
+	for _, obj := range r.Objects {
+		switch obj.Status {
+		case DriftMissing:
+			fmt.Fprintf(w, "%s%s %s %s: in the build but not deployed%s\n", ColorRed, obj.Status, obj.Type, obj.Key, ColorReset)
+		case DriftUnmanaged:
+			fmt.Fprintf(w, "%s%s %s %s: deployed but not in the build%s\n", ColorGreen, obj.Status, obj.Type, obj.Key, ColorReset)
+		default:
+			fmt.Fprintf(w, "%s %s %s: %d change(s) at %s\n", obj.Status, obj.Type, obj.Key, obj.Changes, strings.Join(obj.Paths, ", "))
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package sumoapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// fieldExtractionRule is a field extraction rule (FER). Rules are matched
+// with the rules in the org by name. Rules are enabled unless they say
+// otherwise
+type fieldExtractionRule struct {
+	Id              string `json:"id,omitempty" yaml:"-" diff:"-"`
+	Name            string `json:"name"`
+	Scope           string `json:"scope"`
+	ParseExpression string `json:"parseExpression"`
+	Enabled         *bool  `json:"enabled"`
+}
+
+type extractionRulePage struct {
+	Data []*fieldExtractionRule `json:"data"`
+	Next string                 `json:"next"`
+}
+
+// extractionRulesPageSize is the largest page the Extraction Rules API returns
+const extractionRulesPageSize = 1000
+
+// ReadExtractionRules reads the rule definitions in a YAML file, or in
+// every YAML file of a directory. Files map a key to each rule, like the
+// component files of an overlay. The rules are returned keyed by name
+func ReadExtractionRules(path string) (map[string]*fieldExtractionRule, error) {
+	files, err := definitionFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string]*fieldExtractionRule)
+	keys := make(map[string]string)
+
+	for _, file := range files {
+		var curList map[string]*fieldExtractionRule
+		if err := readYamlFile(file, &curList); err != nil {
+			return nil, err
+		}
+
+		for _, key := range sortedKeys(curList) {
+			rule := curList[key]
+			if rule == nil {
+				return nil, fmt.Errorf("Rule '%s' in %s is empty", key, file)
+			}
+
+			if other, ok := keys[key]; ok {
+				return nil, fmt.Errorf("Rule '%s' is defined in both %s and %s", key, other, file)
+			}
+			keys[key] = file
+
+			if err := rule.Validate(); err != nil {
+				return nil, fmt.Errorf("Invalid rule '%s' in %s: %w", key, file, err)
+			}
+
+			if _, ok := rules[rule.Name]; ok {
+				return nil, fmt.Errorf("More than one rule is named '%s'. Rules are matched by name, so names must be unique", rule.Name)
+			}
+
+			if rule.Enabled == nil {
+				enabled := true
+				rule.Enabled = &enabled
+			}
+
+			rules[rule.Name] = rule
+		}
+	}
+
+	return rules, nil
+}
+
+// Validate checks the rule has everything the Extraction Rules API needs
+func (r *fieldExtractionRule) Validate() error {
+	switch {
+	case r.Name == "":
+		return fmt.Errorf("The rule has no name")
+	case r.Scope == "":
+		return fmt.Errorf("The rule has no scope")
+	case r.ParseExpression == "":
+		return fmt.Errorf("The rule has no parse expression")
+	}
+
+	return nil
+}
+
+// ListExtractionRules returns every rule in the org, keyed by name
+func ListExtractionRules(ctx context.Context, a *APIClient) (map[string]*fieldExtractionRule, error) {
+	rules := make(map[string]*fieldExtractionRule)
+
+	token := ""
+	for {
+		query := url.Values{}
+		query.Add("limit", strconv.Itoa(extractionRulesPageSize))
+		if token != "" {
+			query.Add("token", token)
+		}
+
+		var page extractionRulePage
+		if err := a.requestJSON(ctx, "GET", "/v1/extractionRules", query, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, rule := range page.Data {
+			rules[rule.Name] = rule
+		}
+
+		if page.Next == "" {
+			return rules, nil
+		}
+		token = page.Next
+	}
+}
+
+// WriteExtractionRules writes each rule to its own file in dir, named
+// after the rule
+func WriteExtractionRules(rules map[string]*fieldExtractionRule, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	names := make(map[string]string)
+	for _, name := range sortedKeys(rules) {
+		key := sanitizeName(name)
+		if other, ok := names[key]; ok {
+			return fmt.Errorf("Rules '%s' and '%s' would be written to the same file %s.yaml", other, name, key)
+		}
+		names[key] = name
+
+		filePath := filepath.Join(dir, key+".yaml")
+		if err := writeYamlFile(filePath, map[string]*fieldExtractionRule{key: rules[name]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractionRulePlan is a plan for the rules of the org along with the
+// rules it was made from
+type extractionRulePlan struct {
+	*resourcePlan
+	current map[string]*fieldExtractionRule
+	desired map[string]*fieldExtractionRule
+}
+
+// PlanExtractionRules compares the rules defined at path with the rules
+// in the org. With prune, rules that aren't defined are deleted
+func PlanExtractionRules(ctx context.Context, a *APIClient, path string, prune bool) (*extractionRulePlan, error) {
+	desired, err := ReadExtractionRules(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := ListExtractionRules(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := newResourcePlan("fer", current, desired, prune)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extractionRulePlan{
+		resourcePlan: p,
+		current:      current,
+		desired:      desired,
+	}, nil
+}
+
+// Apply creates, updates, and deletes the rules the plan changes
+func (p *extractionRulePlan) Apply(ctx context.Context, a *APIClient, w io.Writer) error {
+	return p.apply(w, func(action planAction) error {
+		switch action.Action {
+		case PlanCreate:
+			body := *p.desired[action.Name]
+			body.Id = ""
+			return a.requestJSON(ctx, "POST", "/v1/extractionRules", nil, body, nil)
+		case PlanUpdate:
+			body := *p.desired[action.Name]
+			body.Id = ""
+			return a.requestJSON(ctx, "PUT", "/v1/extractionRules/"+p.current[action.Name].Id, nil, body, nil)
+		case PlanDelete:
+			return a.requestJSON(ctx, "DELETE", "/v1/extractionRules/"+p.current[action.Name].Id, nil, nil, nil)
+		}
+
+		return nil
+	})
+}
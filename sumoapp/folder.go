@@ -1,6 +1,7 @@
 package sumoapp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -25,7 +26,13 @@ func NewFolder() *folder {
 }
 
 func (f *folder) Merge(folderObj *folder) error {
-	if err := mergo.Merge(f, folderObj, mergo.WithOverride); err != nil {
+	newFolder := folderObj.Copy()
+
+	if err := mergo.Merge(newFolder, f, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	if err := mergo.Merge(f, newFolder, mergo.WithOverride); err != nil {
 		return err
 	}
 
@@ -37,11 +44,15 @@ func (f *folder) Copy() *folder {
 
 	newFolder := NewFolder()
 
+	newFolder.Id = f.Id
 	newFolder.Type = f.Type
 	newFolder.Name = f.Name
 	newFolder.Description = f.Description
+	newFolder.Permissions = f.Permissions
 	newFolder.Children = f.Children
-	newFolder.Items = f.Items
+	for itemType, items := range f.Items {
+		newFolder.Items[itemType] = items
+	}
 	newFolder.dashboards = f.dashboards
 	newFolder.savedSearches = f.savedSearches
 
@@ -63,6 +74,24 @@ func (f *folder) UploadWithoutOverwrite(a *APIClient, folderId string) error {
 }
 
 func (f *folder) Upload(a *APIClient, folderId string, overwrite bool) error {
+	_, err := f.startImport(a, folderId, overwrite)
+	return err
+}
+
+// UploadAndWait imports the folder into the folder with folderId like
+// Upload, but only returns once the import has finished
+func (f *folder) UploadAndWait(ctx context.Context, a *APIClient, folderId string, overwrite bool) error {
+	jobId, err := f.startImport(a, folderId, overwrite)
+	if err != nil {
+		return err
+	}
+
+	return waitForContentImport(ctx, a, folderId, jobId, "the application")
+}
+
+// startImport begins importing the folder into the folder with folderId
+// and returns the ID of the import job
+func (f *folder) startImport(a *APIClient, folderId string, overwrite bool) (string, error) {
 	var (
 		localVarHttpMethod  = strings.ToUpper("Post")
 		localVarPostBody    interface{}
@@ -104,25 +133,25 @@ func (f *folder) Upload(a *APIClient, folderId string, overwrite bool) error {
 	localVarPostBody = f
 	r, err := a.prepareRequest(localVarPath, localVarHttpMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFileName, localVarFileBytes)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	localVarHttpResponse, err := a.callAPI(r)
 	if err != nil || localVarHttpResponse == nil {
-		return err
+		return "", err
 	}
 
 	localVarBody, err := ioutil.ReadAll(localVarHttpResponse.Body)
 	localVarHttpResponse.Body.Close()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if localVarHttpResponse.StatusCode < 300 {
 		// If we succeed, return the data, otherwise pass on to decode error.
 		err = a.decode(&localVarReturnValue, localVarBody, localVarHttpResponse.Header.Get("Content-Type"))
 		if err == nil {
-			return err
+			return localVarReturnValue.Id, err
 		}
 	} else if localVarHttpResponse.StatusCode >= 300 {
 		newErr := GenericSwaggerError{
@@ -134,28 +163,28 @@ func (f *folder) Upload(a *APIClient, folderId string, overwrite bool) error {
 			err = a.decode(&v, localVarBody, localVarHttpResponse.Header.Get("Content-Type"))
 			if err != nil {
 				newErr.error = err.Error()
-				return newErr
+				return "", newErr
 			}
 			newErr.model = v
-			return newErr
+			return "", newErr
 		} else if localVarHttpResponse.StatusCode >= 400 {
 			var v types.ErrorResponse
 			err = a.decode(&v, localVarBody, localVarHttpResponse.Header.Get("Content-Type"))
 			if err != nil {
 				newErr.error = err.Error()
-				return newErr
+				return "", newErr
 			}
 			if v.Errors[0].Meta.Reason != "" {
 				newErr.error = v.Errors[0].Message + ": " + v.Errors[0].Meta.Reason
 			} else {
 				newErr.error = v.Errors[0].Message
 			}
-			return newErr
+			return "", newErr
 		}
-		return newErr
+		return "", newErr
 	}
 
-	return nil
+	return "", nil
 }
 
 func (f *folder) Download(a *APIClient) ([]byte, error) {
@@ -0,0 +1,180 @@
+package sumoapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// indexReferencePattern matches the _index=name and _view=name terms of a
+// query. Names can be quoted and can contain * wildcards
+var indexReferencePattern = regexp.MustCompile(`(?i)\b_(index|view)\s*=\s*"?([A-Za-z0-9_*{}.-]+)"?`)
+
+// systemIndexPrefix starts the names of the indexes Sumo Logic provides,
+// such as sumologic_audit_events, which aren't defined as code
+const systemIndexPrefix = "sumologic_"
+
+// indexReference is an _index or _view term of a query in the application
+type indexReference struct {
+	ObjectType string `json:"objectType"`
+	Key        string `json:"key"`
+	Term       string `json:"term"`
+	Name       string `json:"name"`
+}
+
+// indexReferenceReport lists the _index and _view references in the
+// application's queries that don't point to a defined partition or
+// scheduled view
+type indexReferenceReport struct {
+	Checked    int               `json:"checked"`
+	Undefined  []*indexReference `json:"undefined"`
+	Unresolved []*indexReference `json:"unresolved"`
+}
+
+// indexReferences returns the _index and _view references in a query
+func indexReferences(objectType string, key string, query string) []*indexReference {
+	refs := make([]*indexReference, 0)
+
+	for _, m := range indexReferencePattern.FindAllStringSubmatch(query, -1) {
+		refs = append(refs, &indexReference{
+			ObjectType: objectType,
+			Key:        key,
+			Term:       "_" + strings.ToLower(m[1]),
+			Name:       m[2],
+		})
+	}
+
+	return refs
+}
+
+// IndexReferences returns the _index and _view references in the queries
+// of the overlay's saved searches, panels, variables, and monitors. The
+// view a saved search runs against counts as a _view reference
+func (s *appOverlay) IndexReferences() []*indexReference {
+	refs := make([]*indexReference, 0)
+
+	for _, key := range sortedKeys(s.SavedSearches) {
+		search := s.SavedSearches[key].Search
+		refs = append(refs, indexReferences("saved-search", key, search.QueryText)...)
+
+		if search.ViewName != "" {
+			refs = append(refs, &indexReference{ObjectType: "saved-search", Key: key, Term: "_view", Name: search.ViewName})
+		}
+	}
+
+	for _, key := range sortedKeys(s.Panels) {
+		for _, q := range s.Panels[key].Queries {
+			refs = append(refs, indexReferences("panel", key, q.QueryString)...)
+		}
+	}
+
+	for _, key := range sortedKeys(s.Variables) {
+		refs = append(refs, indexReferences("variable", key, s.Variables[key].SourceDefinition.Query)...)
+	}
+
+	for _, key := range sortedKeys(s.Monitors) {
+		for _, q := range s.Monitors[key].Queries {
+			refs = append(refs, indexReferences("monitor", key, q.Query)...)
+		}
+	}
+
+	return refs
+}
+
+// CheckIndexReferences checks every _index and _view reference in the
+// overlay's queries points to one of the partitions or scheduled views,
+// or to an index Sumo Logic provides. Partitions and views are searched
+// the same way, so either term can name either. Names are compared
+// without case, and a name with * wildcards must match at least one
+// partition or view. References built from {{variables}} can't be
+// checked and are listed as unresolved
+func (s *appOverlay) CheckIndexReferences(partitions map[string]*partition, views map[string]*scheduledView) *indexReferenceReport {
+	names := make([]string, 0, len(partitions)+len(views))
+	for name := range partitions {
+		names = append(names, strings.ToLower(name))
+	}
+	for name := range views {
+		names = append(names, strings.ToLower(name))
+	}
+
+	report := &indexReferenceReport{
+		Undefined:  make([]*indexReference, 0),
+		Unresolved: make([]*indexReference, 0),
+	}
+
+	for _, ref := range s.IndexReferences() {
+		report.Checked++
+
+		if strings.Contains(ref.Name, "{{") {
+			report.Unresolved = append(report.Unresolved, ref)
+			continue
+		}
+
+		name := strings.ToLower(ref.Name)
+		if strings.HasPrefix(name, systemIndexPrefix) {
+			continue
+		}
+
+		pattern := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(name), `\*`, ".*") + "$")
+
+		found := false
+		for _, defined := range names {
+			if pattern.MatchString(defined) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			report.Undefined = append(report.Undefined, ref)
+		}
+	}
+
+	return report
+}
+
+// CheckIndexDefinitions checks the overlay's _index and _view references
+// against the partitions defined at partitionsPath and the scheduled views
+// defined at viewsPath. A path that doesn't exist defines none
+func (s *appOverlay) CheckIndexDefinitions(partitionsPath string, viewsPath string) (*indexReferenceReport, error) {
+	partitions := make(map[string]*partition)
+	if _, err := os.Stat(partitionsPath); !os.IsNotExist(err) {
+		if partitions, err = ReadPartitions(partitionsPath); err != nil {
+			return nil, err
+		}
+	}
+
+	views := make(map[string]*scheduledView)
+	if _, err := os.Stat(viewsPath); !os.IsNotExist(err) {
+		if views, err = ReadScheduledViews(viewsPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.CheckIndexReferences(partitions, views), nil
+}
+
+// Failed reports whether a reference points to an undefined index
+func (r *indexReferenceReport) Failed() bool {
+	return len(r.Undefined) > 0
+}
+
+// Display writes the references that point to undefined indexes and the
+// ones that couldn't be checked, followed by a summary
+func (r *indexReferenceReport) Display(w io.Writer) {
+	for _, ref := range r.Undefined {
+		fmt.Fprintf(w, "%s%s %s: %s=%s is not a defined partition or scheduled view%s\n", ColorRed, ref.ObjectType, ref.Key, ref.Term, ref.Name, ColorReset)
+	}
+
+	for _, ref := range r.Unresolved {
+		fmt.Fprintf(w, "%s %s: %s=%s uses a variable and wasn't checked\n", ref.ObjectType, ref.Key, ref.Term, ref.Name)
+	}
+
+	fmt.Fprintf(w, "Checked %d reference(s): %d undefined, %d unresolved\n", r.Checked, len(r.Undefined), len(r.Unresolved))
+}
+
+func (r *indexReferenceReport) ToJSON() ([]byte, error) {
+	return canonicalJSON(r)
+}
@@ -0,0 +1,142 @@
+package sumoapp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndexReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"no references", "_sourceCategory=prod error", []string{}},
+		{"index", "_index=prod_logs error", []string{"_index=prod_logs"}},
+		{"quoted view", `_view = "daily-summary" | count`, []string{"_view=daily-summary"}},
+		{"case", "_INDEX=Prod_Logs or _View=x", []string{"_index=Prod_Logs", "_view=x"}},
+		{"wildcard", "_index=prod_* error", []string{"_index=prod_*"}},
+		{"variable", "_index={{env}}_logs", []string{"_index={{env}}_logs"}},
+		{"not a term", "my_index=prod", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, ref := range indexReferences("panel", "p", tt.query) {
+				got = append(got, ref.Term+"="+ref.Name)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("indexReferences(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckIndexReferences(t *testing.T) {
+	partitions := map[string]*partition{"Prod_Logs": {Name: "Prod_Logs"}}
+	views := map[string]*scheduledView{"daily_summary": {IndexName: "daily_summary"}}
+
+	tests := []struct {
+		name           string
+		query          string
+		wantUndefined  []string
+		wantUnresolved []string
+	}{
+		{
+			name:  "partition without case",
+			query: "_index=prod_logs",
+		},
+		{
+			name:  "view named by _index",
+			query: "_index=daily_summary",
+		},
+		{
+			name:  "partition named by _view",
+			query: "_view=prod_logs",
+		},
+		{
+			name:  "system index",
+			query: "_index=sumologic_audit_events",
+		},
+		{
+			name:  "matching wildcard",
+			query: "_index=prod_*",
+		},
+		{
+			name:          "wildcard without a match",
+			query:         "_index=dev_*",
+			wantUndefined: []string{"dev_*"},
+		},
+		{
+			name:          "undefined",
+			query:         "_index=prod_logs or _index=prod_metrics",
+			wantUndefined: []string{"prod_metrics"},
+		},
+		{
+			name:           "variable",
+			query:          "_index={{env}}_logs",
+			wantUnresolved: []string{"{{env}}_logs"},
+		},
+		{
+			name:          "dot is not a wildcard",
+			query:         "_index=prod.logs",
+			wantUndefined: []string{"prod.logs"},
+		},
+	}
+
+	names := func(refs []*indexReference) []string {
+		var n []string
+		for _, ref := range refs {
+			n = append(n, ref.Name)
+		}
+		return n
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAppOverlay("base", NewApplication())
+			s.Panels["p"] = &panel{Key: "p", Queries: []query{{QueryString: tt.query}}}
+
+			report := s.CheckIndexReferences(partitions, views)
+
+			if got := names(report.Undefined); !reflect.DeepEqual(got, tt.wantUndefined) {
+				t.Errorf("Undefined = %v, want %v", got, tt.wantUndefined)
+			}
+
+			if got := names(report.Unresolved); !reflect.DeepEqual(got, tt.wantUnresolved) {
+				t.Errorf("Unresolved = %v, want %v", got, tt.wantUnresolved)
+			}
+
+			if report.Failed() != (len(tt.wantUndefined) > 0) {
+				t.Errorf("Failed() = %v, want %v", report.Failed(), len(tt.wantUndefined) > 0)
+			}
+		})
+	}
+}
+
+func TestOverlayIndexReferences(t *testing.T) {
+	s := NewAppOverlay("base", NewApplication())
+	s.SavedSearches["errors"] = &savedSearch{Search: search{QueryText: "_index=a", ViewName: "b"}}
+	s.Panels["p"] = &panel{Queries: []query{{QueryString: "_index=c"}, {QueryString: "_view=d"}}}
+	s.Variables["v"] = &variable{SourceDefinition: sourceDefinition{Query: "_index=e | count by host"}}
+	s.Monitors["m"] = &monitor{Queries: []monitorQuery{{RowId: "A", Query: "_index=f"}}}
+
+	got := make([]string, 0)
+	for _, ref := range s.IndexReferences() {
+		got = append(got, ref.ObjectType+" "+ref.Key+" "+ref.Term+"="+ref.Name)
+	}
+
+	want := []string{
+		"saved-search errors _index=a",
+		"saved-search errors _view=b",
+		"panel p _index=c",
+		"panel p _view=d",
+		"variable v _index=e",
+		"monitor m _index=f",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IndexReferences() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,472 @@
+package sumoapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Field types of a lookup table
+const (
+	LookupFieldString  = "string"
+	LookupFieldBoolean = "boolean"
+	LookupFieldInt     = "int"
+	LookupFieldLong    = "long"
+	LookupFieldDouble  = "double"
+)
+
+// What a lookup table does once it's full
+const (
+	LookupStopIncomingMessages = "StopIncomingMessages"
+	LookupDeleteOldData        = "DeleteOldData"
+)
+
+// lookupJobPollInterval is how long to wait between checks of a lookup
+// table upload job
+var lookupJobPollInterval = time.Second
+
+// lookupTable is a lookup table's schema along with the CSV file holding
+// its rows. Tables are matched with the tables in the org by name within
+// their parent folder. The fields and primary keys can't be changed once
+// the table is created. Data is the path of the CSV file, relative to the
+// definition, and defaults to the table's key with a .csv extension
+type lookupTable struct {
+	Id              string        `json:"id,omitempty" yaml:"-"`
+	Name            string        `json:"name"`
+	Description     string        `json:"description"`
+	ParentFolderId  string        `json:"parentFolderId"`
+	Fields          []lookupField `json:"fields"`
+	PrimaryKeys     []string      `json:"primaryKeys"`
+	Ttl             int           `json:"ttl" yaml:",omitempty"`
+	SizeLimitAction string        `json:"sizeLimitAction,omitempty" yaml:",omitempty"`
+	Data            string        `json:"-" yaml:",omitempty"`
+	dataPath        string
+}
+
+type lookupField struct {
+	FieldName string `json:"fieldName"`
+	FieldType string `json:"fieldType"`
+}
+
+type lookupJob struct {
+	Id string `json:"id"`
+}
+
+type lookupJobStatus struct {
+	Status         string   `json:"status"`
+	StatusMessages []string `json:"statusMessages"`
+	Error          *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// contentItems are the children of a folder of the content library
+type contentItems []struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	ItemType string `json:"itemType"`
+}
+
+func listContentFolder(ctx context.Context, a *APIClient, id string) (contentItems, error) {
+	var folder struct {
+		Children contentItems `json:"children"`
+	}
+	if err := a.requestJSON(ctx, "GET", "/v2/content/folders/"+id, nil, nil, &folder); err != nil {
+		return nil, err
+	}
+
+	return folder.Children, nil
+}
+
+// ReadLookupTables reads the lookup table definitions in a YAML file, or in
+// every YAML file of a directory. Files map a key to each table. The tables
+// are returned keyed by key
+func ReadLookupTables(path string) (map[string]*lookupTable, error) {
+	files, err := definitionFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]*lookupTable)
+	keys := make(map[string]string)
+
+	for _, file := range files {
+		var curList map[string]*lookupTable
+		if err := readYamlFile(file, &curList); err != nil {
+			return nil, err
+		}
+
+		for _, key := range sortedKeys(curList) {
+			t := curList[key]
+			if t == nil {
+				return nil, fmt.Errorf("Lookup table '%s' in %s is empty", key, file)
+			}
+
+			if other, ok := keys[key]; ok {
+				return nil, fmt.Errorf("Lookup table '%s' is defined in both %s and %s", key, other, file)
+			}
+			keys[key] = file
+
+			if t.SizeLimitAction == "" {
+				t.SizeLimitAction = LookupStopIncomingMessages
+			}
+
+			data := t.Data
+			if data == "" {
+				data = key + ".csv"
+			}
+			t.dataPath = filepath.Join(filepath.Dir(file), data)
+
+			if err := t.Validate(); err != nil {
+				return nil, fmt.Errorf("Invalid lookup table '%s' in %s: %w", key, file, err)
+			}
+
+			tables[key] = t
+		}
+	}
+
+	return tables, nil
+}
+
+// Validate checks the table has everything the Lookup Tables API needs and
+// that its primary keys are among its fields
+func (t *lookupTable) Validate() error {
+	types := []string{LookupFieldString, LookupFieldBoolean, LookupFieldInt, LookupFieldLong, LookupFieldDouble}
+	actions := []string{LookupStopIncomingMessages, LookupDeleteOldData}
+
+	switch {
+	case t.Name == "":
+		return fmt.Errorf("The table has no name")
+	case t.ParentFolderId == "":
+		return fmt.Errorf("The table has no parent folder ID")
+	case len(t.Fields) == 0:
+		return fmt.Errorf("The table has no fields")
+	case len(t.PrimaryKeys) == 0:
+		return fmt.Errorf("The table has no primary keys")
+	case t.Ttl < 0:
+		return fmt.Errorf("The table's TTL can't be negative")
+	case !containsString(actions, t.SizeLimitAction):
+		return fmt.Errorf("Unknown size limit action '%s'. Expected one of: %s", t.SizeLimitAction, strings.Join(actions, ", "))
+	}
+
+	for _, f := range t.Fields {
+		if f.FieldName == "" {
+			return fmt.Errorf("A field has no name")
+		}
+
+		if !containsString(types, f.FieldType) {
+			return fmt.Errorf("Unknown type '%s' of field '%s'. Expected one of: %s", f.FieldType, f.FieldName, strings.Join(types, ", "))
+		}
+	}
+
+	for _, key := range t.PrimaryKeys {
+		if !containsString(t.fieldNames(), key) {
+			return fmt.Errorf("The primary key '%s' is not a field of the table", key)
+		}
+	}
+
+	return nil
+}
+
+func (t *lookupTable) fieldNames() []string {
+	names := make([]string, len(t.Fields))
+	for i, f := range t.Fields {
+		names[i] = f.FieldName
+	}
+
+	return names
+}
+
+// readData reads the table's CSV file and checks its header names every
+// field of the table and nothing else. It returns the file and the number
+// of rows it has
+func (t *lookupTable) readData() ([]byte, int, error) {
+	data, err := ioutil.ReadFile(t.dataPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, 0, fmt.Errorf("Could not read %s: %w", t.dataPath, err)
+	}
+
+	if len(records) == 0 {
+		return nil, 0, fmt.Errorf("%s has no header", t.dataPath)
+	}
+
+	for _, column := range records[0] {
+		if !containsString(t.fieldNames(), column) {
+			return nil, 0, fmt.Errorf("Column '%s' of %s is not a field of lookup table '%s'", column, t.dataPath, t.Name)
+		}
+	}
+
+	for _, name := range t.fieldNames() {
+		if !containsString(records[0], name) {
+			return nil, 0, fmt.Errorf("%s has no column for field '%s' of lookup table '%s'", t.dataPath, name, t.Name)
+		}
+	}
+
+	return data, len(records) - 1, nil
+}
+
+// findLookupTable returns the table in the org with the same name and
+// parent folder as t, or nil if there isn't one
+func findLookupTable(ctx context.Context, a *APIClient, t *lookupTable) (*lookupTable, error) {
+	items, err := listContentFolder(ctx, a, t.ParentFolderId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range items {
+		if child.Name != t.Name || !strings.HasPrefix(child.ItemType, "Lookup") {
+			continue
+		}
+
+		current := &lookupTable{}
+		if err := a.requestJSON(ctx, "GET", "/v1/lookupTables/"+child.Id, nil, nil, current); err != nil {
+			return nil, err
+		}
+
+		return current, nil
+	}
+
+	return nil, nil
+}
+
+// selectLookupTables returns the keys of the tables to work on: every table
+// when key is empty, or else only the table with key
+func selectLookupTables(tables map[string]*lookupTable, key string) ([]string, error) {
+	if key == "" {
+		return sortedKeys(tables), nil
+	}
+
+	if _, ok := tables[key]; !ok {
+		return nil, fmt.Errorf("Could not find lookup table '%s'", key)
+	}
+
+	return []string{key}, nil
+}
+
+// PushLookupTables creates or updates the lookup tables defined at path,
+// or only the table with key when key isn't empty, and uploads their CSV
+// files. The rows of a table are replaced by the file's rows, or with merge,
+// the file's rows are added to them, replacing the rows with the same
+// primary key. Every CSV file is checked before anything is changed
+func PushLookupTables(ctx context.Context, a *APIClient, path string, key string, merge bool, w io.Writer) error {
+	tables, err := ReadLookupTables(path)
+	if err != nil {
+		return err
+	}
+
+	keys, err := selectLookupTables(tables, key)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string][]byte)
+	rows := make(map[string]int)
+	for _, k := range keys {
+		if data[k], rows[k], err = tables[k].readData(); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range keys {
+		t := tables[k]
+
+		id, err := t.push(ctx, a, w)
+		if err != nil {
+			return err
+		}
+
+		query := url.Values{}
+		query.Add("merge", fmt.Sprint(merge))
+		query.Add("fileEncoding", "UTF-8")
+
+		var job lookupJob
+		if err := a.requestFile(ctx, "POST", "/v1/lookupTables/"+id+"/upload", query, filepath.Base(t.dataPath), data[k], &job); err != nil {
+			return err
+		}
+
+		if err := waitForLookupJob(ctx, a, job.Id); err != nil {
+			return fmt.Errorf("Uploading %s to lookup table '%s' failed: %w", t.dataPath, t.Name, err)
+		}
+
+		fmt.Fprintf(w, "Uploaded %d row(s) to lookup table %s\n", rows[k], t.Name)
+	}
+
+	return nil
+}
+
+// push creates the table, or updates the settings of the existing table,
+// and returns its ID
+func (t *lookupTable) push(ctx context.Context, a *APIClient, w io.Writer) (string, error) {
+	current, err := findLookupTable(ctx, a, t)
+	if err != nil {
+		return "", err
+	}
+
+	if current == nil {
+		created := &lookupTable{}
+		if err := a.requestJSON(ctx, "POST", "/v1/lookupTables", nil, t, created); err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(w, "Created lookup table %s\n", t.Name)
+		return created.Id, nil
+	}
+
+	if !reflect.DeepEqual(current.Fields, t.Fields) || !reflect.DeepEqual(current.PrimaryKeys, t.PrimaryKeys) {
+		return "", fmt.Errorf("The fields and primary keys of lookup table '%s' can't be changed once it's created. Define a table with a new name instead", t.Name)
+	}
+
+	if current.Description != t.Description || current.Ttl != t.Ttl || current.SizeLimitAction != t.SizeLimitAction {
+		body := map[string]interface{}{
+			"description":     t.Description,
+			"ttl":             t.Ttl,
+			"sizeLimitAction": t.SizeLimitAction,
+		}
+		if err := a.requestJSON(ctx, "PUT", "/v1/lookupTables/"+current.Id, nil, body, nil); err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(w, "Updated lookup table %s\n", t.Name)
+	}
+
+	return current.Id, nil
+}
+
+// waitForLookupJob polls a lookup table job until it's done
+func waitForLookupJob(ctx context.Context, a *APIClient, jobId string) error {
+	for {
+		var status lookupJobStatus
+		if err := a.requestJSON(ctx, "GET", "/v1/lookupTables/jobs/"+jobId+"/status", nil, nil, &status); err != nil {
+			return err
+		}
+
+		switch status.Status {
+		case "Success":
+			return nil
+		case "Failed":
+			if status.Error != nil {
+				return fmt.Errorf("%s", status.Error.Message)
+			}
+			return fmt.Errorf("%s", strings.Join(status.StatusMessages, "; "))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lookupJobPollInterval):
+		}
+	}
+}
+
+// PullLookupTables writes the current rows of the lookup tables defined at
+// path, or only the table with key when key isn't empty, to their CSV
+// files. Rows are read with a cat search of the table, and written with a
+// column for each field in the order the fields are defined
+func PullLookupTables(ctx context.Context, a *APIClient, path string, key string, w io.Writer) error {
+	tables, err := ReadLookupTables(path)
+	if err != nil {
+		return err
+	}
+
+	keys, err := selectLookupTables(tables, key)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		t := tables[k]
+
+		current, err := findLookupTable(ctx, a, t)
+		if err != nil {
+			return err
+		}
+
+		if current == nil {
+			return fmt.Errorf("Lookup table '%s' doesn't exist in folder %s. Use 'sumo lookup push' to create it", t.Name, t.ParentFolderId)
+		}
+
+		count, err := t.pull(ctx, a, current.Id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "Pulled %d row(s) of lookup table %s to %s\n", count, t.Name, t.dataPath)
+	}
+
+	return nil
+}
+
+// pull writes the rows of the table with id to the table's CSV file and
+// returns how many there were
+func (t *lookupTable) pull(ctx context.Context, a *APIClient, id string) (int, error) {
+	var contentPath struct {
+		Path string `json:"path"`
+	}
+	if err := a.requestJSON(ctx, "GET", "/v2/content/"+id+"/path", nil, nil, &contentPath); err != nil {
+		return 0, err
+	}
+
+	to := time.Now()
+	job, err := NewSearchJob(ctx, a, fmt.Sprintf(`cat path://"%s"`, contentPath.Path), to.Add(-15*time.Minute), to, false)
+	if err != nil {
+		return 0, err
+	}
+	defer job.Delete()
+
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	if err := out.Write(t.fieldNames()); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = job.Stream(ctx, SearchModeRecords, 0, func(fields []searchJobField, rows []map[string]string) error {
+		for _, row := range rows {
+			//Search results name fields in lowercase
+			values := make([]string, len(t.Fields))
+			for i, f := range t.Fields {
+				value, ok := row[f.FieldName]
+				if !ok {
+					value = row[strings.ToLower(f.FieldName)]
+				}
+				values[i] = value
+			}
+
+			if err := out.Write(values); err != nil {
+				return err
+			}
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.dataPath), 0755); err != nil {
+		return 0, err
+	}
+
+	return count, ioutil.WriteFile(t.dataPath, buf.Bytes(), 0644)
+}
@@ -0,0 +1,183 @@
+package sumoapp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testLookupTable = `hosts:
+  name: Hosts
+  parentfolderid: "0000000000000001"
+  fields:
+  - fieldname: host
+    fieldtype: string
+  - fieldname: owner
+    fieldtype: string
+  primarykeys: [host]
+`
+
+func TestReadLookupTables(t *testing.T) {
+	dir := writeAppFiles(t, t.TempDir(), map[string]string{
+		"lookups/hosts.yaml":  testLookupTable,
+		"lookups/teams.yaml":  "teams:\n  name: Teams\n  parentfolderid: \"0000000000000001\"\n  fields:\n  - fieldname: team\n    fieldtype: string\n  primarykeys: [team]\n  sizelimitaction: DeleteOldData\n  data: data/teams.csv\n",
+		"lookups/README.txt":  "not a definition",
+		"lookups/data/x.yaml": "not read, it's in a subdirectory",
+	})
+
+	tables, err := ReadLookupTables(filepath.Join(dir, "lookups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tables) != 2 {
+		t.Fatalf("ReadLookupTables() = %v, want hosts and teams", tables)
+	}
+
+	hosts := tables["hosts"]
+	if hosts.SizeLimitAction != LookupStopIncomingMessages || hosts.dataPath != filepath.Join(dir, "lookups", "hosts.csv") {
+		t.Errorf("hosts = %+v, want the default size limit action and CSV file", hosts)
+	}
+
+	teams := tables["teams"]
+	if teams.SizeLimitAction != LookupDeleteOldData || teams.dataPath != filepath.Join(dir, "lookups", "data", "teams.csv") {
+		t.Errorf("teams = %+v, want its own size limit action and CSV file", teams)
+	}
+}
+
+func TestReadLookupTablesInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "empty table",
+			files:   map[string]string{"hosts.yaml": "hosts:\n"},
+			wantErr: "'hosts' in",
+		},
+		{
+			name:    "defined twice",
+			files:   map[string]string{"hosts.yaml": testLookupTable, "more.yaml": testLookupTable},
+			wantErr: "defined in both",
+		},
+		{
+			name:    "no parent folder",
+			files:   map[string]string{"hosts.yaml": strings.Replace(testLookupTable, "  parentfolderid: \"0000000000000001\"\n", "", 1)},
+			wantErr: "no parent folder ID",
+		},
+		{
+			name:    "unknown field type",
+			files:   map[string]string{"hosts.yaml": strings.Replace(testLookupTable, "fieldtype: string", "fieldtype: date", 1)},
+			wantErr: "Unknown type 'date' of field 'host'",
+		},
+		{
+			name:    "primary key not a field",
+			files:   map[string]string{"hosts.yaml": strings.Replace(testLookupTable, "[host]", "[hostname]", 1)},
+			wantErr: "primary key 'hostname'",
+		},
+		{
+			name:    "negative TTL",
+			files:   map[string]string{"hosts.yaml": testLookupTable + "  ttl: -1\n"},
+			wantErr: "TTL can't be negative",
+		},
+		{
+			name:    "unknown size limit action",
+			files:   map[string]string{"hosts.yaml": testLookupTable + "  sizelimitaction: Grow\n"},
+			wantErr: "Unknown size limit action 'Grow'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadLookupTables(writeAppFiles(t, t.TempDir(), tt.files))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadLookupTables() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLookupTableReadData(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		wantRows int
+		wantErr  string
+	}{
+		{
+			name:     "rows",
+			csv:      "host,owner\nweb-1,web\nweb-2,web\n",
+			wantRows: 2,
+		},
+		{
+			name:     "columns in another order",
+			csv:      "owner,host\nweb,web-1\n",
+			wantRows: 1,
+		},
+		{
+			name:     "quoted values",
+			csv:      "host,owner\n\"web-1,eu\",\"the \"\"web\"\" team\"\n",
+			wantRows: 1,
+		},
+		{
+			name: "header only",
+			csv:  "host,owner\n",
+		},
+		{
+			name:    "empty file",
+			csv:     "",
+			wantErr: "has no header",
+		},
+		{
+			name:    "unknown column",
+			csv:     "host,owner,region\nweb-1,web,eu\n",
+			wantErr: "Column 'region'",
+		},
+		{
+			name:    "missing column",
+			csv:     "host\nweb-1\n",
+			wantErr: "no column for field 'owner'",
+		},
+		{
+			name:    "uneven rows",
+			csv:     "host,owner\nweb-1\n",
+			wantErr: "Could not read",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeAppFiles(t, t.TempDir(), map[string]string{
+				"hosts.yaml": testLookupTable,
+				"hosts.csv":  tt.csv,
+			})
+
+			tables, err := ReadLookupTables(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, rows, err := tables["hosts"].readData()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("readData() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rows != tt.wantRows || string(data) != tt.csv {
+				t.Errorf("readData() = %d rows of %q, want %d rows of the file", rows, data, tt.wantRows)
+			}
+		})
+	}
+
+	table := &lookupTable{Name: "Hosts", dataPath: filepath.Join(t.TempDir(), "missing.csv")}
+	if _, _, err := table.readData(); err == nil {
+		t.Errorf("readData() of a missing file should fail")
+	}
+}
@@ -0,0 +1,282 @@
+package sumoapp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type manifestOverlay struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type manifestObject struct {
+	Type string `json:"type"`
+	Key  string `json:"key"`
+	Name string `json:"name,omitempty"`
+	Hash string `json:"hash"`
+}
+
+type buildManifest struct {
+	Application string            `json:"application"`
+	Version     string            `json:"version"`
+	CLIVersion  string            `json:"cliVersion"`
+	GitCommit   string            `json:"gitCommit,omitempty"`
+	GitDirty    bool              `json:"gitDirty,omitempty"`
+	BuildHash   string            `json:"buildHash"`
+	Overlays    []manifestOverlay `json:"overlays"`
+	SourceFiles map[string]string `json:"sourceFiles"`
+	Objects     []manifestObject  `json:"objects"`
+}
+
+// NewBuildManifest describes the build produced from the application's
+// loaded overlays: which sources went in, the content hash of every
+// object that came out, and which CLI and git commit produced it.
+// It must be called after LoadAppOverlays
+func (a *application) NewBuildManifest(build []byte, cliVersion string) (*buildManifest, error) {
+	if len(a.appOverlays) == 0 {
+		return nil, fmt.Errorf("The application's app overlays have not been loaded")
+	}
+
+	m := &buildManifest{
+		Application: a.Name,
+		Version:     a.Version,
+		CLIVersion:  cliVersion,
+		BuildHash:   hashBytes(build),
+		Overlays:    make([]manifestOverlay, 0),
+		SourceFiles: make(map[string]string),
+		Objects:     make([]manifestObject, 0),
+	}
+
+	m.GitCommit, m.GitDirty = gitState(a.path)
+
+	for _, overlay := range a.appOverlays {
+		overlayPath, err := filepath.Rel(a.path, overlay.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Overlays = append(m.Overlays, manifestOverlay{Name: overlay.Name, Path: filepath.ToSlash(overlayPath)})
+
+		err = filepath.Walk(overlay.Path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+
+			if info.IsDir() || filepath.Ext(path) != ".yaml" {
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(a.path, path)
+			if err != nil {
+				return err
+			}
+
+			m.SourceFiles[filepath.ToSlash(relPath)] = hashBytes(data)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//The top overlay holds the fully merged objects that went into the build
+	top := a.appOverlays[len(a.appOverlays)-1]
+
+	objectLists := []struct {
+		objectType string
+		objects    interface{}
+	}{
+		{"folder", top.Folders},
+		{"dashboard", top.Dashboards},
+		{"saved-search", top.SavedSearches},
+		{"panel", top.Panels},
+		{"variable", top.Variables},
+		{"monitor", top.Monitors},
+		{"parser", top.Parsers},
+	}
+
+	for _, list := range objectLists {
+		objects, err := manifestObjects(list.objectType, list.objects)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Objects = append(m.Objects, objects...)
+	}
+
+	return m, nil
+}
+
+func manifestObjects(objectType string, objects interface{}) ([]manifestObject, error) {
+	result := make([]manifestObject, 0)
+
+	//Round trip through JSON to get each object's name without a type switch
+	var byKey map[string]json.RawMessage
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &byKey); err != nil {
+		return nil, err
+	}
+
+	for _, key := range sortedKeys(byKey) {
+		var named struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(byKey[key], &named); err != nil {
+			return nil, fmt.Errorf("Unable to read the name of %s '%s': %w", objectType, key, err)
+		}
+
+		canonical, err := canonicalJSON(byKey[key])
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, manifestObject{
+			Type: objectType,
+			Key:  key,
+			Name: named.Name,
+			Hash: hashBytes(canonical),
+		})
+	}
+
+	return result, nil
+}
+
+// ReadBuildManifest loads a manifest written by the build command
+func ReadBuildManifest(path string) (*buildManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m buildManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("Unable to decode manifest %s: %w", path, err)
+	}
+
+	return &m, nil
+}
+
+// FindBuildManifest reads the manifest at manifestPath, or the one next to
+// the build file if manifestPath is empty. It returns nil if there's no
+// manifest next to the build
+func FindBuildManifest(buildPath string, manifestPath string) (*buildManifest, error) {
+	if manifestPath == "" {
+		manifestPath = ManifestPath(buildPath)
+		if _, err := os.Stat(manifestPath); err != nil {
+			return nil, nil
+		}
+	}
+
+	return ReadBuildManifest(manifestPath)
+}
+
+// ManifestPath returns the path of the manifest that sits next to a build file
+func ManifestPath(buildPath string) string {
+	return strings.TrimSuffix(buildPath, filepath.Ext(buildPath)) + ".manifest.json"
+}
+
+func (m *buildManifest) ToJSON() ([]byte, error) {
+	return canonicalJSON(m)
+}
+
+// Verify checks the build file contents are the build the manifest describes
+func (m *buildManifest) Verify(build []byte) error {
+	if hash := hashBytes(build); hash != m.BuildHash {
+		return fmt.Errorf("The build does not match its manifest (build hash %s, manifest expects %s). Rebuild the application", hash, m.BuildHash)
+	}
+
+	return nil
+}
+
+// Summary returns a single line summary of the manifest
+func (m *buildManifest) Summary() string {
+	fields := []string{
+		fmt.Sprintf("app=%q", m.Application),
+		fmt.Sprintf("version=%q", m.Version),
+		fmt.Sprintf("build=%s", m.BuildHash),
+		fmt.Sprintf("cli=%s", m.CLIVersion),
+	}
+
+	if m.GitCommit != "" {
+		commit := m.GitCommit
+		if m.GitDirty {
+			commit += "-dirty"
+		}
+		fields = append(fields, fmt.Sprintf("commit=%s", commit))
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// pushRecord is written next to a build when it's pushed. It holds the
+// build's whole manifest along with where and when it was pushed, so what
+// is deployed can be told without changing the deployed content
+type pushRecord struct {
+	PushedAt     string         `json:"pushedAt"`
+	Deployment   string         `json:"deployment"`
+	ParentFolder string         `json:"parentFolder"`
+	Manifest     *buildManifest `json:"manifest"`
+}
+
+// NewPushRecord records that the build the manifest describes was pushed
+// into parentFolder of the Sumo Logic deployment at pushedAt
+func (m *buildManifest) NewPushRecord(deployment string, parentFolder string, pushedAt time.Time) *pushRecord {
+	return &pushRecord{
+		PushedAt:     pushedAt.UTC().Format(time.RFC3339),
+		Deployment:   deployment,
+		ParentFolder: parentFolder,
+		Manifest:     m,
+	}
+}
+
+// PushRecordPath returns the path of the push record that sits next to a
+// build file
+func PushRecordPath(buildPath string) string {
+	return strings.TrimSuffix(buildPath, filepath.Ext(buildPath)) + ".push.json"
+}
+
+func (r *pushRecord) ToJSON() ([]byte, error) {
+	return canonicalJSON(r)
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// gitState returns the commit checked out at path and whether the
+// working tree has uncommitted changes. An empty commit is returned
+// if path isn't in a git repository
+func gitState(path string) (string, bool) {
+	commit, err := exec.Command("git", "-C", path, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+
+	status, err := exec.Command("git", "-C", path, "status", "--porcelain", "--", ".").Output()
+	if err != nil {
+		return strings.TrimSpace(string(commit)), false
+	}
+
+	return strings.TrimSpace(string(commit)), len(strings.TrimSpace(string(status))) > 0
+}
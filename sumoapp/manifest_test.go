@@ -0,0 +1,245 @@
+package sumoapp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testInitYaml = `name: Test App
+description: ""
+version: ""
+type: FolderSyncDefinition
+`
+
+const testBasePanels = `errors:
+  key: errors
+  title: Errors
+  paneltype: SumoSearchPanel
+  queries:
+  - querystring: error | count
+    querytype: Logs
+    querykey: A
+`
+
+// writeAppFiles writes files, keyed by their path relative to dir, and
+// returns dir
+func writeAppFiles(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// testManifest builds the application in dir and returns its manifest
+func testManifest(t *testing.T, dir string) *buildManifest {
+	t.Helper()
+
+	app := NewApplicationWithPath(dir)
+	if err := app.LoadAppOverlays(); err != nil {
+		t.Fatal(err)
+	}
+
+	build, err := app.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := app.NewBuildManifest(build, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Verify(build); err != nil {
+		t.Errorf("Verify() of the build the manifest was made from = %v", err)
+	}
+
+	return m
+}
+
+// objectHash returns the hash of the object with key in the manifest
+func objectHash(m *buildManifest, objectType string, key string) string {
+	for _, obj := range m.Objects {
+		if obj.Type == objectType && obj.Key == key {
+			return obj.Hash
+		}
+	}
+
+	return ""
+}
+
+func TestNewBuildManifest(t *testing.T) {
+	override := "errors:\n  title: Server Errors\n"
+	dir := writeAppFiles(t, t.TempDir(), map[string]string{
+		"base/init.yaml":           testInitYaml,
+		"base/panels/errors.yaml":  testBasePanels,
+		"middle/panels/panel.yaml": override,
+		"middle/panels/notes.txt":  "not a source file",
+	})
+
+	m := testManifest(t, dir)
+
+	if m.Application != "Test App" || m.CLIVersion != "1.2.3" || m.GitCommit != "" {
+		t.Errorf("manifest = %+v, want Test App built by 1.2.3 outside of git", m)
+	}
+
+	wantOverlays := []manifestOverlay{{"base", "base"}, {"middle", "middle"}, {"final", "final"}}
+	if !reflect.DeepEqual(m.Overlays, wantOverlays) {
+		t.Errorf("Overlays = %+v, want %+v", m.Overlays, wantOverlays)
+	}
+
+	sum := sha256.Sum256([]byte(override))
+	wantSources := map[string]string{
+		"base/init.yaml":           hashBytes([]byte(testInitYaml)),
+		"base/panels/errors.yaml":  hashBytes([]byte(testBasePanels)),
+		"middle/panels/panel.yaml": "sha256:" + hex.EncodeToString(sum[:]),
+	}
+	if !reflect.DeepEqual(m.SourceFiles, wantSources) {
+		t.Errorf("SourceFiles = %v, want %v", m.SourceFiles, wantSources)
+	}
+
+	var panel *manifestObject
+	for i, obj := range m.Objects {
+		if obj.Type == "panel" && obj.Key == "errors" {
+			panel = &m.Objects[i]
+		}
+	}
+
+	if panel == nil || !strings.HasPrefix(panel.Hash, "sha256:") {
+		t.Fatalf("Objects = %+v, want the errors panel with a content hash", m.Objects)
+	}
+
+	if err := m.Verify([]byte("{}")); err == nil {
+		t.Errorf("Verify() of another build should fail")
+	}
+}
+
+func TestBuildManifestObjectHashes(t *testing.T) {
+	files := map[string]string{
+		"base/init.yaml":          testInitYaml,
+		"base/panels/errors.yaml": testBasePanels,
+	}
+
+	first := objectHash(testManifest(t, writeAppFiles(t, t.TempDir(), files)), "panel", "errors")
+	again := objectHash(testManifest(t, writeAppFiles(t, t.TempDir(), files)), "panel", "errors")
+
+	files["middle/panels/panel.yaml"] = "errors:\n  title: Server Errors\n"
+	changed := objectHash(testManifest(t, writeAppFiles(t, t.TempDir(), files)), "panel", "errors")
+
+	if first == "" || first != again {
+		t.Errorf("hashes of the same panel = %q and %q, want them equal", first, again)
+	}
+
+	if changed == first {
+		t.Errorf("hash of a changed panel = %q, want it to change", changed)
+	}
+}
+
+func TestManifestObjects(t *testing.T) {
+	objects := map[string]*panel{
+		"b": {Key: "b", Title: "B"},
+		"a": {Key: "a", Title: "A"},
+	}
+
+	got, err := manifestObjects("panel", objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || got[0].Key != "a" || got[1].Key != "b" {
+		t.Fatalf("manifestObjects() = %+v, want a and b sorted by key", got)
+	}
+
+	canonical, err := canonicalJSON(objects["a"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got[0].Hash != hashBytes(canonical) {
+		t.Errorf("hash of a = %s, want the hash of its canonical JSON %s", got[0].Hash, hashBytes(canonical))
+	}
+
+	if _, err := manifestObjects("panel", map[string]string{"a": "not an object"}); err == nil {
+		t.Errorf("manifestObjects() of objects without names should fail")
+	}
+}
+
+func TestManifestPaths(t *testing.T) {
+	tests := []struct {
+		build      string
+		wantRecord string
+		want       string
+	}{
+		{"build.json", "build.push.json", "build.manifest.json"},
+		{"out/app.json", "out/app.push.json", "out/app.manifest.json"},
+		{"build", "build.push.json", "build.manifest.json"},
+	}
+
+	for _, tt := range tests {
+		if got := ManifestPath(tt.build); got != tt.want {
+			t.Errorf("ManifestPath(%q) = %q, want %q", tt.build, got, tt.want)
+		}
+
+		if got := PushRecordPath(tt.build); got != tt.wantRecord {
+			t.Errorf("PushRecordPath(%q) = %q, want %q", tt.build, got, tt.wantRecord)
+		}
+	}
+}
+
+func TestFindBuildManifest(t *testing.T) {
+	dir := t.TempDir()
+	buildPath := filepath.Join(dir, "build.json")
+
+	m, err := FindBuildManifest(buildPath, "")
+	if err != nil || m != nil {
+		t.Fatalf("FindBuildManifest() without a manifest = %v, %v, want none", m, err)
+	}
+
+	if _, err := FindBuildManifest(buildPath, filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("FindBuildManifest() of a missing manifest given by name should fail")
+	}
+
+	want := &buildManifest{Application: "Test App", BuildHash: hashBytes([]byte("{}"))}
+	data, err := want.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(ManifestPath(buildPath), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = FindBuildManifest(buildPath, "")
+	if err != nil || m == nil || m.Application != "Test App" || m.BuildHash != want.BuildHash {
+		t.Errorf("FindBuildManifest() = %+v, %v, want the manifest next to the build", m, err)
+	}
+}
+
+func TestNewPushRecord(t *testing.T) {
+	m := &buildManifest{Application: "Test App", Version: "1.0", BuildHash: "sha256:abc", CLIVersion: "1.2.3", GitCommit: "0123abc", GitDirty: true}
+
+	record := m.NewPushRecord("us2", "0000000001", time.Date(2026, 10, 19, 14, 30, 0, 0, time.FixedZone("", 2*60*60)))
+	if record.PushedAt != "2026-10-19T12:30:00Z" || record.Deployment != "us2" || record.ParentFolder != "0000000001" || record.Manifest != m {
+		t.Errorf("NewPushRecord() = %+v", record)
+	}
+
+	want := `app="Test App" version="1.0" build=sha256:abc cli=1.2.3 commit=0123abc-dirty`
+	if got := m.Summary(); got != want {
+		t.Errorf("Summary() = %s, want %s", got, want)
+	}
+}
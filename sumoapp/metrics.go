@@ -0,0 +1,210 @@
+package sumoapp
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// MetricsOutputFormats lists the formats metrics query results can be
+// written in
+var MetricsOutputFormats = []string{"table", "csv", "json"}
+
+type metricsQueryRow struct {
+	RowId        string `json:"rowId"`
+	Query        string `json:"query"`
+	Quantization int64  `json:"quantization,omitempty"`
+	Rollup       string `json:"rollup,omitempty"`
+}
+
+type metricsTimeBoundary struct {
+	Type        string `json:"type"`
+	EpochMillis int64  `json:"epochMillis"`
+}
+
+type metricsTimeRange struct {
+	Type string              `json:"type"`
+	From metricsTimeBoundary `json:"from"`
+	To   metricsTimeBoundary `json:"to"`
+}
+
+type metricsQueryRequest struct {
+	Queries   []metricsQueryRow `json:"queries"`
+	TimeRange metricsTimeRange  `json:"timeRange"`
+}
+
+type metricsQueryResponse struct {
+	QueryResult []struct {
+		RowId          string `json:"rowId"`
+		TimeSeriesList struct {
+			TimeSeries []struct {
+				MetricDefinition struct {
+					Metric     string            `json:"metric"`
+					Dimensions map[string]string `json:"dimensions"`
+				} `json:"metricDefinition"`
+				Points struct {
+					Timestamps []int64   `json:"timestamps"`
+					Values     []float64 `json:"values"`
+				} `json:"points"`
+			} `json:"timeSeries"`
+		} `json:"timeSeriesList"`
+	} `json:"queryResult"`
+	Errors *struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"errors"`
+}
+
+type metricsPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// metricsSeries is one time series returned by a metrics query
+type metricsSeries struct {
+	RowId      string            `json:"rowId"`
+	Metric     string            `json:"metric"`
+	Dimensions map[string]string `json:"dimensions"`
+	Points     []metricsPoint    `json:"points"`
+}
+
+type metricsResult struct {
+	Start  string          `json:"start"`
+	End    string          `json:"end"`
+	Series []metricsSeries `json:"series"`
+}
+
+// QueryMetrics runs metrics queries over start-end with the Metrics Query
+// API. queries maps row IDs, such as A, to queries, so queries can refer to
+// each other with #A. A quantization of 0 and an empty rollup let the API
+// choose them
+func QueryMetrics(ctx context.Context, a *APIClient, queries map[string]string, start time.Time, end time.Time, quantization time.Duration, rollup string) (*metricsResult, error) {
+	if !start.Before(end) {
+		return nil, fmt.Errorf("The query's start time (%s) must be before its end time (%s)", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	request := metricsQueryRequest{
+		Queries: make([]metricsQueryRow, 0, len(queries)),
+		TimeRange: metricsTimeRange{
+			Type: "BeginBoundedTimeRange",
+			From: metricsTimeBoundary{Type: "EpochTimeRangeBoundary", EpochMillis: start.UnixNano() / int64(time.Millisecond)},
+			To:   metricsTimeBoundary{Type: "EpochTimeRangeBoundary", EpochMillis: end.UnixNano() / int64(time.Millisecond)},
+		},
+	}
+
+	for _, rowId := range sortedKeys(queries) {
+		request.Queries = append(request.Queries, metricsQueryRow{
+			RowId:        rowId,
+			Query:        queries[rowId],
+			Quantization: int64(quantization / time.Millisecond),
+			Rollup:       rollup,
+		})
+	}
+
+	var response metricsQueryResponse
+	if err := a.requestJSON(ctx, "POST", "/v1/metricsQueries", nil, request, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Errors != nil && len(response.Errors.Errors) > 0 {
+		messages := make([]string, 0, len(response.Errors.Errors))
+		for _, e := range response.Errors.Errors {
+			messages = append(messages, e.Message)
+		}
+
+		return nil, fmt.Errorf("Metrics query failed: %s", strings.Join(messages, "; "))
+	}
+
+	result := &metricsResult{
+		Start:  start.Format(time.RFC3339),
+		End:    end.Format(time.RFC3339),
+		Series: make([]metricsSeries, 0),
+	}
+
+	for _, row := range response.QueryResult {
+		for _, ts := range row.TimeSeriesList.TimeSeries {
+			series := metricsSeries{
+				RowId:      row.RowId,
+				Metric:     ts.MetricDefinition.Metric,
+				Dimensions: ts.MetricDefinition.Dimensions,
+				Points:     make([]metricsPoint, 0, len(ts.Points.Timestamps)),
+			}
+
+			for i, timestamp := range ts.Points.Timestamps {
+				if i < len(ts.Points.Values) {
+					series.Points = append(series.Points, metricsPoint{Timestamp: timestamp, Value: ts.Points.Values[i]})
+				}
+			}
+
+			result.Series = append(result.Series, series)
+		}
+	}
+
+	sort.SliceStable(result.Series, func(i, j int) bool {
+		a, b := result.Series[i], result.Series[j]
+		if a.RowId != b.RowId {
+			return a.RowId < b.RowId
+		}
+		return a.name() < b.name()
+	})
+
+	return result, nil
+}
+
+// name identifies a series by its metric and dimensions
+func (s *metricsSeries) name() string {
+	dimensions := make([]string, 0, len(s.Dimensions))
+	for _, key := range sortedKeys(s.Dimensions) {
+		dimensions = append(dimensions, key+"="+s.Dimensions[key])
+	}
+
+	return strings.TrimSpace(s.Metric + " " + strings.Join(dimensions, " "))
+}
+
+// Len returns the number of time series
+func (r *metricsResult) Len() int {
+	return len(r.Series)
+}
+
+// Write writes the time series to w in one of the MetricsOutputFormats.
+// Tables and CSV have a row per data point
+func (r *metricsResult) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		j, err := canonicalJSON(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(j)
+		return err
+	case "csv":
+		c := csv.NewWriter(w)
+		c.Write([]string{"query", "series", "timestamp", "value"})
+		for _, s := range r.Series {
+			for _, p := range s.Points {
+				c.Write([]string{s.RowId, s.name(), strconv.FormatInt(p.Timestamp, 10), strconv.FormatFloat(p.Value, 'f', -1, 64)})
+			}
+		}
+		c.Flush()
+		return c.Error()
+	case "table":
+		table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(table, "QUERY\tSERIES\tTIME\tVALUE")
+		for _, s := range r.Series {
+			for _, p := range s.Points {
+				fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", s.RowId, s.name(), time.Unix(0, p.Timestamp*int64(time.Millisecond)).Format("2006-01-02 15:04:05"), strconv.FormatFloat(p.Value, 'f', -1, 64))
+			}
+		}
+		return table.Flush()
+	}
+
+	return fmt.Errorf("Unknown output format '%s'. Expected one of: %s", format, strings.Join(MetricsOutputFormats, ", "))
+}
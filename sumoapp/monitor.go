@@ -0,0 +1,489 @@
+package sumoapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/imdario/mergo"
+)
+
+// Types of the folders and monitors returned by the Monitors API, as
+// opposed to the export format used in builds
+const (
+	monitorsLibraryFolderType  = "MonitorsLibraryFolder"
+	monitorsLibraryMonitorType = "MonitorsLibraryMonitor"
+	monitorsLibraryUpdateType  = "MonitorsLibraryMonitorUpdate"
+)
+
+// monitorTriggerTypes are the trigger types a monitor can define
+var monitorTriggerTypes = []string{
+	"Critical", "Warning", "MissingData",
+	"ResolvedCritical", "ResolvedWarning", "ResolvedMissingData",
+}
+
+func (m *monitor) Merge(mon *monitor) error {
+	newMonitor := mon.Copy()
+
+	if err := mergo.Merge(newMonitor, m, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	if err := mergo.Merge(m, newMonitor, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *monitor) Copy() *monitor {
+	mon := &monitor{}
+	mergo.Merge(mon, m)
+
+	//mergo copies slices, so the lists need their own copies
+	mon.Queries = append([]monitorQuery(nil), m.Queries...)
+	mon.Triggers = append([]monitorTrigger(nil), m.Triggers...)
+	mon.Notifications = make([]monitorNotification, 0, len(m.Notifications))
+	for _, n := range m.Notifications {
+		n.Notification.Recipients = append([]string(nil), n.Notification.Recipients...)
+		n.RunForTriggerTypes = append([]string(nil), n.RunForTriggerTypes...)
+		mon.Notifications = append(mon.Notifications, n)
+	}
+
+	return mon
+}
+
+// Validate checks the monitor is complete enough for the Monitors API to
+// accept it. Notifications can only run for triggers the monitor defines
+func (m *monitor) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("The monitor has no name")
+	}
+
+	if m.MonitorType != LogsQueryType && m.MonitorType != MetricsQueryType {
+		return fmt.Errorf("Unknown monitor type '%s'. Expected %s or %s", m.MonitorType, LogsQueryType, MetricsQueryType)
+	}
+
+	for _, segment := range strings.Split(m.Folder, "/") {
+		if m.Folder != "" && strings.TrimSpace(segment) == "" {
+			return fmt.Errorf("Folder '%s' has an empty folder name", m.Folder)
+		}
+	}
+
+	if len(m.Queries) == 0 {
+		return fmt.Errorf("The monitor has no queries")
+	}
+
+	rowIds := make(map[string]bool)
+	for _, q := range m.Queries {
+		if q.RowId == "" || rowIds[q.RowId] {
+			return fmt.Errorf("Every query needs a unique rowId, found '%s'", q.RowId)
+		}
+		rowIds[q.RowId] = true
+
+		if strings.TrimSpace(q.Query) == "" {
+			return fmt.Errorf("Query %s is empty", q.RowId)
+		}
+	}
+
+	if len(m.Triggers) == 0 {
+		return fmt.Errorf("The monitor has no triggers")
+	}
+
+	triggerTypes := make([]string, 0, len(m.Triggers))
+	for _, t := range m.Triggers {
+		if !containsString(monitorTriggerTypes, t.TriggerType) {
+			return fmt.Errorf("Unknown trigger type '%s'. Expected one of: %s", t.TriggerType, strings.Join(monitorTriggerTypes, ", "))
+		}
+
+		if containsString(triggerTypes, t.TriggerType) {
+			return fmt.Errorf("Trigger %s is defined more than once", t.TriggerType)
+		}
+		triggerTypes = append(triggerTypes, t.TriggerType)
+	}
+
+	for i, n := range m.Notifications {
+		switch {
+		case n.Notification.ConnectionType == "":
+			return fmt.Errorf("Notification %d has no connectionType", i+1)
+		case n.Notification.ConnectionType == "Email" && len(n.Notification.Recipients) == 0:
+			return fmt.Errorf("Email notification %d has no recipients", i+1)
+		case n.Notification.ConnectionType != "Email" && n.Notification.ConnectionId == "":
+			return fmt.Errorf("%s notification %d has no connectionId", n.Notification.ConnectionType, i+1)
+		}
+
+		if len(n.RunForTriggerTypes) == 0 {
+			return fmt.Errorf("Notification %d has no runForTriggerTypes", i+1)
+		}
+
+		for _, triggerType := range n.RunForTriggerTypes {
+			if !containsString(triggerTypes, triggerType) {
+				return fmt.Errorf("Notification %d runs for trigger %s, which the monitor doesn't define", i+1, triggerType)
+			}
+		}
+	}
+
+	return nil
+}
+
+// MonitorsFolder arranges the overlay's monitors into the folder tree the
+// Monitors API imports. The root folder is named after the application.
+// Folders are listed before monitors and both are sorted by name, so the
+// same monitors always produce the same tree. It returns nil when the
+// overlay has no monitors
+func (s *appOverlay) MonitorsFolder() *monitorFolder {
+	if len(s.Monitors) == 0 {
+		return nil
+	}
+
+	root := newMonitorFolder(s.RootFolder.Name, s.RootFolder.Description)
+	folders := map[string]*monitorFolder{"": root}
+
+	for _, key := range sortedKeys(s.Monitors) {
+		m := s.Monitors[key]
+
+		parent := root
+		path := ""
+		if m.Folder != "" {
+			for _, name := range strings.Split(m.Folder, "/") {
+				path = joinMonitorPath(path, name)
+
+				f, ok := folders[path]
+				if !ok {
+					f = newMonitorFolder(name, "")
+					folders[path] = f
+					parent.Children = append(parent.Children, f)
+				}
+
+				parent = f
+			}
+		}
+
+		mon := *m
+		mon.Type = MonitorType
+		parent.Children = append(parent.Children, &mon)
+	}
+
+	for _, f := range folders {
+		f.sortChildren()
+	}
+
+	return root
+}
+
+func newMonitorFolder(name string, description string) *monitorFolder {
+	return &monitorFolder{
+		Type:        MonitorFolderType,
+		Name:        name,
+		Description: description,
+		Children:    make([]interface{}, 0),
+	}
+}
+
+func (f *monitorFolder) sortChildren() {
+	sortKey := func(child interface{}) string {
+		switch c := child.(type) {
+		case *monitorFolder:
+			return "0" + c.Name
+		case *monitor:
+			return "1" + c.Name
+		}
+		return ""
+	}
+
+	sort.SliceStable(f.Children, func(i, j int) bool {
+		return sortKey(f.Children[i]) < sortKey(f.Children[j])
+	})
+}
+
+func joinMonitorPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "/" + name
+}
+
+// UnmarshalJSON decodes the children of an exported folder into folders
+// and monitors
+func (f *monitorFolder) UnmarshalJSON(data []byte) error {
+	var export struct {
+		Type        string            `json:"type"`
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		Children    []json.RawMessage `json:"children"`
+	}
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		return err
+	}
+
+	f.Type = export.Type
+	f.Name = export.Name
+	f.Description = export.Description
+	f.Children = make([]interface{}, 0, len(export.Children))
+
+	for _, childData := range export.Children {
+		child, err := decodeMonitorsExport(childData)
+		if err != nil {
+			return err
+		}
+
+		f.Children = append(f.Children, child)
+	}
+
+	return nil
+}
+
+// decodeMonitorsExport decodes a folder or monitor exported from the
+// Monitors Library into a *monitorFolder or a *monitor
+func decodeMonitorsExport(data []byte) (interface{}, error) {
+	var export struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+
+	switch export.Type {
+	case MonitorFolderType:
+		f := &monitorFolder{}
+		if err := json.Unmarshal(data, f); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case MonitorType:
+		m := &monitor{}
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("Unknown monitors library type: %s", export.Type)
+}
+
+// importMonitorsExport adds the monitors of an exported monitors folder,
+// or a single exported monitor, to the overlay. The exported folder takes
+// the place of the application's monitors folder
+func (a *application) importMonitorsExport(data []byte, overlay *appOverlay) error {
+	export, err := decodeMonitorsExport(data)
+	if err != nil {
+		return err
+	}
+
+	root, ok := export.(*monitorFolder)
+	if !ok {
+		root = newMonitorFolder("", "")
+		root.Children = append(root.Children, export)
+	}
+
+	importMonitors(root, "", overlay)
+
+	overlay.Normalize(a.NormalizationRules())
+
+	return nil
+}
+
+// importMonitors adds the monitors in a folder and its subfolders to the
+// overlay. path is the folder's path below the application's monitors
+// folder
+func importMonitors(f *monitorFolder, path string, overlay *appOverlay) {
+	for _, child := range f.Children {
+		switch c := child.(type) {
+		case *monitorFolder:
+			importMonitors(c, joinMonitorPath(path, c.Name), overlay)
+		case *monitor:
+			c.Folder = path
+			c.Type = "" //Making this an empty string will cause the yaml marsheler omit it
+			overlay.Monitors[sanitizeName(c.Name)] = c
+		}
+	}
+}
+
+// monitorsLibraryItem is a folder or monitor as the Monitors API returns
+// it. Only folders have children
+type monitorsLibraryItem struct {
+	Id       string                `json:"id"`
+	Type     string                `json:"type"`
+	Name     string                `json:"name"`
+	Version  int64                 `json:"version"`
+	Children []monitorsLibraryItem `json:"children"`
+}
+
+func getMonitorsLibraryItem(ctx context.Context, a *APIClient, id string) (*monitorsLibraryItem, error) {
+	item := &monitorsLibraryItem{}
+	if err := a.requestJSON(ctx, "GET", "/v1/monitors/"+id, nil, nil, item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// child returns the child of the folder with the given type and name
+func (item *monitorsLibraryItem) child(itemType string, name string) *monitorsLibraryItem {
+	for i, c := range item.Children {
+		if c.Type == itemType && c.Name == name {
+			return &item.Children[i]
+		}
+	}
+
+	return nil
+}
+
+// Monitor push actions
+const (
+	MonitorCreated = "created"
+	MonitorUpdated = "updated"
+)
+
+type monitorPushAction struct {
+	Action     string `json:"action"`
+	ObjectType string `json:"objectType"`
+	Path       string `json:"path"`
+}
+
+type monitorPushResult struct {
+	Actions []monitorPushAction `json:"actions"`
+}
+
+func (r *monitorPushResult) add(action string, objectType string, path string) {
+	r.Actions = append(r.Actions, monitorPushAction{Action: action, ObjectType: objectType, Path: path})
+}
+
+// Display writes a line for every folder and monitor the push created or
+// updated
+func (r *monitorPushResult) Display(w io.Writer) {
+	for _, a := range r.Actions {
+		fmt.Fprintf(w, "%s %s %s\n", a.Action, a.ObjectType, a.Path)
+	}
+}
+
+// Upsert pushes the folder tree to the Monitors Library below the folder
+// with parentId, or the library's root folder if parentId is empty.
+// Folders and monitors are matched by name. Missing ones are created and
+// existing monitors are updated. Monitors that are only in the library
+// are left alone
+func (f *monitorFolder) Upsert(ctx context.Context, a *APIClient, parentId string) (*monitorPushResult, error) {
+	result := &monitorPushResult{
+		Actions: make([]monitorPushAction, 0),
+	}
+
+	if parentId == "" {
+		parentId = "root"
+	}
+
+	parent, err := getMonitorsLibraryItem(ctx, a, parentId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.upsert(ctx, a, parent, f.Name, result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (f *monitorFolder) upsert(ctx context.Context, a *APIClient, parent *monitorsLibraryItem, path string, result *monitorPushResult) error {
+	var current *monitorsLibraryItem
+
+	if existing := parent.child(monitorsLibraryFolderType, f.Name); existing != nil {
+		folderItem, err := getMonitorsLibraryItem(ctx, a, existing.Id)
+		if err != nil {
+			return err
+		}
+
+		current = folderItem
+	} else {
+		body := map[string]string{
+			"type":        monitorsLibraryFolderType,
+			"name":        f.Name,
+			"description": f.Description,
+		}
+
+		current = &monitorsLibraryItem{}
+		query := url.Values{"parentId": []string{parent.Id}}
+		if err := a.requestJSON(ctx, "POST", "/v1/monitors", query, body, current); err != nil {
+			return fmt.Errorf("Unable to create monitors folder %s: %w", path, err)
+		}
+
+		result.add(MonitorCreated, "folder", path)
+	}
+
+	for _, child := range f.Children {
+		switch c := child.(type) {
+		case *monitorFolder:
+			if err := c.upsert(ctx, a, current, joinMonitorPath(path, c.Name), result); err != nil {
+				return err
+			}
+		case *monitor:
+			if err := c.upsert(ctx, a, current, joinMonitorPath(path, c.Name), result); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *monitor) upsert(ctx context.Context, a *APIClient, parent *monitorsLibraryItem, path string, result *monitorPushResult) error {
+	body := *m
+
+	if existing := parent.child(monitorsLibraryMonitorType, m.Name); existing != nil {
+		body.Type = monitorsLibraryUpdateType
+
+		//Updates must send the version they're based on
+		update := struct {
+			monitor
+			Version int64 `json:"version"`
+		}{body, existing.Version}
+
+		if err := a.requestJSON(ctx, "PUT", "/v1/monitors/"+existing.Id, nil, update, nil); err != nil {
+			return fmt.Errorf("Unable to update monitor %s: %w", path, err)
+		}
+
+		result.add(MonitorUpdated, "monitor", path)
+		return nil
+	}
+
+	body.Type = monitorsLibraryMonitorType
+
+	query := url.Values{"parentId": []string{parent.Id}}
+	if err := a.requestJSON(ctx, "POST", "/v1/monitors", query, body, nil); err != nil {
+		return fmt.Errorf("Unable to create monitor %s: %w", path, err)
+	}
+
+	result.add(MonitorCreated, "monitor", path)
+	return nil
+}
+
+// ReadBuildMonitors returns the monitors folder of a build, or nil if the
+// build has no monitors
+func ReadBuildMonitors(build []byte) (*monitorFolder, error) {
+	var b struct {
+		Monitors *monitorFolder `json:"monitors"`
+	}
+
+	if err := json.Unmarshal(build, &b); err != nil {
+		return nil, err
+	}
+
+	return b.Monitors, nil
+}
+
+// DownloadMonitors exports a folder or monitor of the Monitors Library.
+// The export can be imported into an overlay like a content export
+func DownloadMonitors(ctx context.Context, a *APIClient, id string) ([]byte, error) {
+	var export json.RawMessage
+	if err := a.requestJSON(ctx, "GET", "/v1/monitors/"+id+"/export", nil, nil, &export); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
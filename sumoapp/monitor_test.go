@@ -0,0 +1,200 @@
+package sumoapp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testBaseMonitors = `high-errors:
+  name: High errors
+  folder: Prod/Errors
+  monitortype: Logs
+  queries:
+  - rowid: A
+    query: error | count
+  triggers:
+  - triggertype: Critical
+    timerange: -15m
+    threshold: 10
+    thresholdtype: GreaterThan
+  notifications:
+  - notification:
+      connectiontype: Email
+      recipients: [ops@example.com]
+    runfortriggertypes: [Critical]
+`
+
+// testMonitors loads an application with the base monitors and the
+// monitors of the middle overlay, and returns the middle overlay
+func testMonitors(t *testing.T, override string) (*appOverlay, error) {
+	t.Helper()
+
+	dir := writeAppFiles(t, t.TempDir(), map[string]string{
+		"base/init.yaml":                testInitYaml,
+		"base/monitors/monitors.yaml":   testBaseMonitors,
+		"middle/monitors/monitors.yaml": override,
+	})
+
+	app := NewApplicationWithPath(dir)
+	if err := app.LoadAppOverlays(); err != nil {
+		return nil, err
+	}
+
+	return app.FindAppOverlay("middle")
+}
+
+func TestMonitorMerge(t *testing.T) {
+	middle, err := testMonitors(t, "high-errors:\n  triggers:\n  - triggertype: Critical\n    timerange: -5m\n    threshold: 50\n    thresholdtype: GreaterThan\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := middle.Monitors["high-errors"]
+	if m == nil {
+		t.Fatalf("Monitors = %v, want high-errors", middle.Monitors)
+	}
+
+	if m.Name != "High errors" || m.Folder != "Prod/Errors" || len(m.Queries) != 1 || len(m.Notifications) != 1 {
+		t.Errorf("merged monitor = %+v, want the fields the overlay leaves out inherited", m)
+	}
+
+	if len(m.Triggers) != 1 || m.Triggers[0].Threshold != 50 || m.Triggers[0].TimeRange != "-5m" {
+		t.Errorf("merged triggers = %+v, want the overlay's", m.Triggers)
+	}
+
+	base := middle.Parent.Monitors["high-errors"]
+	if base.Triggers[0].Threshold != 10 {
+		t.Errorf("base triggers = %+v, want them left unchanged by the merge", base.Triggers)
+	}
+}
+
+func TestMonitorCopy(t *testing.T) {
+	middle, err := testMonitors(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := middle.Monitors["high-errors"]
+	c := m.Copy()
+	if !reflect.DeepEqual(c, m) {
+		t.Fatalf("Copy() = %+v, want %+v", c, m)
+	}
+
+	c.Queries[0].Query = "changed"
+	c.Notifications[0].Notification.Recipients[0] = "changed@example.com"
+	c.Notifications[0].RunForTriggerTypes[0] = "Warning"
+
+	if m.Queries[0].Query == "changed" || m.Notifications[0].Notification.Recipients[0] == "changed@example.com" || m.Notifications[0].RunForTriggerTypes[0] == "Warning" {
+		t.Errorf("changing the copy changed the monitor: %+v", m)
+	}
+}
+
+func TestMonitorValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		wantErr  string
+	}{
+		{
+			name:     "valid",
+			override: "high-errors:\n  description: Errors are high\n",
+		},
+		{
+			name:     "unknown type",
+			override: "high-errors:\n  monitortype: Traces\n",
+			wantErr:  "Unknown monitor type 'Traces'",
+		},
+		{
+			name:     "empty folder name",
+			override: "high-errors:\n  folder: Prod//Errors\n",
+			wantErr:  "empty folder name",
+		},
+		{
+			name:     "duplicate row IDs",
+			override: "high-errors:\n  queries:\n  - rowid: A\n    query: error\n  - rowid: A\n    query: warn\n",
+			wantErr:  "unique rowId",
+		},
+		{
+			name:     "empty query",
+			override: "high-errors:\n  queries:\n  - rowid: A\n    query: \" \"\n",
+			wantErr:  "Query A is empty",
+		},
+		{
+			name:     "unknown trigger",
+			override: "high-errors:\n  triggers:\n  - triggertype: Fatal\n",
+			wantErr:  "Unknown trigger type 'Fatal'",
+		},
+		{
+			name:     "duplicate trigger",
+			override: "high-errors:\n  triggers:\n  - triggertype: Critical\n  - triggertype: Critical\n",
+			wantErr:  "defined more than once",
+		},
+		{
+			name:     "email without recipients",
+			override: "high-errors:\n  notifications:\n  - notification:\n      connectiontype: Email\n    runfortriggertypes: [Critical]\n",
+			wantErr:  "has no recipients",
+		},
+		{
+			name:     "webhook without connection",
+			override: "high-errors:\n  notifications:\n  - notification:\n      connectiontype: Webhook\n    runfortriggertypes: [Critical]\n",
+			wantErr:  "has no connectionId",
+		},
+		{
+			name:     "notification for an undefined trigger",
+			override: "high-errors:\n  notifications:\n  - notification:\n      connectiontype: Webhook\n      connectionid: \"0000000000000001\"\n    runfortriggertypes: [Warning]\n",
+			wantErr:  "which the monitor doesn't define",
+		},
+		{
+			name:     "new monitor without queries",
+			override: "low-traffic:\n  name: Low traffic\n  monitortype: Metrics\n",
+			wantErr:  "has no queries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testMonitors(t, tt.override)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("loading the monitors = %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("loading the monitors = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMonitorsFolder(t *testing.T) {
+	middle, err := testMonitors(t, "low-traffic:\n  name: Low traffic\n  monitortype: Logs\n  queries:\n  - rowid: A\n    query: \"*\"\n  triggers:\n  - triggertype: Warning\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := middle.MonitorsFolder()
+	if root == nil || root.Name != "Test App" || root.Type != MonitorFolderType {
+		t.Fatalf("MonitorsFolder() = %+v, want a folder named after the application", root)
+	}
+
+	if len(root.Children) != 2 {
+		t.Fatalf("root children = %+v, want the Prod folder then Low traffic", root.Children)
+	}
+
+	prod, ok := root.Children[0].(*monitorFolder)
+	if !ok || prod.Name != "Prod" || len(prod.Children) != 1 {
+		t.Fatalf("first child = %+v, want the Prod folder", root.Children[0])
+	}
+
+	errors, ok := prod.Children[0].(*monitorFolder)
+	if !ok || errors.Name != "Errors" || len(errors.Children) != 1 {
+		t.Fatalf("Prod children = %+v, want the Errors folder", prod.Children)
+	}
+
+	if m, ok := errors.Children[0].(*monitor); !ok || m.Name != "High errors" || m.Type != MonitorType {
+		t.Errorf("Errors children = %+v, want High errors", errors.Children)
+	}
+
+	if m, ok := root.Children[1].(*monitor); !ok || m.Name != "Low traffic" {
+		t.Errorf("second child = %+v, want Low traffic", root.Children[1])
+	}
+}
@@ -0,0 +1,292 @@
+package sumoapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/r3labs/diff"
+)
+
+// NormalizationFile is the file at the root of an application that holds
+// its normalization rules
+const NormalizationFile = "normalize.yaml"
+
+// normalizationRules describe the fields of exported content that change
+// between exports without the content itself changing. Each rule is an
+// object type (or '*' for every type) followed by a dot separated field
+// path. A '*' segment matches any field, map key, or list element, and
+// list elements can also be matched by their index or key
+type normalizationRules struct {
+	// Strip clears fields when content is imported, built, or diffed
+	Strip []string `yaml:"strip"`
+	// Canonicalize rewrites fields holding JSON documents with sorted keys
+	// and no insignificant whitespace
+	Canonicalize []string `yaml:"canonicalize"`
+	// Ignore keeps fields in the application but leaves them out of diffs
+	Ignore []string `yaml:"ignore"`
+}
+
+// DefaultNormalizationRules returns the rules used by applications that
+// don't have a normalization file. They strip the IDs Sumo Logic assigns
+// and canonicalize the JSON documents embedded in panels and layouts
+func DefaultNormalizationRules() *normalizationRules {
+	return &normalizationRules{
+		Strip: []string{
+			"folder.Id",
+			"panel.Id",
+			"variable.Id",
+		},
+		Canonicalize: []string{
+			"panel.VisualSettings",
+			"dashboard.Layout.LayoutStructures.*.Structure",
+		},
+		Ignore: []string{},
+	}
+}
+
+// ReadNormalizationRules reads the normalization file of the application
+// at appPath. The default rules are returned if there isn't one
+func ReadNormalizationRules(appPath string) (*normalizationRules, error) {
+	path := filepath.Join(appPath, NormalizationFile)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return DefaultNormalizationRules(), nil
+	}
+
+	rules := &normalizationRules{}
+	if err := readYamlFile(path, rules); err != nil {
+		return nil, err
+	}
+
+	if err := rules.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid rule in %s: %w", path, err)
+	}
+
+	return rules, nil
+}
+
+// LoadNormalizationRules reads the application's normalization file. Import,
+// LoadAppOverlays, and Changes use the rules it read, or the default rules
+// if it hasn't been called
+func (a *application) LoadNormalizationRules() error {
+	rules, err := ReadNormalizationRules(a.path)
+	if err != nil {
+		return err
+	}
+
+	a.normalization = rules
+	return nil
+}
+
+// NormalizationRules returns the rules content of the application is
+// normalized with
+func (a *application) NormalizationRules() *normalizationRules {
+	if a.normalization == nil {
+		return DefaultNormalizationRules()
+	}
+
+	return a.normalization
+}
+
+// Validate checks every rule names a known object type and a field path
+func (r *normalizationRules) Validate() error {
+	ruleLists := [][]string{r.Strip, r.Canonicalize, r.Ignore}
+
+	for _, rules := range ruleLists {
+		for _, rule := range rules {
+			objectType, fieldPath := splitRule(rule)
+			if objectType != "*" && objectTypeIndex(objectType) == len(objectTypeOrder) {
+				return fmt.Errorf("Unknown object type in rule '%s'. Expected '*' or one of: %s", rule, strings.Join(objectTypeOrder, ", "))
+			}
+
+			if len(fieldPath) == 0 {
+				return fmt.Errorf("Rule '%s' has no field path", rule)
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitRule splits a rule into its object type and field path
+func splitRule(rule string) (string, []string) {
+	segments := strings.Split(rule, ".")
+	return segments[0], segments[1:]
+}
+
+// Normalize applies the strip and canonicalize rules to every component
+// of the overlay
+func (s *appOverlay) Normalize(rules *normalizationRules) {
+	for _, list := range s.objectLists() {
+		objects := reflect.ValueOf(list.objects)
+
+		for _, key := range objects.MapKeys() {
+			object := objects.MapIndex(key)
+
+			for _, rule := range rules.Strip {
+				if objectType, fieldPath := splitRule(rule); ruleAppliesTo(objectType, list.objectType) {
+					visitFieldPath(object, fieldPath, stripField)
+				}
+			}
+
+			for _, rule := range rules.Canonicalize {
+				if objectType, fieldPath := splitRule(rule); ruleAppliesTo(objectType, list.objectType) {
+					visitFieldPath(object, fieldPath, canonicalizeField)
+				}
+			}
+		}
+	}
+}
+
+// ignores reports whether a change is to a field the ignore rules leave
+// out of diffs
+func (r *normalizationRules) ignores(c diff.Change) bool {
+	//Changes to whole objects have no field path to ignore
+	if len(c.Path) <= 2 {
+		return false
+	}
+
+	for _, rule := range r.Ignore {
+		objectType, fieldPath := splitRule(rule)
+		if ruleAppliesTo(objectType, c.Path[0]) && fieldPathMatches(fieldPath, c.Path[2:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyIgnoreRules removes the changes the ignore rules leave out of diffs
+func (cs *changeSet) applyIgnoreRules(rules *normalizationRules) {
+	changelogs := []*diff.Changelog{
+		&cs.ChangelogVar,
+		&cs.ChangelogPanel,
+		&cs.ChangelogSavedSearches,
+		&cs.ChangelogDashboard,
+		&cs.ChangelogFolder,
+		&cs.ChangelogMonitor,
+		&cs.ChangelogParser,
+	}
+
+	for _, changelog := range changelogs {
+		kept := make(diff.Changelog, 0, len(*changelog))
+		for _, c := range *changelog {
+			if !rules.ignores(c) {
+				kept = append(kept, c)
+			}
+		}
+
+		*changelog = kept
+	}
+}
+
+func ruleAppliesTo(ruleType string, objectType string) bool {
+	return ruleType == "*" || ruleType == objectType
+}
+
+// visitFieldPath calls visit with every value at the field path below v.
+// Struct fields are matched case-insensitively by name or JSON name
+func visitFieldPath(v reflect.Value, fieldPath []string, visit func(reflect.Value)) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	if len(fieldPath) == 0 {
+		visit(v)
+		return
+	}
+
+	segment := fieldPath[0]
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+
+			//Unexported fields aren't part of the content
+			if field.PkgPath != "" {
+				continue
+			}
+
+			jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+			if segment == "*" || strings.EqualFold(segment, field.Name) || strings.EqualFold(segment, jsonName) {
+				visitFieldPath(v.Field(i), fieldPath[1:], visit)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if segment == "*" || segment == strconv.Itoa(i) || segment == elementIdentifier(v.Index(i)) {
+				visitFieldPath(v.Index(i), fieldPath[1:], visit)
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if segment == "*" || segment == fmt.Sprint(key.Interface()) {
+				visitFieldPath(v.MapIndex(key), fieldPath[1:], visit)
+			}
+		}
+	}
+}
+
+// elementIdentifier returns the value of the field a list element is
+// identified by in diffs, such as a layout structure's key
+func elementIdentifier(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if strings.HasSuffix(v.Type().Field(i).Tag.Get("diff"), ",identifier") {
+			return fmt.Sprint(v.Field(i).Interface())
+		}
+	}
+
+	return ""
+}
+
+func stripField(v reflect.Value) {
+	if v.CanSet() {
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
+// canonicalizeField rewrites a string field holding a JSON document in
+// canonical form. Fields that don't hold JSON are left alone
+func canonicalizeField(v reflect.Value) {
+	if !v.CanSet() || v.Kind() != reflect.String || v.String() == "" {
+		return
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(v.String()))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil || decoder.More() {
+		return
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return
+	}
+
+	v.SetString(strings.TrimSuffix(buf.String(), "\n"))
+}
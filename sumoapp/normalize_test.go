@@ -0,0 +1,179 @@
+package sumoapp
+
+import (
+	"testing"
+
+	"github.com/r3labs/diff"
+)
+
+func TestNormalizationRulesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   normalizationRules
+		wantErr bool
+	}{
+		{
+			name:  "default rules",
+			rules: *DefaultNormalizationRules(),
+		},
+		{
+			name:  "wildcard object type",
+			rules: normalizationRules{Ignore: []string{"*.Description"}},
+		},
+		{
+			name:    "unknown object type",
+			rules:   normalizationRules{Strip: []string{"widget.Id"}},
+			wantErr: true,
+		},
+		{
+			name:    "no field path",
+			rules:   normalizationRules{Canonicalize: []string{"panel"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	newOverlay := func() *appOverlay {
+		s := NewAppOverlay("base", NewApplication())
+		s.Panels["p"] = &panel{
+			Id:             "123",
+			Key:            "p",
+			Title:          "Errors",
+			VisualSettings: `{ "b": 1,  "a": {"d": 2, "c": 3} }`,
+		}
+		s.Variables["v"] = &variable{Id: "456", Name: "v"}
+		s.Dashboards["d"] = &dashboard{
+			Name: "d",
+			Layout: layout{
+				LayoutStructures: []layoutStructure{
+					{Key: "first", Structure: `{"y": 0, "x": 0}`},
+					{Key: "second", Structure: `{"y": 4, "x": 0}`},
+				},
+			},
+		}
+
+		return s
+	}
+
+	tests := []struct {
+		name  string
+		rules normalizationRules
+		check func(t *testing.T, s *appOverlay)
+	}{
+		{
+			name:  "default rules",
+			rules: *DefaultNormalizationRules(),
+			check: func(t *testing.T, s *appOverlay) {
+				if id := s.Panels["p"].Id; id != "" {
+					t.Errorf("panel Id = %q, want it stripped", id)
+				}
+				if id := s.Variables["v"].Id; id != "" {
+					t.Errorf("variable Id = %q, want it stripped", id)
+				}
+				if got, want := s.Panels["p"].VisualSettings, `{"a":{"c":3,"d":2},"b":1}`; got != want {
+					t.Errorf("VisualSettings = %s, want %s", got, want)
+				}
+				if got, want := s.Dashboards["d"].Layout.LayoutStructures[1].Structure, `{"x":0,"y":4}`; got != want {
+					t.Errorf("Structure = %s, want %s", got, want)
+				}
+			},
+		},
+		{
+			name:  "fields matched by JSON name and case",
+			rules: normalizationRules{Strip: []string{"panel.title", "panel.VISUALSETTINGS"}},
+			check: func(t *testing.T, s *appOverlay) {
+				if p := s.Panels["p"]; p.Title != "" || p.VisualSettings != "" {
+					t.Errorf("panel = %+v, want Title and VisualSettings stripped", p)
+				}
+				if s.Panels["p"].Id != "123" {
+					t.Errorf("panel Id was stripped by another rule's path")
+				}
+			},
+		},
+		{
+			name:  "list element by key",
+			rules: normalizationRules{Strip: []string{"dashboard.Layout.LayoutStructures.second.Structure"}},
+			check: func(t *testing.T, s *appOverlay) {
+				structures := s.Dashboards["d"].Layout.LayoutStructures
+				if structures[0].Structure == "" || structures[1].Structure != "" {
+					t.Errorf("LayoutStructures = %+v, want only the second structure stripped", structures)
+				}
+			},
+		},
+		{
+			name:  "list element by index",
+			rules: normalizationRules{Strip: []string{"dashboard.Layout.LayoutStructures.0.Structure"}},
+			check: func(t *testing.T, s *appOverlay) {
+				structures := s.Dashboards["d"].Layout.LayoutStructures
+				if structures[0].Structure != "" || structures[1].Structure == "" {
+					t.Errorf("LayoutStructures = %+v, want only the first structure stripped", structures)
+				}
+			},
+		},
+		{
+			name:  "wildcard object type",
+			rules: normalizationRules{Strip: []string{"*.Id"}},
+			check: func(t *testing.T, s *appOverlay) {
+				if s.Panels["p"].Id != "" || s.Variables["v"].Id != "" {
+					t.Errorf("Ids weren't stripped from every object type")
+				}
+			},
+		},
+		{
+			name:  "canonicalize leaves non JSON alone",
+			rules: normalizationRules{Canonicalize: []string{"panel.Title"}},
+			check: func(t *testing.T, s *appOverlay) {
+				if got := s.Panels["p"].Title; got != "Errors" {
+					t.Errorf("Title = %q, want it unchanged", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newOverlay()
+			s.Normalize(&tt.rules)
+			tt.check(t, s)
+		})
+	}
+}
+
+func TestNormalizationRulesIgnores(t *testing.T) {
+	rules := &normalizationRules{
+		Ignore: []string{"dashboard.Description", "*.Queries.*.QueryKey"},
+	}
+
+	tests := []struct {
+		name string
+		path []string
+		want bool
+	}{
+		{"ignored field", []string{"dashboard", "d", "Description"}, true},
+		{"field below an ignored field", []string{"dashboard", "d", "Description", "x"}, true},
+		{"case insensitive", []string{"dashboard", "d", "description"}, true},
+		{"other object type", []string{"panel", "p", "Description"}, false},
+		{"wildcard type and segment", []string{"panel", "p", "Queries", "A", "QueryKey"}, true},
+		{"other field", []string{"dashboard", "d", "Title"}, false},
+		{"whole object", []string{"dashboard", "d"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := diff.Change{Type: diff.UPDATE, Path: tt.path}
+			if got := rules.ignores(c); got != tt.want {
+				t.Errorf("ignores(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
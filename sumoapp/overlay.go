@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/imdario/mergo"
+	"github.com/r3labs/diff"
 	"gopkg.in/yaml.v2"
 )
 
@@ -23,6 +24,8 @@ func NewAppOverlay(name string, app *application) *appOverlay {
 		SavedSearches: make(map[string]*savedSearch),
 		Folders:       make(map[string]*folder),
 		Queries:       make(map[string]*query),
+		Monitors:      make(map[string]*monitor),
+		Parsers:       make(map[string]*parser),
 	}
 }
 
@@ -35,111 +38,208 @@ func (s *appOverlay) HasParent() bool {
 }
 
 func (s *appOverlay) Diff(diffOverlay *appOverlay) (changeSet, error) {
-	changelogVar, err := taggedDiff("variable", s.Variables, diffOverlay.Variables)
-	changelogPanel, err := taggedDiff("panel", s.Panels, diffOverlay.Panels)
-	changelogSavedSearches, err := taggedDiff("saved-search", s.SavedSearches, diffOverlay.SavedSearches)
-	changelogDashboard, err := taggedDiff("dashboard", s.Dashboards, diffOverlay.Dashboards)
-	changelogFolder, err := taggedDiff("folder", s.Folders, diffOverlay.Folders)
+	cs, err := s.Changes(diffOverlay)
 	if err != nil {
 		return changeSet{}, err
 	}
 
-	cs := changeSet{changelogVar, changelogPanel, changelogSavedSearches, changelogDashboard, changelogFolder}
+	displayDiff(os.Stdout, &cs)
 
-	displayDiff(&cs)
+	return cs, nil
+}
+
+// overlayObjects is one of an overlay's maps of components
+type overlayObjects struct {
+	objectType string
+	objects    interface{}
+}
+
+// objectLists returns the overlay's maps of components, tagged with
+// their object types
+func (s *appOverlay) objectLists() []overlayObjects {
+	return []overlayObjects{
+		{"variable", s.Variables},
+		{"panel", s.Panels},
+		{"saved-search", s.SavedSearches},
+		{"dashboard", s.Dashboards},
+		{"folder", s.Folders},
+		{"monitor", s.Monitors},
+		{"parser", s.Parsers},
+	}
+}
+
+// Changes compares the components of this overlay with the components of
+// diffOverlay and returns every difference without displaying them
+func (s *appOverlay) Changes(diffOverlay *appOverlay) (changeSet, error) {
+	var cs changeSet
+
+	diffs := []struct {
+		tag       string
+		a, b      interface{}
+		changelog *diff.Changelog
+	}{
+		{"variable", s.Variables, diffOverlay.Variables, &cs.ChangelogVar},
+		{"panel", s.Panels, diffOverlay.Panels, &cs.ChangelogPanel},
+		{"saved-search", s.SavedSearches, diffOverlay.SavedSearches, &cs.ChangelogSavedSearches},
+		{"dashboard", s.Dashboards, diffOverlay.Dashboards, &cs.ChangelogDashboard},
+		{"folder", folderItems(s.Folders), folderItems(diffOverlay.Folders), &cs.ChangelogFolder},
+		{"monitor", s.Monitors, diffOverlay.Monitors, &cs.ChangelogMonitor},
+		{"parser", s.Parsers, diffOverlay.Parsers, &cs.ChangelogParser},
+	}
+
+	for _, d := range diffs {
+		changelog, err := taggedDiff(d.tag, d.a, d.b)
+		if err != nil {
+			return changeSet{}, err
+		}
+
+		*d.changelog = changelog
+	}
+
+	//The root folder isn't one of the overlay's folders, so its
+	//permissions are compared on their own
+	if s.RootFolder != nil && diffOverlay.RootFolder != nil {
+		changelog, err := taggedDiff("folder",
+			map[string]*folder{RootFolderKey: {Permissions: s.RootFolder.Permissions}},
+			map[string]*folder{RootFolderKey: {Permissions: diffOverlay.RootFolder.Permissions}})
+		if err != nil {
+			return changeSet{}, err
+		}
+
+		cs.ChangelogFolder = append(cs.ChangelogFolder, changelog...)
+	}
+
+	if s.Application != nil {
+		cs.applyIgnoreRules(s.Application.NormalizationRules())
+	}
+
+	//Deleted objects are only in this overlay, so its containers are
+	//used for objects diffOverlay doesn't place anywhere
+	cs.containers = s.objectContainers()
+	for object, container := range diffOverlay.objectContainers() {
+		cs.containers[object] = container
+	}
 
 	return cs, nil
 }
 
+// folderItems returns copies of the folders without their children. A
+// folder's children are compared as objects of their own, and through the
+// folder's items
+func folderItems(folders map[string]*folder) map[string]*folder {
+	result := make(map[string]*folder, len(folders))
+	for name, f := range folders {
+		fold := f.Copy()
+		fold.Children = nil
+		result[name] = fold
+	}
+
+	return result
+}
+
+// ToJSON compiles the overlay into a build as if it were the top overlay.
+// The application holds the last loaded overlay's root folder, so the
+// build is made from this overlay's own
+func (s *appOverlay) ToJSON() ([]byte, error) {
+	app := *s.Application
+	app.Name = s.RootFolder.Name
+	app.Description = s.RootFolder.Description
+	app.Version = s.version
+	app.Children = s.RootFolder.Children
+	app.Permissions = s.RootFolder.Permissions
+	app.Monitors = s.MonitorsFolder()
+	app.Parsers = s.ParsersFolder()
+
+	return app.ToJSON()
+}
+
 func (s *appOverlay) WriteObjects() error {
 	//Write the folder objects to the app overlay
-	for fName, folderObj := range s.Folders {
+	for _, fName := range sortedKeys(s.Folders) {
+		folderObj := s.Folders[fName]
 		folderMap := make(map[string]*folder)
 		folderMap[fName] = folderObj
 
-		y, err := yaml.Marshal(folderMap)
-		if err != nil {
-			return err
-		}
-
 		filePath := fmt.Sprintf("%s/folders/%s.yaml", s.Path, fName)
-		if err := os.WriteFile(filePath, y, 0644); err != nil {
+		if err := writeYamlFile(filePath, folderMap); err != nil {
 			return err
 		}
 	}
 
 	//Write the dashboard objects to the app overlay
-	for dName, dashboardObj := range s.Dashboards {
+	for _, dName := range sortedKeys(s.Dashboards) {
+		dashboardObj := s.Dashboards[dName]
 		dashMap := make(map[string]*dashboard)
 		dashMap[dName] = dashboardObj
 
-		y, err := yaml.Marshal(dashMap)
-		if err != nil {
-			return err
-		}
-
 		filePath := fmt.Sprintf("%s/dashboards/%s.yaml", s.Path, dName)
-		if err := os.WriteFile(filePath, y, 0644); err != nil {
+		if err := writeYamlFile(filePath, dashMap); err != nil {
 			return err
 		}
 	}
 
 	//Write the panel objects to the app overlay
-	for pName, panelObj := range s.Panels {
+	for _, pName := range sortedKeys(s.Panels) {
+		panelObj := s.Panels[pName]
 		panelMap := make(map[string]*panel)
 		panelMap[pName] = panelObj
 
-		p, err := yaml.Marshal(panelMap)
-		if err != nil {
-			return err
-		}
-
 		filePath := fmt.Sprintf("%s/panels/%s.yaml", s.Path, pName)
-		if err := os.WriteFile(filePath, p, 0644); err != nil {
+		if err := writeYamlFile(filePath, panelMap); err != nil {
 			return err
 		}
 	}
 
 	//Write the variable objects to the app overlay
-	for vName, variableObj := range s.Variables {
+	for _, vName := range sortedKeys(s.Variables) {
+		variableObj := s.Variables[vName]
 		variableMap := make(map[string]*variable)
 		variableMap[vName] = variableObj
 
-		v, err := yaml.Marshal(variableMap)
-		if err != nil {
-			return err
-		}
-
 		filePath := fmt.Sprintf("%s/variables/%s.yaml", s.Path, vName)
-		if err := os.WriteFile(filePath, v, 0644); err != nil {
+		if err := writeYamlFile(filePath, variableMap); err != nil {
 			return err
 		}
 	}
 
 	//Write the saved search objects to the app overlay
-	for sName, searchObj := range s.SavedSearches {
+	for _, sName := range sortedKeys(s.SavedSearches) {
+		searchObj := s.SavedSearches[sName]
 		searchMap := make(map[string]*savedSearch)
 		searchMap[sName] = searchObj
 
-		v, err := yaml.Marshal(searchMap)
-		if err != nil {
+		filePath := fmt.Sprintf("%s/saved-searches/%s.yaml", s.Path, sName)
+		if err := writeYamlFile(filePath, searchMap); err != nil {
 			return err
 		}
+	}
 
-		filePath := fmt.Sprintf("%s/saved-searches/%s.yaml", s.Path, sName)
-		if err := os.WriteFile(filePath, v, 0644); err != nil {
+	//Write the monitor objects to the app overlay
+	for _, mName := range sortedKeys(s.Monitors) {
+		monitorObj := s.Monitors[mName]
+		monitorMap := make(map[string]*monitor)
+		monitorMap[mName] = monitorObj
+
+		filePath := fmt.Sprintf("%s/monitors/%s.yaml", s.Path, mName)
+		if err := writeYamlFile(filePath, monitorMap); err != nil {
 			return err
 		}
 	}
 
-	//Write the application's definition to the init file in the overlay
-	a, err := yaml.Marshal(s.Application)
-	if err != nil {
-		return err
+	//Write the parser objects to the app overlay
+	for _, pName := range sortedKeys(s.Parsers) {
+		parserMap := make(map[string]*parser)
+		parserMap[pName] = s.Parsers[pName]
+
+		filePath := fmt.Sprintf("%s/parsers/%s.yaml", s.Path, pName)
+		if err := writeYamlFile(filePath, parserMap); err != nil {
+			return err
+		}
 	}
 
+	//Write the application's definition to the init file in the overlay
 	filePath := fmt.Sprintf("%s/init.yaml", s.Path)
-	if err := os.WriteFile(filePath, a, 0644); err != nil {
+	if err := writeYamlFile(filePath, s.Application); err != nil {
 		return err
 	}
 
@@ -181,11 +281,27 @@ func (s *appOverlay) populateFolder(f *folder) error {
 	return nil
 }
 
+// readYamlFile decodes a component file into out. Decoding is strict so
+// misspelled or unknown fields are reported along with the file and line
+// they appear on instead of being silently dropped
+func readYamlFile(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Unable to read file %s: %w", path, err)
+	}
+
+	if err := yaml.UnmarshalStrict(data, out); err != nil {
+		return fmt.Errorf("Unable to decode %s: %w", path, err)
+	}
+
+	return nil
+}
+
 func (s *appOverlay) loadDashboards(basePath string) error {
 	dashboards := make(map[string]*dashboard)
 
 	dfiles, err := ioutil.ReadDir(basePath)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
@@ -198,13 +314,7 @@ func (s *appOverlay) loadDashboards(basePath string) error {
 			continue
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			err := fmt.Errorf("Unable to read file ", path, ": ", err)
-			return err
-		}
-
-		if err := yaml.Unmarshal(data, &curList); err != nil {
+		if err := readYamlFile(path, &curList); err != nil {
 			return err
 		}
 
@@ -223,9 +333,11 @@ func (s *appOverlay) loadDashboards(basePath string) error {
 		for name, pd := range s.Parent.Dashboards {
 			//If the parent overlay has a dashboard by the same name
 			//merge the current dashboard with its parent
+			//Inherited dashboards are copied, as they are populated with
+			//this overlay's panels and variables below
 			d, ok := s.Dashboards[name]
 			if !ok {
-				s.Dashboards[name] = pd
+				s.Dashboards[name] = pd.Copy()
 			} else {
 				if err := d.Merge(pd); err != nil {
 					return err
@@ -239,7 +351,8 @@ func (s *appOverlay) loadDashboards(basePath string) error {
 	//TODO: This should leverage go functions to parallelize the
 	//population of each dashboard. There's no reason for it to be
 	//serialized
-	for name, dash := range s.Dashboards {
+	for _, name := range sortedKeys(s.Dashboards) {
+		dash := s.Dashboards[name]
 		dash.key = name
 		dash.Type = DashboardType
 
@@ -249,7 +362,7 @@ func (s *appOverlay) loadDashboards(basePath string) error {
 		dash.Panels = make([]*panel, 0)
 		dash.Variables = make([]*variable, 0)
 
-		if dash.Populate(s); err != nil {
+		if err := dash.Populate(s); err != nil {
 			return err
 		}
 	}
@@ -261,7 +374,7 @@ func (s *appOverlay) loadVariables(basePath string) error {
 	variables := make(map[string]*variable)
 
 	files, err := ioutil.ReadDir(basePath)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
@@ -274,13 +387,7 @@ func (s *appOverlay) loadVariables(basePath string) error {
 			continue
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			err := fmt.Errorf("Unable to read file ", path, ": ", err)
-			return err
-		}
-
-		if err := yaml.Unmarshal(data, &curList); err != nil {
+		if err := readYamlFile(path, &curList); err != nil {
 			return err
 		}
 
@@ -324,7 +431,7 @@ func (s *appOverlay) loadPanels(basePath string) error {
 
 	//Load the panel files from this overlay's file system
 	files, err := ioutil.ReadDir(basePath)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
@@ -337,13 +444,7 @@ func (s *appOverlay) loadPanels(basePath string) error {
 			continue
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			err := fmt.Errorf("Unable to read file ", path, ": ", err)
-			return err
-		}
-
-		if err := yaml.Unmarshal(data, &curList); err != nil {
+		if err := readYamlFile(path, &curList); err != nil {
 			return err
 		}
 
@@ -382,6 +483,125 @@ func (s *appOverlay) loadPanels(basePath string) error {
 	return nil
 }
 
+func (s *appOverlay) loadMonitors(basePath string) error {
+	monitors := make(map[string]*monitor)
+
+	mfiles, err := ioutil.ReadDir(basePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, file := range mfiles {
+		var curList map[string]*monitor
+
+		path := fmt.Sprintf("%s/%s", basePath, file.Name())
+		extension := filepath.Ext(path)
+		if extension != ".yaml" {
+			continue
+		}
+
+		if err := readYamlFile(path, &curList); err != nil {
+			return err
+		}
+
+		if err := mergo.Merge(&monitors, curList); err != nil {
+			return err
+		}
+	}
+
+	s.Monitors = monitors
+
+	//Append the monitors defined in the parent overlay that are NOT
+	//overwritten in this overlay. Monitors that have overwrites in this
+	//overlay will be merged with their parent monitor and the new
+	//object will be added to this overlay's list of monitors
+	if s.HasParent() {
+		for name, pm := range s.Parent.Monitors {
+			m, ok := s.Monitors[name]
+			if !ok {
+				s.Monitors[name] = pm
+			} else {
+				if err := m.Merge(pm); err != nil {
+					return err
+				}
+
+				s.Monitors[name] = m
+			}
+		}
+	}
+
+	//Monitors are checked once they're merged, so an overlay only
+	//has to set the fields it changes
+	for _, name := range sortedKeys(s.Monitors) {
+		mon := s.Monitors[name]
+		mon.Type = MonitorType
+
+		if err := mon.Validate(); err != nil {
+			return fmt.Errorf("Invalid monitor '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *appOverlay) loadParsers(basePath string) error {
+	parsers := make(map[string]*parser)
+
+	pfiles, err := ioutil.ReadDir(basePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, file := range pfiles {
+		var curList map[string]*parser
+
+		path := fmt.Sprintf("%s/%s", basePath, file.Name())
+		extension := filepath.Ext(path)
+		if extension != ".yaml" {
+			continue
+		}
+
+		if err := readYamlFile(path, &curList); err != nil {
+			return err
+		}
+
+		if err := mergo.Merge(&parsers, curList); err != nil {
+			return err
+		}
+	}
+
+	s.Parsers = parsers
+
+	//Append the parsers defined in the parent overlay that are NOT
+	//overwritten in this overlay, and merge the ones that are with
+	//their parent parser
+	if s.HasParent() {
+		for name, pp := range s.Parent.Parsers {
+			p, ok := s.Parsers[name]
+			if !ok {
+				s.Parsers[name] = pp
+			} else {
+				if err := p.Merge(pp); err != nil {
+					return err
+				}
+
+				s.Parsers[name] = p
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(s.Parsers) {
+		par := s.Parsers[name]
+		par.Type = ParserType
+
+		if err := par.Validate(); err != nil {
+			return fmt.Errorf("Invalid parser '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
 func (s *appOverlay) FindDashboard(name string) (*dashboard, error) {
 	dash, ok := s.Dashboards[name]
 	if !ok {
@@ -433,15 +653,21 @@ func (s *appOverlay) FindPanel(name string) (*panel, error) {
 }
 
 func (s *appOverlay) loadRootFolder(appFilePath string) error {
-	var root folder
+	var definition application
 
-	data, err := os.ReadFile(appFilePath)
-	if err == nil {
-		if err := yaml.Unmarshal(data, &root); err != nil {
+	if _, err := os.Stat(appFilePath); err == nil {
+		if err := readYamlFile(appFilePath, &definition); err != nil {
 			return err
 		}
 	}
 
+	root := folder{
+		Name:        definition.Name,
+		Description: definition.Description,
+		Items:       definition.Items,
+		Permissions: definition.Permissions,
+	}
+
 	root.Type = FolderType
 
 	//Before the folder is populated, the items needs to be merged
@@ -451,11 +677,29 @@ func (s *appOverlay) loadRootFolder(appFilePath string) error {
 		if err != nil {
 			return err
 		}
+
+		if root.Name == "" {
+			root.Name = s.Parent.RootFolder.Name
+		}
+
+		if root.Description == "" {
+			root.Description = s.Parent.RootFolder.Description
+		}
+
+		if definition.Version == "" {
+			definition.Version = s.Parent.version
+		}
+
+		//Overlays only replace the root folder's permissions as a whole
+		if len(root.Permissions) == 0 {
+			root.Permissions = s.Parent.RootFolder.Permissions
+		}
 	}
 
 	s.populateFolder(&root)
 
 	s.RootFolder = &root
+	s.version = definition.Version
 
 	if root.Description != "" {
 		s.Application.Description = root.Description
@@ -465,8 +709,17 @@ func (s *appOverlay) loadRootFolder(appFilePath string) error {
 		s.Application.Name = root.Name
 	}
 
-	//Update the application's children to be this overlay's children
+	if definition.Version != "" {
+		s.Application.Version = definition.Version
+	}
+
+	s.Application.Permissions = root.Permissions
+
+	//Update the application's children, monitors, and parsers to be this
+	//overlay's
 	s.Application.Children = root.Children
+	s.Application.Monitors = s.MonitorsFolder()
+	s.Application.Parsers = s.ParsersFolder()
 
 	return nil
 }
@@ -475,7 +728,7 @@ func (s *appOverlay) loadFolders(basePath string) error {
 	folders := make(map[string]*folder)
 
 	ffiles, err := ioutil.ReadDir(basePath)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
@@ -488,13 +741,7 @@ func (s *appOverlay) loadFolders(basePath string) error {
 			continue
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			err := fmt.Errorf("Unable to read file ", path, ": ", err)
-			return err
-		}
-
-		if err := yaml.Unmarshal(data, &curList); err != nil {
+		if err := readYamlFile(path, &curList); err != nil {
 			return err
 		}
 
@@ -513,9 +760,11 @@ func (s *appOverlay) loadFolders(basePath string) error {
 		for name, pf := range s.Parent.Folders {
 			//If the parent overlay has a folder by the same name
 			//merge the current folder with its parent
+			//Inherited folders are copied, as they are populated with
+			//this overlay's children when the root folder loads
 			f, ok := s.Folders[name]
 			if !ok {
-				s.Folders[name] = pf
+				s.Folders[name] = pf.Copy()
 			} else {
 				if err := f.Merge(pf); err != nil {
 					return err
@@ -539,7 +788,7 @@ func (s *appOverlay) loadSavedSearches(basePath string) error {
 	searches := make(map[string]*savedSearch)
 
 	sfiles, err := ioutil.ReadDir(basePath)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
@@ -552,13 +801,7 @@ func (s *appOverlay) loadSavedSearches(basePath string) error {
 			continue
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			err := fmt.Errorf("Unable to read file ", path, ": ", err)
-			return err
-		}
-
-		if err := yaml.Unmarshal(data, &curList); err != nil {
+		if err := readYamlFile(path, &curList); err != nil {
 			return err
 		}
 
@@ -592,7 +835,7 @@ func (s *appOverlay) loadSavedSearches(basePath string) error {
 
 	//Ensure all the search objects are annotated properly.
 	//There might be a more efficient way to do this
-	for _, search := range s.Folders {
+	for _, search := range s.SavedSearches {
 		search.Type = SavedSearchType
 	}
 
@@ -607,6 +850,8 @@ func (s *appOverlay) Load() error {
 	folderBasePath := fmt.Sprintf("%s/folders", s.Path)
 	variableBasePath := fmt.Sprintf("%s/variables", s.Path)
 	searchesBasePath := fmt.Sprintf("%s/saved-searches", s.Path)
+	monitorsBasePath := fmt.Sprintf("%s/monitors", s.Path)
+	parsersBasePath := fmt.Sprintf("%s/parsers", s.Path)
 
 	//It's important the components be loaded in
 	//the correct order. Variables and panels should
@@ -645,6 +890,18 @@ func (s *appOverlay) Load() error {
 		return err
 	}
 
+	err = s.loadMonitors(monitorsBasePath)
+	if err != nil {
+		err := fmt.Errorf("Could not load monitors at %s: %w", monitorsBasePath, err)
+		return err
+	}
+
+	err = s.loadParsers(parsersBasePath)
+	if err != nil {
+		err := fmt.Errorf("Could not load parsers at %s: %w", parsersBasePath, err)
+		return err
+	}
+
 	rootPath := fmt.Sprintf("%s/init.yaml", s.Path)
 	err = s.loadRootFolder(rootPath)
 	if err != nil {
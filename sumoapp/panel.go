@@ -33,5 +33,9 @@ func (p *panel) Merge(pan *panel) error {
 func (p *panel) Copy() *panel {
 	pan := &panel{}
 	mergo.Merge(pan, p)
+
+	//mergo copies pointers, so the time range needs its own copy
+	pan.TimeRange = p.TimeRange.Copy()
+
 	return pan
 }
@@ -0,0 +1,261 @@
+package sumoapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/imdario/mergo"
+)
+
+// contentJobPollInterval is how long to wait between checks of a content
+// import job
+var contentJobPollInterval = time.Second
+
+func (p *parser) Merge(par *parser) error {
+	newParser := par.Copy()
+
+	if err := mergo.Merge(newParser, p, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	if err := mergo.Merge(p, newParser, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *parser) Copy() *parser {
+	par := *p
+	return &par
+}
+
+// Validate checks the parser has a name and that its code is made of
+// stanzas with KEY = value settings, one of which sets the FORMAT
+func (p *parser) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("The parser has no name")
+	}
+
+	if p.Code == "" {
+		return fmt.Errorf("The parser has no code")
+	}
+
+	config, err := parseParserCode(p.Code)
+	if err != nil {
+		return err
+	}
+
+	if _, err := config.format(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ParsersFolder returns the overlay's parsers in a folder named after the
+// application, in the format the Content Management API imports. Parsers
+// are sorted by key so the same parsers always produce the same folder. It
+// returns nil when the overlay has no parsers
+func (s *appOverlay) ParsersFolder() *parserFolder {
+	if len(s.Parsers) == 0 {
+		return nil
+	}
+
+	root := &parserFolder{
+		Type:        ParserFolderType,
+		Name:        s.RootFolder.Name,
+		Description: s.RootFolder.Description,
+		Children:    make([]*parser, 0, len(s.Parsers)),
+	}
+
+	for _, key := range sortedKeys(s.Parsers) {
+		p := *s.Parsers[key]
+		p.Type = ParserType
+		root.Children = append(root.Children, &p)
+	}
+
+	return root
+}
+
+// parserExportItem is a folder or parser exported from the Parsers
+// library. Only folders have children
+type parserExportItem struct {
+	parser
+	Children []json.RawMessage `json:"children"`
+}
+
+// importParsersExport adds the parsers of an exported parsers folder, or a
+// single exported parser, to the overlay. Parsers in subfolders are added
+// too, as the application's parsers are kept in a single folder
+func (a *application) importParsersExport(data []byte, overlay *appOverlay) error {
+	if err := importParserItem(data, overlay); err != nil {
+		return err
+	}
+
+	overlay.Normalize(a.NormalizationRules())
+
+	return nil
+}
+
+// importParserItem adds an exported parser, or the parsers of an exported
+// folder and its subfolders, to the overlay
+func importParserItem(data []byte, overlay *appOverlay) error {
+	var item parserExportItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+
+	switch item.Type {
+	case ParserType:
+		importParser(&item.parser, overlay)
+	case ParserFolderType:
+		for _, child := range item.Children {
+			if err := importParserItem(child, overlay); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("Unknown parsers library type: %s", item.Type)
+	}
+
+	return nil
+}
+
+func importParser(p *parser, overlay *appOverlay) {
+	par := p.Copy()
+	par.Type = ""
+	overlay.Parsers[sanitizeName(par.Name)] = par
+}
+
+// ReadBuildParsers returns the parsers folder of a build, or nil if the
+// build has no parsers
+func ReadBuildParsers(build []byte) (*parserFolder, error) {
+	var b struct {
+		Parsers *parserFolder `json:"parsers"`
+	}
+
+	if err := json.Unmarshal(build, &b); err != nil {
+		return nil, err
+	}
+
+	return b.Parsers, nil
+}
+
+// contentImportStatus is the status of a Content Management API import job
+type contentImportStatus struct {
+	Status        string `json:"status"`
+	StatusMessage string `json:"statusMessage"`
+	Error         *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Upload imports the folder into the folder of the Parsers library with
+// folderId, replacing a folder of the same name, and waits for the import
+// to finish
+func (f *parserFolder) Upload(ctx context.Context, a *APIClient, folderId string) error {
+	query := url.Values{}
+	query.Add("overwrite", "true")
+
+	var job asyncAPIContent
+	path := fmt.Sprintf("/v2/content/folders/%s/import", folderId)
+	if err := a.requestJSON(ctx, "POST", path, query, f, &job); err != nil {
+		return err
+	}
+
+	return waitForContentImport(ctx, a, folderId, job.Id, "the parsers")
+}
+
+// waitForContentImport polls an import job of the Content Management API
+// into the folder with folderId until it's done. What names the imported
+// content in errors
+func waitForContentImport(ctx context.Context, a *APIClient, folderId string, jobId string, what string) error {
+	path := fmt.Sprintf("/v2/content/folders/%s/import/%s/status", folderId, jobId)
+
+	for {
+		var status contentImportStatus
+		if err := a.requestJSON(ctx, "GET", path, nil, nil, &status); err != nil {
+			return err
+		}
+
+		switch status.Status {
+		case "Success":
+			return nil
+		case "Failed":
+			if status.Error != nil {
+				return fmt.Errorf("Importing %s failed: %s", what, status.Error.Message)
+			}
+			return fmt.Errorf("Importing %s failed: %s", what, status.StatusMessage)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(contentJobPollInterval):
+		}
+	}
+}
+
+// ReadParserFile reads the parser defined in a parser component file. The
+// file must define exactly one parser
+func ReadParserFile(path string) (*parser, error) {
+	var parsers map[string]*parser
+	if err := readYamlFile(path, &parsers); err != nil {
+		return nil, err
+	}
+
+	if len(parsers) != 1 {
+		return nil, fmt.Errorf("Expected one parser in %s, found %d", path, len(parsers))
+	}
+
+	for key, p := range parsers {
+		if p == nil {
+			return nil, fmt.Errorf("Parser '%s' in %s is empty", key, path)
+		}
+
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid parser '%s': %w", key, err)
+		}
+
+		return p, nil
+	}
+
+	return nil, nil
+}
+
+// FindParser returns the overlay's parser with the given key
+func (s *appOverlay) FindParser(key string) (*parser, error) {
+	p, ok := s.Parsers[key]
+	if !ok {
+		return nil, fmt.Errorf("Could not find parser '%s'", key)
+	}
+
+	return p, nil
+}
+
+// LoadParser returns the parser in a parser's YAML file, when spec is the
+// path to one, or else the parser with key spec in the app overlay of the
+// application at appPath
+func LoadParser(appPath string, overlayName string, spec string) (*parser, error) {
+	if ext := filepath.Ext(spec); ext == ".yaml" || ext == ".yml" {
+		return ReadParserFile(spec)
+	}
+
+	app := NewApplicationWithPath(appPath)
+	if err := app.LoadAppOverlays(); err != nil {
+		return nil, err
+	}
+
+	overlay, err := app.FindAppOverlay(overlayName)
+	if err != nil {
+		return nil, err
+	}
+
+	return overlay.FindParser(spec)
+}
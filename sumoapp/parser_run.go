@@ -0,0 +1,435 @@
+package sumoapp
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parser formats that can be tested locally
+const (
+	ParserFormatJSON  = "JSON"
+	ParserFormatRegex = "REGEX"
+	ParserFormatCSV   = "CSV"
+	ParserFormatKV    = "KEY-VALUE"
+)
+
+// parserDefaultStanza is the stanza whose settings apply to every message
+const parserDefaultStanza = "Default"
+
+// parserStanza is a [name] section of a parser's code and its settings.
+// Setting names are upper case
+type parserStanza struct {
+	Name     string
+	Settings map[string]string
+}
+
+type parserConfig struct {
+	Stanzas []*parserStanza
+}
+
+// parserCodeLine matches a KEY = value setting. Values can contain "=",
+// so only the first one separates the key
+var parserCodeLine = regexp.MustCompile(`^([A-Za-z0-9_]+)\s*=\s*(.*)$`)
+
+// javaNamedGroup matches the start of a (?<name>...) group, the syntax
+// parsers use for named groups
+var javaNamedGroup = regexp.MustCompile(`\(\?<([A-Za-z][A-Za-z0-9_]*)>`)
+
+// parseParserCode reads the stanzas of a parser's code. Blank lines and
+// lines starting with # are skipped
+func parseParserCode(code string) (*parserConfig, error) {
+	config := &parserConfig{}
+
+	var stanza *parserStanza
+	for i, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			stanza = &parserStanza{
+				Name:     strings.TrimSpace(line[1 : len(line)-1]),
+				Settings: make(map[string]string),
+			}
+			config.Stanzas = append(config.Stanzas, stanza)
+			continue
+		}
+
+		m := parserCodeLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("Line %d of the parser code is neither a [stanza] nor a KEY = value setting: %s", i+1, line)
+		}
+
+		if stanza == nil {
+			return nil, fmt.Errorf("Line %d of the parser code sets %s outside of a [stanza]", i+1, m[1])
+		}
+
+		stanza.Settings[strings.ToUpper(m[1])] = m[2]
+	}
+
+	if len(config.Stanzas) == 0 {
+		return nil, fmt.Errorf("The parser code has no [stanza]")
+	}
+
+	return config, nil
+}
+
+// main returns the [Default] stanza, or the first stanza if there's none
+func (c *parserConfig) main() *parserStanza {
+	for _, s := range c.Stanzas {
+		if s.Name == parserDefaultStanza {
+			return s
+		}
+	}
+
+	return c.Stanzas[0]
+}
+
+// format returns the FORMAT of the parser's main stanza, in upper case
+func (c *parserConfig) format() (string, error) {
+	format := strings.ToUpper(c.main().Settings["FORMAT"])
+	if format == "" {
+		return "", fmt.Errorf("The [%s] stanza of the parser code doesn't set a FORMAT", c.main().Name)
+	}
+
+	return format, nil
+}
+
+// parserLineResult is the outcome of parsing one sample line
+type parserLineResult struct {
+	Line   int               `json:"line"`
+	Fields map[string]string `json:"fields,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// parserTestReport holds the fields a parser extracted from each line of
+// a sample log file
+type parserTestReport struct {
+	Parser  string              `json:"parser"`
+	Format  string              `json:"format"`
+	Results []*parserLineResult `json:"results"`
+}
+
+// maxSampleLineLength is the longest line of a sample log file
+const maxSampleLineLength = 1024 * 1024
+
+// lineParser extracts the fields of a message
+type lineParser func(message string) (map[string]string, error)
+
+// TestParser parses every non-blank line of sample with the parser and
+// reports the fields extracted from each. Parsers are run locally, so only
+// the JSON, REGEX, CSV, and KEY-VALUE formats of the main stanza are
+// supported. Time parsing, mappings, and transforms aren't applied
+func TestParser(p *parser, sample io.Reader) (*parserTestReport, error) {
+	config, err := parseParserCode(p.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := config.format()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := config.main().Settings
+
+	var parse lineParser
+	switch format {
+	case ParserFormatJSON:
+		parse = parseJSONMessage
+	case ParserFormatRegex:
+		parse, err = newRegexParser(settings)
+	case ParserFormatCSV:
+		parse, err = newCSVParser(settings)
+	case ParserFormatKV, "KV", "KEYVALUE":
+		parse, err = newKVParser(settings)
+	default:
+		return nil, fmt.Errorf("Parsers with FORMAT = %s can't be tested locally. Supported formats are: %s", format, strings.Join([]string{ParserFormatJSON, ParserFormatRegex, ParserFormatCSV, ParserFormatKV}, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &parserTestReport{
+		Parser:  p.Name,
+		Format:  format,
+		Results: make([]*parserLineResult, 0),
+	}
+
+	scanner := bufio.NewScanner(sample)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSampleLineLength)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		result := &parserLineResult{Line: lineNumber}
+		fields, err := parse(line)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Fields = fields
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Parsed returns how many lines the parser extracted fields from
+func (r *parserTestReport) Parsed() int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Error == "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Display writes the fields extracted from each line, sorted by name,
+// followed by a summary
+func (r *parserTestReport) Display(w io.Writer) {
+	for _, result := range r.Results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "%sLine %d: %s%s\n", ColorRed, result.Line, result.Error, ColorReset)
+			continue
+		}
+
+		fmt.Fprintf(w, "Line %d: %d field(s)\n", result.Line, len(result.Fields))
+		for _, name := range sortedKeys(result.Fields) {
+			fmt.Fprintf(w, "  %s = %s\n", name, result.Fields[name])
+		}
+	}
+
+	fmt.Fprintf(w, "\nParsed %d of %d line(s) with %s (FORMAT = %s)\n", r.Parsed(), len(r.Results), r.Parser, r.Format)
+}
+
+func (r *parserTestReport) ToJSON() ([]byte, error) {
+	return canonicalJSON(r)
+}
+
+// parseJSONMessage extracts the fields of a JSON object. Nested objects
+// are flattened into dotted names and arrays into indexed names, such as
+// user.roles[0]
+func parseJSONMessage(message string) (map[string]string, error) {
+	decoder := json.NewDecoder(strings.NewReader(message))
+	decoder.UseNumber()
+
+	var obj map[string]interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("not a JSON object: %s", err)
+	}
+
+	fields := make(map[string]string)
+	flattenJSON("", obj, fields)
+
+	return fields, nil
+}
+
+func flattenJSON(prefix string, value interface{}, fields map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flattenJSON(name, child, fields)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenJSON(fmt.Sprintf("%s[%d]", prefix, i), child, fields)
+		}
+	case nil:
+		fields[prefix] = ""
+	case string:
+		fields[prefix] = v
+	default:
+		fields[prefix] = fmt.Sprint(v)
+	}
+}
+
+// newRegexParser returns a parser for the named groups of the REGEX
+// setting. Groups can be named with either (?<name>) or (?P<name>)
+func newRegexParser(settings map[string]string) (lineParser, error) {
+	expr, ok := settings["REGEX"]
+	if !ok || expr == "" {
+		return nil, fmt.Errorf("FORMAT = %s needs a REGEX setting", ParserFormatRegex)
+	}
+
+	re, err := regexp.Compile(javaNamedGroup.ReplaceAllString(expr, "(?P<$1>"))
+	if err != nil {
+		return nil, fmt.Errorf("The REGEX can't be tested locally: %w", err)
+	}
+
+	names := make([]string, 0)
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("The REGEX has no named groups, so it doesn't extract any fields")
+	}
+
+	return func(message string) (map[string]string, error) {
+		m := re.FindStringSubmatch(message)
+		if m == nil {
+			return nil, fmt.Errorf("the REGEX doesn't match")
+		}
+
+		fields := make(map[string]string)
+		for i, name := range re.SubexpNames() {
+			if name != "" {
+				fields[name] = m[i]
+			}
+		}
+
+		return fields, nil
+	}, nil
+}
+
+// newCSVParser returns a parser that names the values of a delimited line
+// after the comma separated FIELDS setting. DELIMITER defaults to a comma
+func newCSVParser(settings map[string]string) (lineParser, error) {
+	names := make([]string, 0)
+	for _, name := range strings.Split(settings["FIELDS"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("FORMAT = %s needs a FIELDS setting that lists the field names", ParserFormatCSV)
+	}
+
+	delimiter, err := parserDelimiter(settings, "DELIMITER", ",")
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(delimiter)
+	if len(runes) != 1 {
+		return nil, fmt.Errorf("The DELIMITER of a %s parser must be a single character", ParserFormatCSV)
+	}
+
+	return func(message string) (map[string]string, error) {
+		reader := csv.NewReader(strings.NewReader(message))
+		reader.Comma = runes[0]
+		reader.LazyQuotes = true
+		reader.FieldsPerRecord = -1
+
+		values, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("not a delimited line: %s", err)
+		}
+
+		if len(values) != len(names) {
+			return nil, fmt.Errorf("expected %d value(s) for FIELDS, found %d", len(names), len(values))
+		}
+
+		fields := make(map[string]string)
+		for i, name := range names {
+			fields[name] = values[i]
+		}
+
+		return fields, nil
+	}, nil
+}
+
+// newKVParser returns a parser for key=value pairs. KV_SEPARATOR splits
+// the pairs and defaults to a space, KV_DELIMITER splits a key from its
+// value and defaults to "=". Quoted values can contain the separator
+func newKVParser(settings map[string]string) (lineParser, error) {
+	separator, err := parserDelimiter(settings, "KV_SEPARATOR", " ")
+	if err != nil {
+		return nil, err
+	}
+
+	delimiter, err := parserDelimiter(settings, "KV_DELIMITER", "=")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(message string) (map[string]string, error) {
+		fields := make(map[string]string)
+
+		for _, pair := range splitQuoted(message, separator) {
+			parts := strings.SplitN(pair, delimiter, 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+				continue
+			}
+
+			value := strings.TrimSpace(parts[1])
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+
+			fields[strings.TrimSpace(parts[0])] = value
+		}
+
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("no key%svalue pairs found", delimiter)
+		}
+
+		return fields, nil
+	}, nil
+}
+
+// parserDelimiter returns the setting with the given name. Quoted values
+// are unquoted, so a delimiter can be a space or "\t"
+func parserDelimiter(settings map[string]string, name string, defaultValue string) (string, error) {
+	value, ok := settings[name]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+
+	if value == "" {
+		return "", fmt.Errorf("%s can't be empty", name)
+	}
+
+	return value, nil
+}
+
+// splitQuoted splits s around separator, except inside double quotes
+func splitQuoted(s string, separator string) []string {
+	parts := make([]string, 0)
+
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"' && (i == 0 || s[i-1] != '\\'):
+			inQuotes = !inQuotes
+		case !inQuotes && strings.HasPrefix(s[i:], separator):
+			parts = append(parts, s[start:i])
+			start = i + len(separator)
+			i += len(separator) - 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
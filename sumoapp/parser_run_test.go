@@ -0,0 +1,271 @@
+package sumoapp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseParserCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		wantFormat string
+		wantErr    bool
+	}{
+		{
+			name:       "default stanza",
+			code:       "[Other]\nFORMAT = csv\n\n# comment\n[Default]\nformat = Key-Value\nKV_DELIMITER = =\n",
+			wantFormat: ParserFormatKV,
+		},
+		{
+			name:       "first stanza without a default",
+			code:       "[Access]\nFORMAT = regex\nREGEX = a=(?<a>\\d+)\n",
+			wantFormat: ParserFormatRegex,
+		},
+		{
+			name:    "no format",
+			code:    "[Default]\nFIELDS = a,b\n",
+			wantErr: true,
+		},
+		{
+			name:    "setting outside of a stanza",
+			code:    "FORMAT = JSON\n[Default]\n",
+			wantErr: true,
+		},
+		{
+			name:    "not a setting",
+			code:    "[Default]\nFORMAT JSON\n",
+			wantErr: true,
+		},
+		{
+			name:    "no stanza",
+			code:    "# nothing\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseParserCode(tt.code)
+			if err == nil {
+				var format string
+				format, err = config.format()
+				if format != tt.wantFormat {
+					t.Errorf("format() = %q, want %q", format, tt.wantFormat)
+				}
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	config, err := parseParserCode("[Default]\nKV_DELIMITER = =\nREGEX = a=(b)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"KV_DELIMITER": "=", "REGEX": "a=(b)"}
+	if got := config.main().Settings; !reflect.DeepEqual(got, want) {
+		t.Errorf("Settings = %v, want values split at the first =: %v", got, want)
+	}
+}
+
+func TestLineParsers(t *testing.T) {
+	tests := []struct {
+		name       string
+		newParser  func(map[string]string) (lineParser, error)
+		settings   map[string]string
+		message    string
+		want       map[string]string
+		wantErr    bool
+		wantNewErr bool
+	}{
+		{
+			name:      "regex with Java named groups",
+			newParser: newRegexParser,
+			settings:  map[string]string{"REGEX": `^(?<ip>\S+) .* (?P<status>\d{3})$`},
+			message:   "10.0.0.1 GET /index.html 200",
+			want:      map[string]string{"ip": "10.0.0.1", "status": "200"},
+		},
+		{
+			name:      "regex that doesn't match",
+			newParser: newRegexParser,
+			settings:  map[string]string{"REGEX": `^(?<status>\d{3})$`},
+			message:   "GET",
+			wantErr:   true,
+		},
+		{
+			name:       "regex without named groups",
+			newParser:  newRegexParser,
+			settings:   map[string]string{"REGEX": `^(\d+)$`},
+			wantNewErr: true,
+		},
+		{
+			name:       "regex without a REGEX",
+			newParser:  newRegexParser,
+			settings:   map[string]string{},
+			wantNewErr: true,
+		},
+		{
+			name:       "regex Go can't compile",
+			newParser:  newRegexParser,
+			settings:   map[string]string{"REGEX": `(?<a>x)(?=y)`},
+			wantNewErr: true,
+		},
+		{
+			name:      "csv",
+			newParser: newCSVParser,
+			settings:  map[string]string{"FIELDS": "time, level ,message"},
+			message:   `2026-10-19,ERROR,"disk full, retrying"`,
+			want:      map[string]string{"time": "2026-10-19", "level": "ERROR", "message": "disk full, retrying"},
+		},
+		{
+			name:      "csv with a quoted tab delimiter",
+			newParser: newCSVParser,
+			settings:  map[string]string{"FIELDS": "a,b", "DELIMITER": `"\t"`},
+			message:   "1\t2",
+			want:      map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name:      "csv with too few values",
+			newParser: newCSVParser,
+			settings:  map[string]string{"FIELDS": "a,b,c"},
+			message:   "1,2",
+			wantErr:   true,
+		},
+		{
+			name:       "csv without FIELDS",
+			newParser:  newCSVParser,
+			settings:   map[string]string{"FIELDS": " , "},
+			wantNewErr: true,
+		},
+		{
+			name:       "csv with a long delimiter",
+			newParser:  newCSVParser,
+			settings:   map[string]string{"FIELDS": "a", "DELIMITER": "::"},
+			wantNewErr: true,
+		},
+		{
+			name:      "key value",
+			newParser: newKVParser,
+			settings:  map[string]string{},
+			message:   `user=alice  msg="login failed" novalue =skipped`,
+			want:      map[string]string{"user": "alice", "msg": "login failed"},
+		},
+		{
+			name:      "key value with custom separators",
+			newParser: newKVParser,
+			settings:  map[string]string{"KV_SEPARATOR": ";", "KV_DELIMITER": ":"},
+			message:   "a:1; b: 2",
+			want:      map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name:      "key value without pairs",
+			newParser: newKVParser,
+			settings:  map[string]string{},
+			message:   "no pairs here",
+			wantErr:   true,
+		},
+		{
+			name:       "key value with an empty separator",
+			newParser:  newKVParser,
+			settings:   map[string]string{"KV_SEPARATOR": `""`},
+			wantNewErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parse, err := tt.newParser(tt.settings)
+			if (err != nil) != tt.wantNewErr {
+				t.Fatalf("new parser error = %v, wantErr %v", err, tt.wantNewErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got, err := parse(tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse(%q) error = %v, wantErr %v", tt.message, err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse(%q) = %v, want %v", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONMessage(t *testing.T) {
+	got, err := parseJSONMessage(`{"level": "info", "took": 12.50, "user": {"id": 7, "roles": ["admin", null]}, "ok": true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"level":         "info",
+		"took":          "12.50",
+		"user.id":       "7",
+		"user.roles[0]": "admin",
+		"user.roles[1]": "",
+		"ok":            "true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSONMessage() = %v, want %v", got, want)
+	}
+
+	for _, message := range []string{`["a"]`, `level=info`} {
+		if _, err := parseJSONMessage(message); err == nil {
+			t.Errorf("parseJSONMessage(%q) should fail", message)
+		}
+	}
+}
+
+func TestSplitQuoted(t *testing.T) {
+	tests := []struct {
+		s         string
+		separator string
+		want      []string
+	}{
+		{"a b c", " ", []string{"a", "b", "c"}},
+		{`a="x y" b=1`, " ", []string{`a="x y"`, "b=1"}},
+		{`a="x \" y" b`, " ", []string{`a="x \" y"`, "b"}},
+		{"a, b,, c", ", ", []string{"a", "b,", "c"}},
+		{"", " ", []string{""}},
+	}
+
+	for _, tt := range tests {
+		if got := splitQuoted(tt.s, tt.separator); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitQuoted(%q, %q) = %q, want %q", tt.s, tt.separator, got, tt.want)
+		}
+	}
+}
+
+func TestTestParser(t *testing.T) {
+	p := &parser{
+		Name: "access",
+		Code: "[Default]\nFORMAT = CSV\nFIELDS = a,b\n",
+	}
+
+	report, err := TestParser(p, strings.NewReader("1,2\r\n\n3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Results) != 2 || report.Parsed() != 1 {
+		t.Fatalf("Results = %+v, want the blank line skipped and one line parsed", report.Results)
+	}
+
+	if report.Results[0].Fields["b"] != "2" || report.Results[1].Line != 3 || report.Results[1].Error == "" {
+		t.Errorf("Results = %+v, %+v", report.Results[0], report.Results[1])
+	}
+
+	p.Code = "[Default]\nFORMAT = XML\n"
+	if _, err := TestParser(p, strings.NewReader("<a/>")); err == nil {
+		t.Errorf("TestParser() should fail for formats that can't be run locally")
+	}
+}
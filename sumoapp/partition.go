@@ -0,0 +1,235 @@
+package sumoapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Analytics tiers of a partition
+const (
+	PartitionTierContinuous = "continuous"
+	PartitionTierFrequent   = "frequent"
+	PartitionTierInfrequent = "infrequent"
+)
+
+// partitionsPageSize is the largest page the Partitions API returns
+const partitionsPageSize = 1000
+
+// partition is a partition of the org's log data, which queries search
+// with _index=name. Partitions are matched with the partitions in the org
+// by name. The analytics tier defaults to continuous
+type partition struct {
+	Id                string `json:"id,omitempty" yaml:"-" diff:"-"`
+	Name              string `json:"name"`
+	RoutingExpression string `json:"routingExpression"`
+	AnalyticsTier     string `json:"analyticsTier,omitempty" yaml:",omitempty"`
+	RetentionPeriod   int    `json:"retentionPeriod"`
+	IsCompliant       bool   `json:"isCompliant" yaml:",omitempty"`
+	DataForwardingId  string `json:"dataForwardingId,omitempty" yaml:",omitempty"`
+}
+
+type partitionPage struct {
+	Data []struct {
+		partition
+		IsActive bool `json:"isActive"`
+	} `json:"data"`
+	Next string `json:"next"`
+}
+
+// ReadPartitions reads the partition definitions in a YAML file, or in
+// every YAML file of a directory. Files map a key to each partition. The
+// partitions are returned keyed by name
+func ReadPartitions(path string) (map[string]*partition, error) {
+	files, err := definitionFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make(map[string]*partition)
+	keys := make(map[string]string)
+
+	for _, file := range files {
+		var curList map[string]*partition
+		if err := readYamlFile(file, &curList); err != nil {
+			return nil, err
+		}
+
+		for _, key := range sortedKeys(curList) {
+			p := curList[key]
+			if p == nil {
+				return nil, fmt.Errorf("Partition '%s' in %s is empty", key, file)
+			}
+
+			if other, ok := keys[key]; ok {
+				return nil, fmt.Errorf("Partition '%s' is defined in both %s and %s", key, other, file)
+			}
+			keys[key] = file
+
+			if p.AnalyticsTier == "" {
+				p.AnalyticsTier = PartitionTierContinuous
+			}
+			p.AnalyticsTier = strings.ToLower(p.AnalyticsTier)
+
+			if err := p.Validate(); err != nil {
+				return nil, fmt.Errorf("Invalid partition '%s' in %s: %w", key, file, err)
+			}
+
+			if _, ok := partitions[p.Name]; ok {
+				return nil, fmt.Errorf("More than one partition is named '%s'. Partitions are matched by name, so names must be unique", p.Name)
+			}
+
+			partitions[p.Name] = p
+		}
+	}
+
+	return partitions, nil
+}
+
+// Validate checks the partition has everything the Partitions API needs
+func (p *partition) Validate() error {
+	tiers := []string{PartitionTierContinuous, PartitionTierFrequent, PartitionTierInfrequent}
+
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("The partition has no name")
+	case p.RoutingExpression == "":
+		return fmt.Errorf("The partition has no routing expression")
+	case p.RetentionPeriod <= 0:
+		return fmt.Errorf("The partition's retention period must be a positive number of days")
+	case !containsString(tiers, p.AnalyticsTier):
+		return fmt.Errorf("Unknown analytics tier '%s'. Expected one of: %s", p.AnalyticsTier, strings.Join(tiers, ", "))
+	}
+
+	return nil
+}
+
+// ListPartitions returns the org's active partitions, keyed by name.
+// Decommissioned partitions are left out
+func ListPartitions(ctx context.Context, a *APIClient) (map[string]*partition, error) {
+	partitions := make(map[string]*partition)
+
+	token := ""
+	for {
+		query := url.Values{}
+		query.Add("limit", strconv.Itoa(partitionsPageSize))
+		if token != "" {
+			query.Add("token", token)
+		}
+
+		var page partitionPage
+		if err := a.requestJSON(ctx, "GET", "/v1/partitions", query, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for i := range page.Data {
+			if !page.Data[i].IsActive {
+				continue
+			}
+
+			p := page.Data[i].partition
+			p.AnalyticsTier = strings.ToLower(p.AnalyticsTier)
+			partitions[p.Name] = &p
+		}
+
+		if page.Next == "" {
+			return partitions, nil
+		}
+		token = page.Next
+	}
+}
+
+// WritePartitions writes each partition to its own file in dir, named
+// after the partition
+func WritePartitions(partitions map[string]*partition, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	names := make(map[string]string)
+	for _, name := range sortedKeys(partitions) {
+		key := sanitizeName(name)
+		if other, ok := names[key]; ok {
+			return fmt.Errorf("Partitions '%s' and '%s' would be written to the same file %s.yaml", other, name, key)
+		}
+		names[key] = name
+
+		filePath := filepath.Join(dir, key+".yaml")
+		if err := writeYamlFile(filePath, map[string]*partition{key: partitions[name]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// partitionPlan is a plan for the partitions of the org along with the
+// partitions it was made from
+type partitionPlan struct {
+	*resourcePlan
+	current map[string]*partition
+	desired map[string]*partition
+}
+
+// PlanPartitions compares the partitions defined at path with the active
+// partitions in the org. With prune, partitions that aren't defined are
+// decommissioned
+func PlanPartitions(ctx context.Context, a *APIClient, path string, prune bool) (*partitionPlan, error) {
+	desired, err := ReadPartitions(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := ListPartitions(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := newResourcePlan("partition", current, desired, prune)
+	if err != nil {
+		return nil, err
+	}
+
+	return &partitionPlan{
+		resourcePlan: p,
+		current:      current,
+		desired:      desired,
+	}, nil
+}
+
+// Apply creates, updates, and decommissions the partitions the plan
+// changes. A partition's analytics tier can't change once it's created,
+// so nothing is applied when the plan changes one
+func (p *partitionPlan) Apply(ctx context.Context, a *APIClient, w io.Writer) error {
+	if err := p.checkImmutable("AnalyticsTier"); err != nil {
+		return err
+	}
+
+	return p.apply(w, func(action planAction) error {
+		switch action.Action {
+		case PlanCreate:
+			body := *p.desired[action.Name]
+			body.Id = ""
+			return a.requestJSON(ctx, "POST", "/v1/partitions", nil, body, nil)
+		case PlanUpdate:
+			desired := p.desired[action.Name]
+			body := map[string]interface{}{
+				"routingExpression":                desired.RoutingExpression,
+				"retentionPeriod":                  desired.RetentionPeriod,
+				"isCompliant":                      desired.IsCompliant,
+				"dataForwardingId":                 desired.DataForwardingId,
+				"reduceRetentionPeriodImmediately": false,
+			}
+			return a.requestJSON(ctx, "PUT", "/v1/partitions/"+p.current[action.Name].Id, nil, body, nil)
+		case PlanDelete:
+			return a.requestJSON(ctx, "POST", "/v1/partitions/"+p.current[action.Name].Id+"/decommission", nil, nil, nil)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,582 @@
+package sumoapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Access levels of a content permission
+const (
+	PermissionView   = "view"
+	PermissionEdit   = "edit"
+	PermissionManage = "manage"
+)
+
+// RootFolderKey names the application's root folder in diffs, as it isn't
+// one of the overlay's folders
+const RootFolderKey = "(root)"
+
+// principalsPageSize is the largest page the Roles and Users APIs return
+const principalsPageSize = 1000
+
+// permissionLevelNames maps each access level to the Content Permissions
+// API permissions it's made of, from the lowest to the highest
+var permissionLevelNames = map[string][]string{
+	PermissionView:   {"View"},
+	PermissionEdit:   {"View", "Edit"},
+	PermissionManage: {"View", "Edit", "Manage"},
+}
+
+// Item types of the content library the permissions of a build apply to
+const (
+	contentItemFolder    = "Folder"
+	contentItemDashboard = "Dashboard"
+)
+
+type contentPermissionAssignment struct {
+	PermissionName string `json:"permissionName"`
+	SourceType     string `json:"sourceType"`
+	SourceId       string `json:"sourceId"`
+	ContentId      string `json:"contentId"`
+}
+
+type contentPermissionsResponse struct {
+	ExplicitPermissions []contentPermissionAssignment `json:"explicitPermissions"`
+}
+
+type contentPermissionsRequest struct {
+	ContentPermissionAssignments []contentPermissionAssignment `json:"contentPermissionAssignments"`
+	NotifyRecipients             bool                          `json:"notifyRecipients"`
+	NotificationMessage          string                        `json:"notificationMessage"`
+}
+
+// contentPermissionNode holds the permissions declared on a folder or
+// dashboard of a build, along with the nodes of a folder's children. Items
+// without declared permissions keep the permissions they have
+type contentPermissionNode struct {
+	Name        string
+	ItemType    string
+	Permissions []contentPermission
+	Children    []*contentPermissionNode
+}
+
+// Validate checks the permission names a role or a user, but not both,
+// and has a known level
+func (p contentPermission) Validate() error {
+	switch {
+	case p.Role == "" && p.User == "":
+		return fmt.Errorf("A permission has no role or user")
+	case p.Role != "" && p.User != "":
+		return fmt.Errorf("A permission has both a role (%s) and a user (%s). Use a permission for each", p.Role, p.User)
+	case permissionLevelNames[p.Level] == nil:
+		return fmt.Errorf("Unknown permission level '%s'. Expected one of: %s", p.Level, strings.Join([]string{PermissionView, PermissionEdit, PermissionManage}, ", "))
+	}
+
+	return nil
+}
+
+// TakePermissions removes the permissions declared in the folder and its
+// children, as the content import format has no place for them, and
+// returns them to be applied once the folder is imported
+func (f *folder) TakePermissions() (*contentPermissionNode, error) {
+	root := &contentPermissionNode{
+		Name:        f.Name,
+		ItemType:    contentItemFolder,
+		Permissions: f.Permissions,
+	}
+	f.Permissions = nil
+
+	for _, child := range f.Children {
+		node, err := takeChildPermissions(child)
+		if err != nil {
+			return nil, err
+		}
+
+		if node != nil {
+			root.Children = append(root.Children, node)
+		}
+	}
+
+	if err := root.validate(); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// takeChildPermissions removes the permissions of a folder or dashboard
+// in a build's children. Saved searches have no node
+func takeChildPermissions(obj interface{}) (*contentPermissionNode, error) {
+	child, ok := obj.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	node := &contentPermissionNode{}
+	if name, ok := child["name"].(string); ok {
+		node.Name = name
+	}
+
+	switch child["type"] {
+	case FolderType:
+		node.ItemType = contentItemFolder
+	case DashboardType:
+		node.ItemType = contentItemDashboard
+	default:
+		return nil, nil
+	}
+
+	if permissions, ok := child["permissions"]; ok {
+		data, err := json.Marshal(permissions)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, &node.Permissions); err != nil {
+			return nil, fmt.Errorf("Invalid permissions of '%s': %w", node.Name, err)
+		}
+
+		delete(child, "permissions")
+	}
+
+	if children, ok := child["children"].([]interface{}); ok {
+		for _, c := range children {
+			childNode, err := takeChildPermissions(c)
+			if err != nil {
+				return nil, err
+			}
+
+			if childNode != nil {
+				node.Children = append(node.Children, childNode)
+			}
+		}
+	}
+
+	return node, nil
+}
+
+func (n *contentPermissionNode) validate() error {
+	for _, p := range n.Permissions {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("Invalid permissions of '%s': %w", n.Name, err)
+		}
+	}
+
+	for _, child := range n.Children {
+		if err := child.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasPermissions reports whether the node or any of its children declare
+// permissions
+func (n *contentPermissionNode) HasPermissions() bool {
+	if len(n.Permissions) > 0 {
+		return true
+	}
+
+	for _, child := range n.Children {
+		if child.HasPermissions() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply makes the explicit View, Edit, and Manage permissions of the roles
+// and users on the imported folder in the folder with parentId, and on its
+// children, match the declared permissions. Items without declared
+// permissions, and permissions given to the whole org, are left alone
+func (n *contentPermissionNode) Apply(ctx context.Context, a *APIClient, parentId string, w io.Writer) error {
+	principals := &contentPrincipals{client: a}
+
+	items, err := listContentFolder(ctx, a, parentId)
+	if err != nil {
+		return err
+	}
+
+	id, ok := items.find(n.Name, n.ItemType)
+	if !ok {
+		return fmt.Errorf("Could not find the imported folder '%s' in folder %s", n.Name, parentId)
+	}
+
+	count, err := n.apply(ctx, a, principals, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Applied permissions to %d folder(s) and dashboard(s)\n", count)
+	return nil
+}
+
+// apply applies the node's permissions to the item with id, and its
+// children's to the items in it. It returns how many items it changed
+func (n *contentPermissionNode) apply(ctx context.Context, a *APIClient, principals *contentPrincipals, id string) (int, error) {
+	count := 0
+
+	if len(n.Permissions) > 0 {
+		changed, err := n.applyItem(ctx, a, principals, id)
+		if err != nil {
+			return 0, err
+		}
+
+		if changed {
+			count++
+		}
+	}
+
+	//The folder is only listed when one of its children declares
+	//permissions
+	var items contentItems
+	for _, child := range n.Children {
+		if !child.HasPermissions() {
+			continue
+		}
+
+		if items == nil {
+			var err error
+			if items, err = listContentFolder(ctx, a, id); err != nil {
+				return 0, err
+			}
+		}
+
+		childId, ok := items.find(child.Name, child.ItemType)
+		if !ok {
+			return 0, fmt.Errorf("Could not find the imported %s '%s' in folder '%s'", strings.ToLower(child.ItemType), child.Name, n.Name)
+		}
+
+		childCount, err := child.apply(ctx, a, principals, childId)
+		if err != nil {
+			return 0, err
+		}
+		count += childCount
+	}
+
+	return count, nil
+}
+
+// applyItem adds the declared permissions the item with id lacks and
+// removes the ones it has but doesn't declare. It reports whether the
+// item's permissions changed
+func (n *contentPermissionNode) applyItem(ctx context.Context, a *APIClient, principals *contentPrincipals, id string) (bool, error) {
+	desired, err := principals.assignments(ctx, n.Permissions, id)
+	if err != nil {
+		return false, fmt.Errorf("Invalid permissions of '%s': %w", n.Name, err)
+	}
+
+	current, err := explicitPermissions(ctx, a, id)
+	if err != nil {
+		return false, err
+	}
+
+	add := make([]contentPermissionAssignment, 0)
+	for _, d := range desired {
+		if !containsAssignment(current, d) {
+			add = append(add, d)
+		}
+	}
+
+	remove := make([]contentPermissionAssignment, 0)
+	for _, c := range current {
+		if managedAssignment(c) && !containsAssignment(desired, c) {
+			remove = append(remove, c)
+		}
+	}
+
+	if len(remove) > 0 {
+		body := contentPermissionsRequest{ContentPermissionAssignments: remove}
+		if err := a.requestJSON(ctx, "PUT", "/v2/content/"+id+"/permissions/remove", nil, body, nil); err != nil {
+			return false, err
+		}
+	}
+
+	if len(add) > 0 {
+		body := contentPermissionsRequest{ContentPermissionAssignments: add}
+		if err := a.requestJSON(ctx, "PUT", "/v2/content/"+id+"/permissions/add", nil, body, nil); err != nil {
+			return false, err
+		}
+	}
+
+	return len(add) > 0 || len(remove) > 0, nil
+}
+
+// managedAssignment reports whether an assignment is one declared
+// permissions manage: View, Edit, or Manage given to a role or a user
+func managedAssignment(c contentPermissionAssignment) bool {
+	return (c.SourceType == "role" || c.SourceType == "user") && containsString(permissionLevelNames[PermissionManage], c.PermissionName)
+}
+
+func containsAssignment(list []contentPermissionAssignment, c contentPermissionAssignment) bool {
+	for _, l := range list {
+		if l.PermissionName == c.PermissionName && l.SourceType == c.SourceType && l.SourceId == c.SourceId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// explicitPermissions returns the permissions given on the item with id
+// itself, leaving out the ones it inherits from its folders
+func explicitPermissions(ctx context.Context, a *APIClient, id string) ([]contentPermissionAssignment, error) {
+	query := url.Values{}
+	query.Add("explicitOnly", "true")
+
+	var response contentPermissionsResponse
+	if err := a.requestJSON(ctx, "GET", "/v2/content/"+id+"/permissions", query, nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response.ExplicitPermissions, nil
+}
+
+// find returns the ID of the item with name and itemType
+func (items contentItems) find(name string, itemType string) (string, bool) {
+	for _, item := range items {
+		if item.Name == name && item.ItemType == itemType {
+			return item.Id, true
+		}
+	}
+
+	return "", false
+}
+
+// contentPrincipals resolves role names and user emails to IDs and back.
+// The org's roles and users are listed the first time they're needed
+type contentPrincipals struct {
+	client *APIClient
+	roles  map[string]string
+	users  map[string]string
+	names  map[string]string
+}
+
+func (p *contentPrincipals) load(ctx context.Context) error {
+	if p.names != nil {
+		return nil
+	}
+
+	p.roles = make(map[string]string)
+	p.users = make(map[string]string)
+	p.names = make(map[string]string)
+
+	lists := []struct {
+		path  string
+		ids   map[string]string
+		names func(item map[string]interface{}) string
+	}{
+		{"/v1/roles", p.roles, func(item map[string]interface{}) string { return fmt.Sprint(item["name"]) }},
+		{"/v1/users", p.users, func(item map[string]interface{}) string { return fmt.Sprint(item["email"]) }},
+	}
+
+	for _, list := range lists {
+		token := ""
+		for {
+			query := url.Values{}
+			query.Add("limit", strconv.Itoa(principalsPageSize))
+			if token != "" {
+				query.Add("token", token)
+			}
+
+			var page struct {
+				Data []map[string]interface{} `json:"data"`
+				Next string                   `json:"next"`
+			}
+			if err := p.client.requestJSON(ctx, "GET", list.path, query, nil, &page); err != nil {
+				return err
+			}
+
+			for _, item := range page.Data {
+				id := fmt.Sprint(item["id"])
+				name := list.names(item)
+				list.ids[strings.ToLower(name)] = id
+				p.names[id] = name
+			}
+
+			if page.Next == "" {
+				break
+			}
+			token = page.Next
+		}
+	}
+
+	return nil
+}
+
+// assignments turns declared permissions into the Content Permissions API
+// assignments of the item with contentId
+func (p *contentPrincipals) assignments(ctx context.Context, permissions []contentPermission, contentId string) ([]contentPermissionAssignment, error) {
+	if err := p.load(ctx); err != nil {
+		return nil, err
+	}
+
+	result := make([]contentPermissionAssignment, 0)
+	for _, perm := range permissions {
+		sourceType, sourceId := "role", p.roles[strings.ToLower(perm.Role)]
+		if perm.Role == "" {
+			sourceType, sourceId = "user", p.users[strings.ToLower(perm.User)]
+		}
+
+		if sourceId == "" {
+			return nil, fmt.Errorf("There's no %s '%s%s' in the org", sourceType, perm.Role, perm.User)
+		}
+
+		for _, name := range permissionLevelNames[perm.Level] {
+			c := contentPermissionAssignment{
+				PermissionName: name,
+				SourceType:     sourceType,
+				SourceId:       sourceId,
+				ContentId:      contentId,
+			}
+
+			if !containsAssignment(result, c) {
+				result = append(result, c)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// permissions turns the assignments of an item into permissions, giving
+// each role and user the highest level they have. Roles come first, and
+// both are sorted by name so the same assignments always produce the same
+// permissions
+func (p *contentPrincipals) permissions(ctx context.Context, assignments []contentPermissionAssignment) ([]contentPermission, error) {
+	if err := p.load(ctx); err != nil {
+		return nil, err
+	}
+
+	levels := []string{PermissionView, PermissionEdit, PermissionManage}
+	highest := make(map[contentPermission]int)
+
+	for _, c := range assignments {
+		if !managedAssignment(c) {
+			continue
+		}
+
+		name, ok := p.names[c.SourceId]
+		if !ok {
+			name = c.SourceId
+		}
+
+		key := contentPermission{Role: name}
+		if c.SourceType == "user" {
+			key = contentPermission{User: name}
+		}
+
+		level := 0
+		for i, l := range levels {
+			if permissionLevelNames[l][len(permissionLevelNames[l])-1] == c.PermissionName {
+				level = i
+			}
+		}
+
+		if current, ok := highest[key]; !ok || level > current {
+			highest[key] = level
+		}
+	}
+
+	result := make([]contentPermission, 0, len(highest))
+	for key, level := range highest {
+		key.Level = levels[level]
+		result = append(result, key)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Role == "") != (result[j].Role == "") {
+			return result[i].Role != ""
+		}
+		return result[i].Role+result[i].User < result[j].Role+result[j].User
+	})
+
+	return result, nil
+}
+
+// AddContentPermissions adds the permissions of the exported folder with
+// folderId, and of the folders and dashboards in it, to the export, so
+// they're kept along with the content. Only the View, Edit, and Manage
+// permissions given on an item itself to roles and users are added
+func AddContentPermissions(ctx context.Context, a *APIClient, folderId string, export []byte) ([]byte, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(export, &root); err != nil {
+		return nil, err
+	}
+
+	principals := &contentPrincipals{client: a}
+	if err := addItemPermissions(ctx, a, principals, folderId, root); err != nil {
+		return nil, err
+	}
+
+	return canonicalJSON(root)
+}
+
+// addItemPermissions adds the permissions of the item with id to its
+// exported object and, for a folder, adds its children's
+func addItemPermissions(ctx context.Context, a *APIClient, principals *contentPrincipals, id string, obj map[string]interface{}) error {
+	assignments, err := explicitPermissions(ctx, a, id)
+	if err != nil {
+		return err
+	}
+
+	permissions, err := principals.permissions(ctx, assignments)
+	if err != nil {
+		return err
+	}
+
+	if len(permissions) > 0 {
+		obj["permissions"] = permissions
+	}
+
+	if obj["type"] != FolderType {
+		return nil
+	}
+
+	children, _ := obj["children"].([]interface{})
+	if len(children) == 0 {
+		return nil
+	}
+
+	items, err := listContentFolder(ctx, a, id)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range children {
+		child, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		itemType := ""
+		switch child["type"] {
+		case FolderType:
+			itemType = contentItemFolder
+		case DashboardType:
+			itemType = contentItemDashboard
+		default:
+			continue
+		}
+
+		name, _ := child["name"].(string)
+		childId, ok := items.find(name, itemType)
+		if !ok {
+			continue
+		}
+
+		if err := addItemPermissions(ctx, a, principals, childId, child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package sumoapp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContentPermissionValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		permission contentPermission
+		wantErr    string
+	}{
+		{"role", contentPermission{Role: "Analysts", Level: PermissionView}, ""},
+		{"user", contentPermission{User: "ops@example.com", Level: PermissionManage}, ""},
+		{"no principal", contentPermission{Level: PermissionEdit}, "no role or user"},
+		{"role and user", contentPermission{Role: "Analysts", User: "ops@example.com", Level: PermissionView}, "both a role"},
+		{"unknown level", contentPermission{Role: "Analysts", Level: "admin"}, "Unknown permission level"},
+		{"no level", contentPermission{Role: "Analysts"}, "Unknown permission level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.permission.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTakePermissions(t *testing.T) {
+	const build = `{
+		"type": "FolderSyncDefinition",
+		"name": "Test App",
+		"permissions": [{"role": "Analysts", "level": "view"}],
+		"children": [
+			{"type": "SavedSearchWithScheduleSyncDefinition", "name": "Errors"},
+			{"type": "DashboardV2SyncDefinition", "name": "Overview"},
+			{
+				"type": "FolderSyncDefinition",
+				"name": "Sub Folder",
+				"children": [
+					{
+						"type": "DashboardV2SyncDefinition",
+						"name": "Details",
+						"permissions": [{"user": "ops@example.com", "level": "manage"}]
+					}
+				]
+			}
+		]
+	}`
+
+	root := NewFolder()
+	if err := json.Unmarshal([]byte(build), root); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := root.TakePermissions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &contentPermissionNode{
+		Name:        "Test App",
+		ItemType:    contentItemFolder,
+		Permissions: []contentPermission{{Role: "Analysts", Level: PermissionView}},
+		Children: []*contentPermissionNode{
+			{Name: "Overview", ItemType: contentItemDashboard},
+			{Name: "Sub Folder", ItemType: contentItemFolder, Children: []*contentPermissionNode{
+				{Name: "Details", ItemType: contentItemDashboard, Permissions: []contentPermission{{User: "ops@example.com", Level: PermissionManage}}},
+			}},
+		},
+	}
+
+	if !reflect.DeepEqual(node, want) {
+		t.Errorf("TakePermissions() = %+v, want %+v", node, want)
+	}
+
+	if !node.HasPermissions() || node.Children[0].HasPermissions() || !node.Children[1].HasPermissions() {
+		t.Errorf("HasPermissions() should only hold for nodes with declared permissions in them")
+	}
+
+	//The import format has no place for permissions
+	data, err := json.Marshal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), "permissions") {
+		t.Errorf("the folder still has permissions after TakePermissions(): %s", data)
+	}
+}
+
+func TestTakePermissionsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   string
+		wantErr string
+	}{
+		{
+			name:    "invalid root permission",
+			build:   `{"name": "Test App", "permissions": [{"level": "view"}]}`,
+			wantErr: "Invalid permissions of 'Test App'",
+		},
+		{
+			name:    "invalid child permission",
+			build:   `{"name": "Test App", "children": [{"type": "DashboardV2SyncDefinition", "name": "Overview", "permissions": [{"role": "Analysts", "level": "owner"}]}]}`,
+			wantErr: "Invalid permissions of 'Overview'",
+		},
+		{
+			name:    "malformed child permissions",
+			build:   `{"name": "Test App", "children": [{"type": "FolderSyncDefinition", "name": "Sub Folder", "permissions": "everyone"}]}`,
+			wantErr: "Invalid permissions of 'Sub Folder'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := NewFolder()
+			if err := json.Unmarshal([]byte(tt.build), root); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := root.TakePermissions(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("TakePermissions() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// testPrincipals returns principals that are already loaded, so they
+// don't call the API
+func testPrincipals() *contentPrincipals {
+	return &contentPrincipals{
+		roles: map[string]string{"analysts": "r1", "administrators": "r2"},
+		users: map[string]string{"ops@example.com": "u1"},
+		names: map[string]string{"r1": "Analysts", "r2": "Administrators", "u1": "ops@example.com"},
+	}
+}
+
+func TestContentPrincipalsAssignments(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []contentPermission
+		want        []contentPermissionAssignment
+		wantErr     bool
+	}{
+		{
+			name:        "view",
+			permissions: []contentPermission{{Role: "Analysts", Level: PermissionView}},
+			want: []contentPermissionAssignment{
+				{PermissionName: "View", SourceType: "role", SourceId: "r1", ContentId: "c1"},
+			},
+		},
+		{
+			name:        "manage includes the lower levels",
+			permissions: []contentPermission{{User: "OPS@example.com", Level: PermissionManage}},
+			want: []contentPermissionAssignment{
+				{PermissionName: "View", SourceType: "user", SourceId: "u1", ContentId: "c1"},
+				{PermissionName: "Edit", SourceType: "user", SourceId: "u1", ContentId: "c1"},
+				{PermissionName: "Manage", SourceType: "user", SourceId: "u1", ContentId: "c1"},
+			},
+		},
+		{
+			name: "overlapping levels are assigned once",
+			permissions: []contentPermission{
+				{Role: "analysts", Level: PermissionView},
+				{Role: "Analysts", Level: PermissionEdit},
+			},
+			want: []contentPermissionAssignment{
+				{PermissionName: "View", SourceType: "role", SourceId: "r1", ContentId: "c1"},
+				{PermissionName: "Edit", SourceType: "role", SourceId: "r1", ContentId: "c1"},
+			},
+		},
+		{
+			name:        "unknown role",
+			permissions: []contentPermission{{Role: "Auditors", Level: PermissionView}},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown user",
+			permissions: []contentPermission{{User: "nobody@example.com", Level: PermissionView}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testPrincipals().assignments(context.Background(), tt.permissions, "c1")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("assignments() = %+v, want an error", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assignments() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentPrincipalsPermissions(t *testing.T) {
+	assignments := []contentPermissionAssignment{
+		{PermissionName: "View", SourceType: "user", SourceId: "u1"},
+		{PermissionName: "Edit", SourceType: "user", SourceId: "u1"},
+		{PermissionName: "View", SourceType: "role", SourceId: "r2"},
+		{PermissionName: "Manage", SourceType: "role", SourceId: "r1"},
+		{PermissionName: "View", SourceType: "role", SourceId: "r9"},
+		{PermissionName: "View", SourceType: "org", SourceId: "0000000000000001"},
+		{PermissionName: "GrantView", SourceType: "role", SourceId: "r1"},
+	}
+
+	got, err := testPrincipals().permissions(context.Background(), assignments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []contentPermission{
+		{Role: "Administrators", Level: PermissionView},
+		{Role: "Analysts", Level: PermissionManage},
+		{Role: "r9", Level: PermissionView},
+		{User: "ops@example.com", Level: PermissionEdit},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("permissions() = %+v, want %+v", got, want)
+	}
+
+	//Declared permissions turned into assignments and back are unchanged
+	for _, p := range want[:2] {
+		declared := []contentPermission{p}
+
+		a, err := testPrincipals().assignments(context.Background(), declared, "c1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		back, err := testPrincipals().permissions(context.Background(), a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(back, declared) {
+			t.Errorf("permissions(assignments(%+v)) = %+v", declared, back)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package sumoapp
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/r3labs/diff"
+)
+
+// Actions of a resource plan
+const (
+	PlanCreate = "create"
+	PlanUpdate = "update"
+	PlanDelete = "delete"
+)
+
+// planAction is a change to one resource. Resources are matched by name
+type planAction struct {
+	Action string   `json:"action"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// resourcePlan lists the actions that make the resources of one type in
+// the org match their definitions. Resources that are only in the org are
+// deleted with prune, otherwise they're listed as unmanaged
+type resourcePlan struct {
+	ResourceType string        `json:"resourceType"`
+	Actions      []planAction  `json:"actions"`
+	Unmanaged    []string      `json:"unmanaged"`
+	Changes      []fieldChange `json:"changes"`
+	changes      diff.Changelog
+}
+
+// newResourcePlan diffs the current resources with the desired ones. Both
+// are maps of resources keyed by name. Fields the org assigns, such as
+// IDs, must be left out of the diff with a `diff:"-"` tag
+func newResourcePlan(resourceType string, current interface{}, desired interface{}, prune bool) (*resourcePlan, error) {
+	changes, err := taggedDiff(resourceType, current, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &resourcePlan{
+		ResourceType: resourceType,
+		Actions:      make([]planAction, 0),
+		Unmanaged:    make([]string, 0),
+		Changes:      make([]fieldChange, 0),
+	}
+
+	byName := make(map[string]diff.Changelog)
+	for _, c := range changes {
+		byName[c.Path[1]] = append(byName[c.Path[1]], c)
+	}
+
+	for _, name := range sortedKeys(byName) {
+		nameChanges := byName[name]
+		action := planAction{Action: PlanUpdate, Name: name}
+
+		if len(nameChanges) == 1 && len(nameChanges[0].Path) == 2 {
+			//The whole resource was created or deleted
+			switch nameChanges[0].Type {
+			case diff.CREATE:
+				action.Action = PlanCreate
+			case diff.DELETE:
+				action.Action = PlanDelete
+			}
+		}
+
+		if action.Action == PlanDelete && !prune {
+			p.Unmanaged = append(p.Unmanaged, name)
+			continue
+		}
+
+		if action.Action == PlanUpdate {
+			for _, c := range nameChanges {
+				field := humanizeField(c.Path[2])
+				if !containsString(action.Fields, field) {
+					action.Fields = append(action.Fields, field)
+				}
+			}
+		}
+
+		p.Actions = append(p.Actions, action)
+		p.changes = append(p.changes, nameChanges...)
+
+		for _, c := range nameChanges {
+			p.Changes = append(p.Changes, newFieldChange(c))
+		}
+	}
+
+	return p, nil
+}
+
+// HasChanges reports whether applying the plan changes anything
+func (p *resourcePlan) HasChanges() bool {
+	return len(p.Actions) > 0
+}
+
+// count returns how many actions of a kind the plan has
+func (p *resourcePlan) count(action string) int {
+	n := 0
+	for _, a := range p.Actions {
+		if a.Action == action {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (p *resourcePlan) ToJSON() ([]byte, error) {
+	return canonicalJSON(p)
+}
+
+// Display writes a line per action followed by the diff of every changed
+// resource and a summary
+func (p *resourcePlan) Display(w io.Writer) {
+	if !p.HasChanges() {
+		fmt.Fprintf(w, "No changes. The %s definitions match the org.\n", p.ResourceType)
+	}
+
+	for _, a := range p.Actions {
+		switch a.Action {
+		case PlanCreate:
+			fmt.Fprintf(w, "%s+ %s %s%s\n", ColorGreen, p.ResourceType, a.Name, ColorReset)
+		case PlanDelete:
+			fmt.Fprintf(w, "%s- %s %s%s\n", ColorRed, p.ResourceType, a.Name, ColorReset)
+		default:
+			fmt.Fprintf(w, "~ %s %s (%s)\n", p.ResourceType, a.Name, strings.Join(a.Fields, ", "))
+		}
+	}
+
+	if p.HasChanges() {
+		fmt.Fprintln(w)
+		displayDiffSection(w, p.changes)
+		fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete.\n", p.count(PlanCreate), p.count(PlanUpdate), p.count(PlanDelete))
+	}
+
+	if len(p.Unmanaged) > 0 {
+		fmt.Fprintf(w, "\n%d %s(s) in the org have no definition and are left alone. Use --prune to delete them: %s\n", len(p.Unmanaged), p.ResourceType, strings.Join(p.Unmanaged, ", "))
+	}
+}
+
+// apply carries out the plan's actions in order with applyAction, and
+// writes a line for each action that succeeds. It stops at the first
+// action that fails
+func (p *resourcePlan) apply(w io.Writer, applyAction func(action planAction) error) error {
+	pastTense := map[string]string{
+		PlanCreate: "Created",
+		PlanUpdate: "Updated",
+		PlanDelete: "Deleted",
+	}
+
+	for _, a := range p.Actions {
+		if err := applyAction(a); err != nil {
+			return fmt.Errorf("Unable to %s %s '%s': %w", a.Action, p.ResourceType, a.Name, err)
+		}
+
+		fmt.Fprintf(w, "%s %s %s\n", pastTense[a.Action], p.ResourceType, a.Name)
+	}
+
+	return nil
+}
+
+// checkImmutable returns an error when the plan updates one of the given
+// fields, which the API can't change once a resource is created. Fields
+// are named as in the resource's struct
+func (p *resourcePlan) checkImmutable(fields ...string) error {
+	for _, a := range p.Actions {
+		if a.Action != PlanUpdate {
+			continue
+		}
+
+		for _, field := range fields {
+			if containsString(a.Fields, humanizeField(field)) {
+				return fmt.Errorf("The %s of %s '%s' can't be changed once it's created. Define a %s with a new name instead", humanizeField(field), p.ResourceType, a.Name, p.ResourceType)
+			}
+		}
+	}
+
+	return nil
+}
+
+// definitionFiles returns path when it's a file, or else the YAML files in
+// the directory at path
+func definitionFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".yaml" {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,203 @@
+package sumoapp
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewResourcePlan(t *testing.T) {
+	rule := func(id string, scope string, expression string) *fieldExtractionRule {
+		return &fieldExtractionRule{
+			Id:              id,
+			Name:            "rule",
+			Scope:           scope,
+			ParseExpression: expression,
+			Enabled:         boolPtr(true),
+		}
+	}
+
+	current := map[string]*fieldExtractionRule{
+		"same":      rule("1", "_sourceCategory=a", "parse \"x=*\" as x"),
+		"changed":   rule("2", "_sourceCategory=a", "parse \"x=*\" as x"),
+		"unmanaged": rule("3", "_sourceCategory=a", "parse \"x=*\" as x"),
+	}
+
+	desired := map[string]*fieldExtractionRule{
+		"same":    rule("", "_sourceCategory=a", "parse \"x=*\" as x"),
+		"changed": rule("", "_sourceCategory=b", "parse \"y=*\" as y"),
+		"new":     rule("", "_sourceCategory=c", "parse \"z=*\" as z"),
+	}
+
+	tests := []struct {
+		name          string
+		prune         bool
+		wantActions   []planAction
+		wantUnmanaged []string
+	}{
+		{
+			name: "without prune",
+			wantActions: []planAction{
+				{Action: PlanUpdate, Name: "changed", Fields: []string{"scope", "parse expression"}},
+				{Action: PlanCreate, Name: "new"},
+			},
+			wantUnmanaged: []string{"unmanaged"},
+		},
+		{
+			name:  "with prune",
+			prune: true,
+			wantActions: []planAction{
+				{Action: PlanUpdate, Name: "changed", Fields: []string{"scope", "parse expression"}},
+				{Action: PlanCreate, Name: "new"},
+				{Action: PlanDelete, Name: "unmanaged"},
+			},
+			wantUnmanaged: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newResourcePlan("fer", current, desired, tt.prune)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(p.Actions, tt.wantActions) {
+				t.Errorf("Actions = %+v, want %+v", p.Actions, tt.wantActions)
+			}
+
+			if !reflect.DeepEqual(p.Unmanaged, tt.wantUnmanaged) {
+				t.Errorf("Unmanaged = %v, want %v", p.Unmanaged, tt.wantUnmanaged)
+			}
+
+			if !p.HasChanges() {
+				t.Errorf("HasChanges() = false, want true")
+			}
+		})
+	}
+}
+
+func TestNewResourcePlanNoChanges(t *testing.T) {
+	current := map[string]*fieldExtractionRule{"a": {Id: "1", Name: "a", Scope: "x", Enabled: boolPtr(false)}}
+	desired := map[string]*fieldExtractionRule{"a": {Name: "a", Scope: "x", Enabled: boolPtr(false)}}
+
+	p, err := newResourcePlan("fer", current, desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.HasChanges() {
+		t.Errorf("Actions = %+v, want none as IDs aren't compared", p.Actions)
+	}
+
+	var out bytes.Buffer
+	p.Display(&out)
+	if want := "No changes. The fer definitions match the org.\n"; out.String() != want {
+		t.Errorf("Display() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestResourcePlanCheckImmutable(t *testing.T) {
+	p := &resourcePlan{
+		ResourceType: "partition",
+		Actions: []planAction{
+			{Action: PlanCreate, Name: "new"},
+			{Action: PlanUpdate, Name: "prod", Fields: []string{"retention period"}},
+		},
+	}
+
+	tests := []struct {
+		fields  []string
+		wantErr bool
+	}{
+		{fields: []string{"AnalyticsTier"}},
+		{fields: []string{"AnalyticsTier", "RetentionPeriod"}, wantErr: true},
+		{fields: nil},
+	}
+
+	for _, tt := range tests {
+		if err := p.checkImmutable(tt.fields...); (err != nil) != tt.wantErr {
+			t.Errorf("checkImmutable(%v) error = %v, wantErr %v", tt.fields, err, tt.wantErr)
+		}
+	}
+}
+
+func TestResourcePlanApply(t *testing.T) {
+	p := &resourcePlan{
+		ResourceType: "fer",
+		Actions: []planAction{
+			{Action: PlanCreate, Name: "a"},
+			{Action: PlanUpdate, Name: "b"},
+			{Action: PlanDelete, Name: "c"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		failOn  string
+		wantOut string
+		wantErr string
+	}{
+		{
+			name:    "every action",
+			wantOut: "Created fer a\nUpdated fer b\nDeleted fer c\n",
+		},
+		{
+			name:    "stops at the first failure",
+			failOn:  "b",
+			wantOut: "Created fer a\n",
+			wantErr: "Unable to update fer 'b': boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := p.apply(&out, func(a planAction) error {
+				if a.Name == tt.failOn {
+					return fmt.Errorf("boom")
+				}
+				return nil
+			})
+
+			if out.String() != tt.wantOut {
+				t.Errorf("apply() wrote %q, want %q", out.String(), tt.wantOut)
+			}
+
+			if (err == nil && tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("apply() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefinitionFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.yaml", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := definitionFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("definitionFiles(dir) = %v, want %v", files, want)
+	}
+
+	file := filepath.Join(dir, "notes.txt")
+	if files, err := definitionFiles(file); err != nil || !reflect.DeepEqual(files, []string{file}) {
+		t.Errorf("definitionFiles(file) = %v, %v, want the file itself", files, err)
+	}
+
+	if _, err := definitionFiles(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("definitionFiles() of a missing path should fail")
+	}
+}
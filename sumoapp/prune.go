@@ -0,0 +1,180 @@
+package sumoapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// prunedOverride is a redundant override removed from an overlay file
+type prunedOverride struct {
+	Overlay    string      `json:"overlay"`
+	File       string      `json:"file"`
+	ObjectType string      `json:"objectType"`
+	Key        string      `json:"key"`
+	Path       string      `json:"path"`
+	Value      interface{} `json:"value"`
+}
+
+type pruneReport struct {
+	DryRun         bool             `json:"dryRun"`
+	Pruned         []prunedOverride `json:"pruned"`
+	RewrittenFiles []string         `json:"rewrittenFiles"`
+	DeletedFiles   []string         `json:"deletedFiles"`
+}
+
+// PruneOverlays removes the overrides in the application's overlays that
+// set the value they already inherit from their parent overlay. Overlay
+// files are rewritten without them, and files left without any objects
+// are deleted. With dryRun nothing is written, only reported. It must be
+// called after LoadAppOverlays
+func (a *application) PruneOverlays(dryRun bool) (*pruneReport, error) {
+	if len(a.appOverlays) == 0 {
+		return nil, fmt.Errorf("The application's app overlays have not been loaded")
+	}
+
+	report := &pruneReport{
+		DryRun:         dryRun,
+		Pruned:         make([]prunedOverride, 0),
+		RewrittenFiles: make([]string, 0),
+		DeletedFiles:   make([]string, 0),
+	}
+
+	for _, overlay := range a.appOverlays {
+		if !overlay.HasParent() {
+			continue
+		}
+
+		//Removing a redundant override doesn't change the overlay's merged
+		//objects, so the overlays above it can be compared before the
+		//files are rewritten
+		d, err := overlay.ThreeWayDiff()
+		if err != nil {
+			return nil, err
+		}
+
+		redundantByFile := make(map[string][]overrideField)
+		for _, f := range d.Fields {
+			if f.Status == OverrideRedundant {
+				redundantByFile[f.file] = append(redundantByFile[f.file], f)
+			}
+		}
+
+		for _, file := range sortedKeys(redundantByFile) {
+			relPath, err := filepath.Rel(a.path, file)
+			if err != nil {
+				return nil, err
+			}
+			relPath = filepath.ToSlash(relPath)
+
+			var doc yaml.MapSlice
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := yaml.Unmarshal(data, &doc); err != nil {
+				return nil, fmt.Errorf("Unable to decode %s: %w", file, err)
+			}
+
+			for _, f := range redundantByFile[file] {
+				doc = removeMapSliceValue(doc, append([]string{f.Key}, f.fieldPath...))
+
+				report.Pruned = append(report.Pruned, prunedOverride{
+					Overlay:    overlay.Name,
+					File:       relPath,
+					ObjectType: f.ObjectType,
+					Key:        f.Key,
+					Path:       f.Path,
+					Value:      f.Override,
+				})
+			}
+
+			if len(doc) == 0 {
+				report.DeletedFiles = append(report.DeletedFiles, relPath)
+				if !dryRun {
+					if err := os.Remove(file); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+
+			report.RewrittenFiles = append(report.RewrittenFiles, relPath)
+			if !dryRun {
+				if err := writeYamlFile(file, doc); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// removeMapSliceValue removes the value at yamlPath from doc, along with
+// the maps above it that are left empty
+func removeMapSliceValue(doc yaml.MapSlice, yamlPath []string) yaml.MapSlice {
+	for i, item := range doc {
+		if fmt.Sprint(item.Key) != yamlPath[0] {
+			continue
+		}
+
+		if len(yamlPath) > 1 {
+			child, ok := item.Value.(yaml.MapSlice)
+			if !ok {
+				return doc
+			}
+
+			child = removeMapSliceValue(child, yamlPath[1:])
+			if len(child) > 0 {
+				doc[i].Value = child
+				return doc
+			}
+		}
+
+		return append(doc[:i], doc[i+1:]...)
+	}
+
+	return doc
+}
+
+func (r *pruneReport) ToJSON() ([]byte, error) {
+	return canonicalJSON(r)
+}
+
+// Display writes a human readable summary of the pruned overrides
+func (r *pruneReport) Display(w io.Writer) {
+	if len(r.Pruned) == 0 {
+		fmt.Fprintln(w, "No redundant overrides found")
+		return
+	}
+
+	verb := "Removed"
+	if r.DryRun {
+		verb = "Would remove"
+	}
+
+	fmt.Fprintln(w, verb, len(r.Pruned), "redundant override(s)")
+	fmt.Fprintln(w)
+
+	for _, p := range r.Pruned {
+		fmt.Fprintf(w, "%s: %s %s %s = %s\n", p.File, p.ObjectType, p.Key, p.Path, inlineValue(p.Value))
+	}
+
+	if len(r.DeletedFiles) > 0 {
+		fmt.Fprintln(w)
+		if r.DryRun {
+			fmt.Fprintln(w, "Would delete files left empty:")
+		} else {
+			fmt.Fprintln(w, "Deleted files left empty:")
+		}
+
+		for _, file := range r.DeletedFiles {
+			fmt.Fprintln(w, " ", file)
+		}
+	}
+}
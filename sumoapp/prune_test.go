@@ -0,0 +1,126 @@
+package sumoapp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestPruneOverlays(t *testing.T) {
+	const redundantQueries = "  queries:\n  - querystring: error | count\n    querytype: Logs\n    querykey: A\n"
+
+	tests := []struct {
+		name        string
+		override    string
+		dryRun      bool
+		wantPruned  []string
+		wantDeleted bool
+		wantFile    map[string]interface{}
+	}{
+		{
+			name:        "only redundant overrides",
+			override:    "errors:\n  title: Errors\n" + redundantQueries,
+			wantPruned:  []string{"queries", "title"},
+			wantDeleted: true,
+		},
+		{
+			name:       "redundant and changed overrides",
+			override:   "errors:\n  title: Server Errors\n" + redundantQueries,
+			wantPruned: []string{"queries"},
+			wantFile:   map[string]interface{}{"errors": map[interface{}]interface{}{"title": "Server Errors"}},
+		},
+		{
+			name:       "changed list",
+			override:   "errors:\n  queries:\n  - querystring: error | count by host\n    querykey: A\n",
+			wantPruned: []string{},
+		},
+		{
+			name:        "dry run",
+			override:    "errors:\n" + redundantQueries,
+			dryRun:      true,
+			wantPruned:  []string{"queries"},
+			wantDeleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeAppFiles(t, t.TempDir(), map[string]string{
+				"base/init.yaml":           testInitYaml,
+				"base/panels/errors.yaml":  testBasePanels,
+				"middle/panels/panel.yaml": tt.override,
+			})
+
+			app := NewApplicationWithPath(dir)
+			if err := app.LoadAppOverlays(); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := app.PruneOverlays(tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pruned := make([]string, 0)
+			for _, p := range report.Pruned {
+				pruned = append(pruned, p.Path)
+			}
+
+			if !reflect.DeepEqual(pruned, tt.wantPruned) {
+				t.Errorf("pruned %v, want %v", pruned, tt.wantPruned)
+			}
+
+			if got := len(report.DeletedFiles) == 1; got != tt.wantDeleted {
+				t.Errorf("DeletedFiles = %v, want the file deleted: %v", report.DeletedFiles, tt.wantDeleted)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "middle", "panels", "panel.yaml"))
+			switch {
+			case tt.dryRun || len(tt.wantPruned) == 0:
+				if string(data) != tt.override {
+					t.Errorf("the overlay file was rewritten:\n%s", data)
+				}
+			case tt.wantDeleted:
+				if !os.IsNotExist(err) {
+					t.Errorf("the overlay file wasn't deleted: %v", err)
+				}
+			default:
+				var got map[string]interface{}
+				if err := yaml.Unmarshal(data, &got); err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(got, tt.wantFile) {
+					t.Errorf("rewritten file = %v, want %v", got, tt.wantFile)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveMapSliceValue(t *testing.T) {
+	doc := yaml.MapSlice{
+		{Key: "errors", Value: yaml.MapSlice{
+			{Key: "title", Value: "Errors"},
+			{Key: "timerange", Value: yaml.MapSlice{{Key: "from", Value: "-1h"}}},
+		}},
+	}
+
+	doc = removeMapSliceValue(doc, []string{"errors", "timerange", "from"})
+	want := yaml.MapSlice{{Key: "errors", Value: yaml.MapSlice{{Key: "title", Value: "Errors"}}}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("removeMapSliceValue() = %v, want the emptied map removed: %v", doc, want)
+	}
+
+	doc = removeMapSliceValue(doc, []string{"errors", "missing"})
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("removeMapSliceValue() of a missing path = %v, want %v", doc, want)
+	}
+
+	if doc = removeMapSliceValue(doc, []string{"errors", "title"}); len(doc) != 0 {
+		t.Errorf("removeMapSliceValue() = %v, want an empty document", doc)
+	}
+}
@@ -0,0 +1,430 @@
+package sumoapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Query types of a panel's queries
+const (
+	LogsQueryType    = "Logs"
+	MetricsQueryType = "Metrics"
+)
+
+// queryVariablePattern matches a {{variable}} reference in a query
+var queryVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// runnableQuery is a query from the application source, with its variables
+// substituted, and the time range it runs over. From and To are in the
+// format ParseSearchTime reads. They're empty when the source doesn't set
+// a time range
+type runnableQuery struct {
+	ObjectType    string `json:"objectType"`
+	Key           string `json:"key"`
+	QueryKey      string `json:"queryKey,omitempty"`
+	QueryType     string `json:"queryType"`
+	Query         string `json:"query"`
+	From          string `json:"from,omitempty"`
+	To            string `json:"to,omitempty"`
+	ByReceiptTime bool   `json:"byReceiptTime,omitempty"`
+}
+
+// SavedSearchQuery returns the merged query of a saved search in the
+// overlay. Query parameters take their default value unless vars sets them
+func (s *appOverlay) SavedSearchQuery(key string, vars map[string]string) (*runnableQuery, error) {
+	search, ok := s.SavedSearches[key]
+	if !ok {
+		return nil, fmt.Errorf("Could not find saved search '%s' in app overlay %s", key, s.Name)
+	}
+
+	values := make(map[string]string)
+	for _, param := range search.Search.QueryParameters {
+		p, ok := stringKeyed(param).(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _ := p["name"].(string)
+		if name == "" {
+			continue
+		}
+
+		if value, ok := p["value"]; ok && value != nil {
+			values[name] = fmt.Sprint(value)
+		}
+	}
+
+	for name, value := range vars {
+		values[name] = value
+	}
+
+	queryText, err := substituteVariables(search.Search.QueryText, values)
+	if err != nil {
+		return nil, fmt.Errorf("Saved search '%s': %w", key, err)
+	}
+
+	return &runnableQuery{
+		ObjectType:    "saved-search",
+		Key:           key,
+		QueryType:     LogsQueryType,
+		Query:         queryText,
+		From:          search.Search.DefaultTimeRange,
+		ByReceiptTime: search.Search.ByReceiptTime,
+	}, nil
+}
+
+// PanelQueries returns the merged queries of a panel in the overlay, or
+// only the query with queryKey when it isn't empty. Variables take the
+// default value set by the dashboards the panel is on unless vars sets
+// them. The panel's time range is used, or else its dashboard's
+func (s *appOverlay) PanelQueries(key string, queryKey string, vars map[string]string) ([]runnableQuery, error) {
+	p, ok := s.Panels[key]
+	if !ok {
+		return nil, fmt.Errorf("Could not find panel '%s' in app overlay %s", key, s.Name)
+	}
+
+	values := make(map[string]string)
+	timeRange := p.TimeRange
+
+	for _, dashboardKey := range sortedKeys(s.Dashboards) {
+		d := s.Dashboards[dashboardKey]
+		if !d.hasPanel(key) {
+			continue
+		}
+
+		for _, variableName := range d.IncludeVariables {
+			if v, ok := s.Variables[variableName]; ok {
+				values[v.Name] = v.DefaultValue
+			}
+		}
+
+		if timeRange == nil {
+			timeRange = d.TimeRange
+		}
+	}
+
+	for name, value := range vars {
+		values[name] = value
+	}
+
+	from, to, err := timeRange.relativeBounds()
+	if err != nil {
+		return nil, fmt.Errorf("Panel '%s': %w", key, err)
+	}
+
+	queries := make([]runnableQuery, 0, len(p.Queries))
+	for _, q := range p.Queries {
+		if queryKey != "" && q.QueryKey != queryKey {
+			continue
+		}
+
+		queryText, err := substituteVariables(q.QueryString, values)
+		if err != nil {
+			return nil, fmt.Errorf("Panel '%s' query %s: %w", key, q.QueryKey, err)
+		}
+
+		queryType := q.QueryType
+		if queryType == "" {
+			queryType = LogsQueryType
+		}
+
+		queries = append(queries, runnableQuery{
+			ObjectType:    "panel",
+			Key:           key,
+			QueryKey:      q.QueryKey,
+			QueryType:     queryType,
+			Query:         queryText,
+			From:          from,
+			To:            to,
+			ByReceiptTime: q.TimeSource == "Receipt",
+		})
+	}
+
+	if len(queries) == 0 {
+		if queryKey != "" {
+			return nil, fmt.Errorf("Panel '%s' has no query %s", key, queryKey)
+		}
+		return nil, fmt.Errorf("Panel '%s' has no queries", key)
+	}
+
+	return queries, nil
+}
+
+// hasPanel reports whether the panel is in the dashboard's layout
+func (d *dashboard) hasPanel(key string) bool {
+	for _, structures := range [][]layoutStructure{d.Layout.LayoutStructures, d.Layout.AppendLayoutStructures} {
+		for _, s := range structures {
+			if s.Key == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// relativeBounds returns the relative start and end of the time range.
+// Ranges without an end end now. A nil time range has no bounds
+func (t *timerange) relativeBounds() (string, string, error) {
+	if t == nil || t.From == nil {
+		return "", "", nil
+	}
+
+	bounds := make([]string, 0, 2)
+	for _, b := range []*timeBoundary{t.From, t.To} {
+		switch {
+		case b == nil:
+			bounds = append(bounds, "now")
+		case b.RelativeTime != "":
+			bounds = append(bounds, b.RelativeTime)
+		default:
+			return "", "", fmt.Errorf("Only relative time ranges can be run. Set the time range with --from and --to")
+		}
+	}
+
+	return bounds[0], bounds[1], nil
+}
+
+// substituteVariables replaces the {{variable}} references in a query with
+// their values. Referencing a variable without a value is an error
+func substituteVariables(query string, values map[string]string) (string, error) {
+	missing := make(map[string]bool)
+
+	result := queryVariablePattern.ReplaceAllStringFunc(query, func(ref string) string {
+		name := queryVariablePattern.FindStringSubmatch(ref)[1]
+
+		value, ok := values[name]
+		if !ok {
+			missing[name] = true
+			return ref
+		}
+
+		return value
+	})
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return "", fmt.Errorf("No value for variable(s) %s. Set them with --var name=value", strings.Join(names, ", "))
+	}
+
+	return result, nil
+}
+
+// Statuses of a query run
+const (
+	QueryRunOK      = "ok"
+	QueryRunEmpty   = "empty"
+	QueryRunFailed  = "failed"
+	QueryRunSkipped = "skipped"
+)
+
+// queryRunResult is the outcome of running a query from the application
+// source
+type queryRunResult struct {
+	runnableQuery
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Status   string   `json:"status"`
+	Messages int      `json:"messages"`
+	Records  int      `json:"records"`
+	Series   int      `json:"series"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type queryRunReport struct {
+	Results []queryRunResult `json:"results"`
+}
+
+func NewQueryRunReport() *queryRunReport {
+	return &queryRunReport{
+		Results: make([]queryRunResult, 0),
+	}
+}
+
+func (r *queryRunReport) Add(result queryRunResult) {
+	r.Results = append(r.Results, result)
+}
+
+// RunQueries runs queries from the application source one after the other.
+// from and to override the time range set in the source when they aren't
+// empty. Queries without a time range run over the last 15 minutes
+func RunQueries(ctx context.Context, a *APIClient, from string, to string, queries ...runnableQuery) *queryRunReport {
+	report := NewQueryRunReport()
+	now := time.Now()
+
+	for _, q := range queries {
+		start, end, err := q.TimeRange(from, to, now)
+		if err != nil {
+			report.Add(queryRunResult{runnableQuery: q, Status: QueryRunFailed, Error: err.Error()})
+			continue
+		}
+
+		report.Add(q.Run(ctx, a, start, end))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return report
+}
+
+// TimeRange resolves the time range the query runs over. from and to
+// override the query's own time range when they aren't empty
+func (q *runnableQuery) TimeRange(from string, to string, now time.Time) (time.Time, time.Time, error) {
+	bounds := []string{from, to}
+	for i, b := range []string{q.From, q.To} {
+		if bounds[i] == "" {
+			bounds[i] = b
+		}
+	}
+
+	if bounds[0] == "" {
+		bounds[0] = "-15m"
+	}
+
+	if bounds[1] == "" {
+		bounds[1] = "now"
+	}
+
+	start, err := ParseSearchTime(bounds[0], now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	end, err := ParseSearchTime(bounds[1], now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return start, end, nil
+}
+
+// Run runs a query over start-end. Logs queries run as a search job, which
+// is deleted afterwards, and the messages and records they return are
+// counted. Metrics queries run with the Metrics Query API and the time
+// series they return are counted. Errors running the query are reported
+// in the result
+func (q *runnableQuery) Run(ctx context.Context, a *APIClient, start time.Time, end time.Time) queryRunResult {
+	result := queryRunResult{
+		runnableQuery: *q,
+		Start:         start.Format(time.RFC3339),
+		End:           end.Format(time.RFC3339),
+	}
+
+	switch q.QueryType {
+	case LogsQueryType:
+	case MetricsQueryType:
+		metrics, err := QueryMetrics(ctx, a, map[string]string{q.QueryKey: q.Query}, start, end, 0, "")
+		if err != nil {
+			result.Status = QueryRunFailed
+			result.Error = err.Error()
+			return result
+		}
+
+		result.Series = metrics.Len()
+		result.Status = QueryRunOK
+		if result.Series == 0 {
+			result.Status = QueryRunEmpty
+		}
+		return result
+	default:
+		result.Status = QueryRunSkipped
+		result.Error = fmt.Sprintf("%s queries can't be run", q.QueryType)
+		return result
+	}
+
+	job, err := NewSearchJob(ctx, a, q.Query, start, end, q.ByReceiptTime)
+	if err != nil {
+		result.Status = QueryRunFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	status, err := job.Wait(ctx)
+	result.Warnings = job.Warnings
+
+	if deleteErr := job.Delete(); deleteErr != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Unable to delete search job %s. %s", job.Id, deleteErr))
+	}
+
+	if err != nil {
+		result.Status = QueryRunFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Messages = status.MessageCount
+	result.Records = status.RecordCount
+	result.setCountStatus(IsAggregateQuery(q.Query))
+
+	return result
+}
+
+// setCountStatus marks the result empty when the query returned nothing.
+// Aggregating queries are empty without records, others without messages
+func (r *queryRunResult) setCountStatus(aggregate bool) {
+	count := r.Messages
+	if aggregate {
+		count = r.Records
+	}
+
+	r.Status = QueryRunOK
+	if count == 0 {
+		r.Status = QueryRunEmpty
+	}
+}
+
+// Failed reports whether any query failed, or with requireResults,
+// returned nothing
+func (r *queryRunReport) Failed(requireResults bool) bool {
+	for _, result := range r.Results {
+		if result.Status == QueryRunFailed || (requireResults && result.Status == QueryRunEmpty) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *queryRunReport) ToJSON() ([]byte, error) {
+	return canonicalJSON(r)
+}
+
+// Display writes a table of the query results followed by their errors and
+// warnings
+func (r *queryRunReport) Display(w io.Writer) {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(table, "OBJECT\tQUERY\tTYPE\tSTATUS\tMESSAGES\tRECORDS\tSERIES")
+	for _, result := range r.Results {
+		fmt.Fprintf(table, "%s %s\t%s\t%s\t%s\t%d\t%d\t%d\n", result.ObjectType, result.Key, result.QueryKey, result.QueryType, result.Status, result.Messages, result.Records, result.Series)
+	}
+	table.Flush()
+
+	for _, result := range r.Results {
+		name := result.ObjectType + " " + result.Key
+		if result.QueryKey != "" {
+			name += " query " + result.QueryKey
+		}
+
+		if result.Error != "" {
+			fmt.Fprintf(w, "\n%s: %s\n", name, result.Error)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(w, "\n%s: warning: %s\n", name, warning)
+		}
+	}
+}
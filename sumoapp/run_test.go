@@ -0,0 +1,229 @@
+package sumoapp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubstituteVariables(t *testing.T) {
+	values := map[string]string{
+		"host":      "web-1",
+		"env":       "prod",
+		"empty":     "",
+		"dotted.id": "42",
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "no variables",
+			query: "error | count",
+			want:  "error | count",
+		},
+		{
+			name:  "one variable",
+			query: "_sourceHost={{host}} error",
+			want:  "_sourceHost=web-1 error",
+		},
+		{
+			name:  "spaces inside braces",
+			query: "_sourceHost={{ host }} _sourceCategory={{env}}/app",
+			want:  "_sourceHost=web-1 _sourceCategory=prod/app",
+		},
+		{
+			name:  "repeated variable",
+			query: "{{env}} or {{env}}",
+			want:  "prod or prod",
+		},
+		{
+			name:  "empty value",
+			query: "error {{empty}}",
+			want:  "error ",
+		},
+		{
+			name:  "dotted name",
+			query: "id={{dotted.id}}",
+			want:  "id=42",
+		},
+		{
+			name:    "missing variable",
+			query:   "_sourceHost={{host}} {{region}} {{zone}}",
+			wantErr: true,
+		},
+		{
+			name:  "single braces aren't variables",
+			query: "parse \"{*}\" as body",
+			want:  "parse \"{*}\" as body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := substituteVariables(tt.query, values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("substituteVariables(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("substituteVariables(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	_, err := substituteVariables("{{zone}} {{region}}", values)
+	if err == nil || err.Error() != "No value for variable(s) region, zone. Set them with --var name=value" {
+		t.Errorf("missing variables error = %v, want them listed in order", err)
+	}
+}
+
+func TestSavedSearchQuery(t *testing.T) {
+	s := NewAppOverlay("final", NewApplication())
+	s.SavedSearches["errors"] = &savedSearch{
+		Name: "Errors",
+		Search: search{
+			QueryText:        "_sourceCategory={{category}} {{term}}",
+			DefaultTimeRange: "-1h",
+			QueryParameters: []interface{}{
+				map[interface{}]interface{}{"name": "category", "value": "prod/app"},
+				map[interface{}]interface{}{"name": "term", "value": "error"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		vars    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "parameter defaults",
+			key:  "errors",
+			want: "_sourceCategory=prod/app error",
+		},
+		{
+			name: "vars override parameters",
+			key:  "errors",
+			vars: map[string]string{"term": "timeout"},
+			want: "_sourceCategory=prod/app timeout",
+		},
+		{
+			name:    "unknown saved search",
+			key:     "missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := s.SavedSearchQuery(tt.key, tt.vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SavedSearchQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if q.Query != tt.want {
+				t.Errorf("Query = %q, want %q", q.Query, tt.want)
+			}
+
+			if q.From != "-1h" || q.QueryType != LogsQueryType {
+				t.Errorf("query = %+v, want a logs query from -1h", q)
+			}
+		})
+	}
+}
+
+func TestRelativeBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		tr       *timerange
+		from, to string
+		wantErr  bool
+	}{
+		{
+			name: "no time range",
+		},
+		{
+			name: "open ended",
+			tr:   &timerange{From: &timeBoundary{RelativeTime: "-1h"}},
+			from: "-1h",
+			to:   "now",
+		},
+		{
+			name: "relative bounds",
+			tr:   &timerange{From: &timeBoundary{RelativeTime: "-2d"}, To: &timeBoundary{RelativeTime: "-1d"}},
+			from: "-2d",
+			to:   "-1d",
+		},
+		{
+			name:    "absolute bound",
+			tr:      &timerange{From: &timeBoundary{Type: "EpochTimeRangeBoundary"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := tt.tr.relativeBounds()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("relativeBounds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if from != tt.from || to != tt.to {
+				t.Errorf("relativeBounds() = %q, %q, want %q, %q", from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestRunnableQueryTimeRange(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		q         runnableQuery
+		from, to  string
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "default range",
+			wantStart: now.Add(-15 * time.Minute),
+			wantEnd:   now,
+		},
+		{
+			name:      "query's range",
+			q:         runnableQuery{From: "-1h", To: "-30m"},
+			wantStart: now.Add(-time.Hour),
+			wantEnd:   now.Add(-30 * time.Minute),
+		},
+		{
+			name:      "overridden start",
+			q:         runnableQuery{From: "-1h", To: "-30m"},
+			from:      "-2h",
+			wantStart: now.Add(-2 * time.Hour),
+			wantEnd:   now.Add(-30 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := tt.q.TimeRange(tt.from, tt.to, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("TimeRange() = %v, %v, want %v, %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
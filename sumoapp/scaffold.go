@@ -0,0 +1,433 @@
+package sumoapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// AppOverlayNames lists the overlays of an application, from the
+// bottom of the stack to the top
+var AppOverlayNames = []string{"base", "middle", "final"}
+
+// ComponentDirectories lists the directories each overlay keeps its
+// component files in
+var ComponentDirectories = []string{"dashboards", "folders", "monitors", "panels", "parsers", "saved-searches", "variables"}
+
+// ComponentKinds lists the kinds of components that can be generated
+// with NewComponent
+var ComponentKinds = []string{"dashboard", "panel", "variable", "saved-search", "folder", "monitor", "parser"}
+
+// Init creates the directory tree of a new application and writes the
+// application's definition to the base overlay's init.yaml file
+func (a *application) Init(name string, description string) error {
+	initPath := filepath.Join(a.path, AppOverlayNames[0], "init.yaml")
+	if _, err := os.Stat(initPath); err == nil {
+		return fmt.Errorf("An application already exists at %s", a.path)
+	}
+
+	for _, overlayName := range AppOverlayNames {
+		for _, dir := range ComponentDirectories {
+			dirPath := filepath.Join(a.path, overlayName, dir)
+			if err := os.MkdirAll(dirPath, 0755); err != nil {
+				return err
+			}
+		}
+	}
+
+	a.Name = name
+	a.Description = description
+
+	return writeYamlFile(initPath, a)
+}
+
+// NewComponent writes a YAML stub for a new component of the given kind
+// to the overlay and returns the key the component was written under.
+// Dashboards, saved searches, and folders are added to the Items of the
+// parent folder, or the application's root folder if parent is empty.
+// Panels and variables are added to the dashboard named by parent, if any.
+// Monitors are put in the monitors folder path given by parent. Parsers
+// have no parent
+func (s *appOverlay) NewComponent(kind string, name string, parent string) (string, error) {
+	var (
+		key    string
+		dir    string
+		object interface{}
+	)
+
+	switch kind {
+	case "dashboard":
+		key = sanitizeName(name)
+		dir = "dashboards"
+		if _, ok := s.Dashboards[key]; ok {
+			return "", fmt.Errorf("Dashboard '%s' already exists", key)
+		}
+
+		object = &dashboard{
+			Name:  name,
+			Title: name,
+			Theme: "Dark",
+			TimeRange: &timerange{
+				Type: "BeginBoundedTimeRange",
+				From: &timeBoundary{
+					Type:         "RelativeTimeRangeBoundary",
+					RelativeTime: "-15m",
+				},
+			},
+			Layout: layout{
+				LayoutType:       "Grid",
+				LayoutStructures: make([]layoutStructure, 0),
+			},
+		}
+
+	case "panel":
+		key = sanitizeName(name)
+		dir = "panels"
+		if _, ok := s.Panels[key]; ok {
+			return "", fmt.Errorf("Panel '%s' already exists", key)
+		}
+
+		object = &panel{
+			Key:                                    key,
+			Title:                                  name,
+			VisualSettings:                         "{}",
+			KeepVisualSettingsConsistentWithParent: true,
+			PanelType:                              "SumoSearchPanel",
+			Queries: []query{
+				{
+					QueryType: "Logs",
+					QueryKey:  "A",
+				},
+			},
+		}
+
+	case "variable":
+		//Variable keys are referenced in queries as {{name}}, so they
+		//are used verbatim
+		key = name
+		dir = "variables"
+		if _, ok := s.Variables[key]; ok {
+			return "", fmt.Errorf("Variable '%s' already exists", key)
+		}
+
+		object = &variable{
+			Name:         name,
+			DisplayName:  name,
+			DefaultValue: "*",
+			SourceDefinition: sourceDefinition{
+				VariableSourceType: "CsvVariableSourceDefinition",
+			},
+			IncludeAllOption: true,
+			ValueType:        "Any",
+		}
+
+	case "saved-search":
+		key = sanitizeName(name)
+		dir = "saved-searches"
+		if _, ok := s.SavedSearches[key]; ok {
+			return "", fmt.Errorf("Saved search '%s' already exists", key)
+		}
+
+		object = &savedSearch{
+			Name: name,
+			Search: search{
+				DefaultTimeRange: "-15m",
+				QueryParameters:  make([]interface{}, 0),
+				ParsingMode:      "Manual",
+			},
+		}
+
+	case "folder":
+		key = sanitizeName(name)
+		dir = "folders"
+		if _, ok := s.Folders[key]; ok {
+			return "", fmt.Errorf("Folder '%s' already exists", key)
+		}
+
+		object = &folder{
+			Name:  name,
+			Items: make(map[string][]string),
+		}
+
+	case "monitor":
+		key = sanitizeName(name)
+		dir = "monitors"
+		if _, ok := s.Monitors[key]; ok {
+			return "", fmt.Errorf("Monitor '%s' already exists", key)
+		}
+
+		object = &monitor{
+			Name:        name,
+			Folder:      parent,
+			MonitorType: LogsQueryType,
+			Queries: []monitorQuery{
+				{
+					RowId: "A",
+					Query: "_sourceCategory=*",
+				},
+			},
+			Triggers: []monitorTrigger{
+				{
+					TriggerType:   "Critical",
+					TimeRange:     "-15m",
+					Threshold:     0,
+					ThresholdType: "GreaterThan",
+				},
+				{
+					TriggerType:   "ResolvedCritical",
+					TimeRange:     "-15m",
+					Threshold:     0,
+					ThresholdType: "LessThanOrEqual",
+				},
+			},
+			Notifications: make([]monitorNotification, 0),
+		}
+
+	case "parser":
+		key = sanitizeName(name)
+		dir = "parsers"
+		if _, ok := s.Parsers[key]; ok {
+			return "", fmt.Errorf("Parser '%s' already exists", key)
+		}
+
+		if parent != "" {
+			return "", fmt.Errorf("Parsers are kept in the application's parsers folder and can't have a parent")
+		}
+
+		object = &parser{
+			Name: name,
+			Code: "[Default]\nFORMAT = JSON\n",
+		}
+
+	default:
+		return "", fmt.Errorf("Unknown component kind '%s'. Expected one of: %s", kind, strings.Join(ComponentKinds, ", "))
+	}
+
+	//Check the parent exists before anything is written so a missing
+	//parent doesn't leave an orphaned file behind. Monitor folders are
+	//created when the monitors are pushed
+	if parent != "" && kind != "monitor" {
+		if err := s.checkParent(kind, parent); err != nil {
+			return "", err
+		}
+	}
+
+	filePath := filepath.Join(s.Path, dir, key+".yaml")
+	if _, err := os.Stat(filePath); err == nil {
+		return "", fmt.Errorf("File %s already exists", filePath)
+	}
+
+	if err := writeYamlFile(filePath, map[string]interface{}{key: object}); err != nil {
+		return "", err
+	}
+
+	if err := s.wireComponent(kind, key, parent); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (s *appOverlay) wireComponent(kind string, key string, parent string) error {
+	switch kind {
+	case "dashboard":
+		return s.addFolderItem(parent, "dashboards", key)
+	case "saved-search":
+		return s.addFolderItem(parent, "savedSearches", key)
+	case "folder":
+		return s.addFolderItem(parent, "folders", key)
+	case "panel":
+		if parent != "" {
+			return s.addDashboardPanel(parent, key)
+		}
+	case "variable":
+		if parent != "" {
+			return s.addDashboardVariable(parent, key)
+		}
+	}
+
+	return nil
+}
+
+func (s *appOverlay) checkParent(kind string, parent string) error {
+	switch kind {
+	case "panel", "variable":
+		_, err := s.FindDashboard(parent)
+		return err
+	default:
+		_, err := s.FindFolder(parent)
+		return err
+	}
+}
+
+// addFolderItem appends key to the itemType list of the parent folder's
+// Items. An overlay's Items replace the ones of its parent overlay, so the
+// complete merged list is written to this overlay
+func (s *appOverlay) addFolderItem(parent string, itemType string, key string) error {
+	var (
+		items    map[string][]string
+		filePath string
+		yamlPath []string
+	)
+
+	if parent == "" {
+		if s.RootFolder != nil {
+			items = s.RootFolder.Items
+		}
+
+		filePath = filepath.Join(s.Path, "init.yaml")
+		yamlPath = []string{"items", itemType}
+	} else {
+		folderObj, err := s.FindFolder(parent)
+		if err != nil {
+			return err
+		}
+
+		items = folderObj.Items
+		filePath = s.componentFile("folders", parent)
+		yamlPath = []string{parent, "items", itemType}
+	}
+
+	list := make([]string, 0)
+	for _, item := range items[itemType] {
+		if item != key {
+			list = append(list, item)
+		}
+	}
+	list = append(list, key)
+
+	return updateYamlFile(filePath, yamlPath, list)
+}
+
+// addDashboardPanel adds the panel to the bottom of the dashboard's layout
+func (s *appOverlay) addDashboardPanel(dashboardName string, key string) error {
+	dash, err := s.FindDashboard(dashboardName)
+	if err != nil {
+		return err
+	}
+
+	bottom := 0
+	structures := make([]layoutStructure, 0)
+	for _, ls := range dash.Layout.LayoutStructures {
+		if ls.Key == key {
+			continue
+		}
+
+		var position struct {
+			Y      int `json:"y"`
+			Height int `json:"height"`
+		}
+		if err := json.Unmarshal([]byte(ls.Structure), &position); err == nil {
+			if position.Y+position.Height > bottom {
+				bottom = position.Y + position.Height
+			}
+		}
+
+		structures = append(structures, ls)
+	}
+
+	structures = append(structures, layoutStructure{
+		Key:       key,
+		Structure: fmt.Sprintf(`{"height":6,"width":24,"x":0,"y":%d}`, bottom),
+	})
+
+	filePath := s.componentFile("dashboards", dashboardName)
+	return updateYamlFile(filePath, []string{dashboardName, "layout", "layoutstructures"}, structures)
+}
+
+// addDashboardVariable adds the variable to the dashboard's IncludeVariables
+func (s *appOverlay) addDashboardVariable(dashboardName string, key string) error {
+	dash, err := s.FindDashboard(dashboardName)
+	if err != nil {
+		return err
+	}
+
+	variables := make([]string, 0)
+	for _, v := range dash.IncludeVariables {
+		if v != key {
+			variables = append(variables, v)
+		}
+	}
+	variables = append(variables, key)
+
+	filePath := s.componentFile("dashboards", dashboardName)
+	return updateYamlFile(filePath, []string{dashboardName, "includevariables"}, variables)
+}
+
+// componentFile returns the file in this overlay's component directory
+// that defines key. If no file defines it, the conventional <key>.yaml
+// file name is returned
+func (s *appOverlay) componentFile(dir string, key string) string {
+	dirPath := filepath.Join(s.Path, dir)
+
+	files, _ := ioutil.ReadDir(dirPath)
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".yaml" {
+			continue
+		}
+
+		var doc map[string]interface{}
+		data, err := os.ReadFile(filepath.Join(dirPath, file.Name()))
+		if err != nil {
+			continue
+		}
+
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			continue
+		}
+
+		if _, ok := doc[key]; ok {
+			return filepath.Join(dirPath, file.Name())
+		}
+	}
+
+	return filepath.Join(dirPath, key+".yaml")
+}
+
+// updateYamlFile sets the value at yamlPath in the YAML file, creating
+// the file and any missing mappings along the way. The order and content
+// of everything else in the file is preserved
+func updateYamlFile(filePath string, yamlPath []string, value interface{}) error {
+	var doc yaml.MapSlice
+
+	data, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("Unable to decode %s: %w", filePath, err)
+	}
+
+	doc = setMapSliceValue(doc, yamlPath, value)
+
+	return writeYamlFile(filePath, doc)
+}
+
+func setMapSliceValue(doc yaml.MapSlice, yamlPath []string, value interface{}) yaml.MapSlice {
+	for i, item := range doc {
+		if item.Key != yamlPath[0] {
+			continue
+		}
+
+		if len(yamlPath) == 1 {
+			doc[i].Value = value
+		} else {
+			child, _ := item.Value.(yaml.MapSlice)
+			doc[i].Value = setMapSliceValue(child, yamlPath[1:], value)
+		}
+
+		return doc
+	}
+
+	if len(yamlPath) == 1 {
+		return append(doc, yaml.MapItem{Key: yamlPath[0], Value: value})
+	}
+
+	return append(doc, yaml.MapItem{Key: yamlPath[0], Value: setMapSliceValue(nil, yamlPath[1:], value)})
+}
@@ -0,0 +1,248 @@
+package sumoapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parsing modes of a scheduled view
+const (
+	ViewParsingManual    = "Manual"
+	ViewParsingAutoParse = "AutoParse"
+)
+
+// scheduledViewsPageSize is the largest page the Scheduled Views API
+// returns
+const scheduledViewsPageSize = 1000
+
+// scheduledView is a scheduled view, the indexed results of a query that
+// queries search with _view=name. Views are matched with the views in the
+// org by index name. StartTime is an RFC 3339 timestamp. The parsing mode
+// defaults to Manual
+type scheduledView struct {
+	Id               string `json:"id,omitempty" yaml:"-" diff:"-"`
+	IndexName        string `json:"indexName"`
+	Query            string `json:"query"`
+	StartTime        string `json:"startTime"`
+	RetentionPeriod  int    `json:"retentionPeriod"`
+	ParsingMode      string `json:"parsingMode,omitempty" yaml:",omitempty"`
+	DataForwardingId string `json:"dataForwardingId,omitempty" yaml:",omitempty"`
+}
+
+type scheduledViewPage struct {
+	Data []*scheduledView `json:"data"`
+	Next string           `json:"next"`
+}
+
+// ReadScheduledViews reads the view definitions in a YAML file, or in
+// every YAML file of a directory. Files map a key to each view. The views
+// are returned keyed by index name
+func ReadScheduledViews(path string) (map[string]*scheduledView, error) {
+	files, err := definitionFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make(map[string]*scheduledView)
+	keys := make(map[string]string)
+
+	for _, file := range files {
+		var curList map[string]*scheduledView
+		if err := readYamlFile(file, &curList); err != nil {
+			return nil, err
+		}
+
+		for _, key := range sortedKeys(curList) {
+			v := curList[key]
+			if v == nil {
+				return nil, fmt.Errorf("View '%s' in %s is empty", key, file)
+			}
+
+			if other, ok := keys[key]; ok {
+				return nil, fmt.Errorf("View '%s' is defined in both %s and %s", key, other, file)
+			}
+			keys[key] = file
+
+			if v.ParsingMode == "" {
+				v.ParsingMode = ViewParsingManual
+			}
+
+			if err := v.Validate(); err != nil {
+				return nil, fmt.Errorf("Invalid view '%s' in %s: %w", key, file, err)
+			}
+
+			if _, ok := views[v.IndexName]; ok {
+				return nil, fmt.Errorf("More than one view is named '%s'. Views are matched by index name, so names must be unique", v.IndexName)
+			}
+
+			views[v.IndexName] = v
+		}
+	}
+
+	return views, nil
+}
+
+// Validate checks the view has everything the Scheduled Views API needs,
+// and puts its start time in the format the API returns it in
+func (v *scheduledView) Validate() error {
+	modes := []string{ViewParsingManual, ViewParsingAutoParse}
+
+	switch {
+	case v.IndexName == "":
+		return fmt.Errorf("The view has no index name")
+	case v.Query == "":
+		return fmt.Errorf("The view has no query")
+	case v.StartTime == "":
+		return fmt.Errorf("The view has no start time")
+	case v.RetentionPeriod <= 0:
+		return fmt.Errorf("The view's retention period must be a positive number of days")
+	case !containsString(modes, v.ParsingMode):
+		return fmt.Errorf("Unknown parsing mode '%s'. Expected one of: %s", v.ParsingMode, strings.Join(modes, ", "))
+	}
+
+	startTime, err := normalizeViewStartTime(v.StartTime)
+	if err != nil {
+		return err
+	}
+	v.StartTime = startTime
+
+	return nil
+}
+
+// normalizeViewStartTime formats an RFC 3339 timestamp in UTC, so start
+// times compare equal however they're written
+func normalizeViewStartTime(value string) (string, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("The start time must be an RFC 3339 timestamp, such as 2022-01-01T00:00:00Z: %w", err)
+	}
+
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// ListScheduledViews returns every scheduled view in the org, keyed by
+// index name
+func ListScheduledViews(ctx context.Context, a *APIClient) (map[string]*scheduledView, error) {
+	views := make(map[string]*scheduledView)
+
+	token := ""
+	for {
+		query := url.Values{}
+		query.Add("limit", strconv.Itoa(scheduledViewsPageSize))
+		if token != "" {
+			query.Add("token", token)
+		}
+
+		var page scheduledViewPage
+		if err := a.requestJSON(ctx, "GET", "/v1/scheduledViews", query, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, v := range page.Data {
+			if startTime, err := normalizeViewStartTime(v.StartTime); err == nil {
+				v.StartTime = startTime
+			}
+
+			views[v.IndexName] = v
+		}
+
+		if page.Next == "" {
+			return views, nil
+		}
+		token = page.Next
+	}
+}
+
+// WriteScheduledViews writes each view to its own file in dir, named after
+// the view
+func WriteScheduledViews(views map[string]*scheduledView, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	names := make(map[string]string)
+	for _, name := range sortedKeys(views) {
+		key := sanitizeName(name)
+		if other, ok := names[key]; ok {
+			return fmt.Errorf("Views '%s' and '%s' would be written to the same file %s.yaml", other, name, key)
+		}
+		names[key] = name
+
+		filePath := filepath.Join(dir, key+".yaml")
+		if err := writeYamlFile(filePath, map[string]*scheduledView{key: views[name]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scheduledViewPlan is a plan for the scheduled views of the org along
+// with the views it was made from
+type scheduledViewPlan struct {
+	*resourcePlan
+	current map[string]*scheduledView
+	desired map[string]*scheduledView
+}
+
+// PlanScheduledViews compares the views defined at path with the views in
+// the org. With prune, views that aren't defined are disabled
+func PlanScheduledViews(ctx context.Context, a *APIClient, path string, prune bool) (*scheduledViewPlan, error) {
+	desired, err := ReadScheduledViews(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := ListScheduledViews(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := newResourcePlan("view", current, desired, prune)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduledViewPlan{
+		resourcePlan: p,
+		current:      current,
+		desired:      desired,
+	}, nil
+}
+
+// Apply creates, updates, and disables the views the plan changes. Only a
+// view's retention period and data forwarding can change once it's
+// created, so nothing is applied when the plan changes anything else
+func (p *scheduledViewPlan) Apply(ctx context.Context, a *APIClient, w io.Writer) error {
+	if err := p.checkImmutable("Query", "StartTime", "ParsingMode"); err != nil {
+		return err
+	}
+
+	return p.apply(w, func(action planAction) error {
+		switch action.Action {
+		case PlanCreate:
+			body := *p.desired[action.Name]
+			body.Id = ""
+			return a.requestJSON(ctx, "POST", "/v1/scheduledViews", nil, body, nil)
+		case PlanUpdate:
+			desired := p.desired[action.Name]
+			body := map[string]interface{}{
+				"retentionPeriod":                  desired.RetentionPeriod,
+				"dataForwardingId":                 desired.DataForwardingId,
+				"reduceRetentionPeriodImmediately": false,
+			}
+			return a.requestJSON(ctx, "PUT", "/v1/scheduledViews/"+p.current[action.Name].Id, nil, body, nil)
+		case PlanDelete:
+			return a.requestJSON(ctx, "DELETE", "/v1/scheduledViews/"+p.current[action.Name].Id+"/disable", nil, nil, nil)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,127 @@
+package sumoapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// componentSchemaTypes maps each overlay component name to the type
+// its YAML files are decoded into. The component name matches the
+// directory the files live in
+var componentSchemaTypes = map[string]reflect.Type{
+	"dashboards":     reflect.TypeOf(dashboard{}),
+	"panels":         reflect.TypeOf(panel{}),
+	"variables":      reflect.TypeOf(variable{}),
+	"saved-searches": reflect.TypeOf(savedSearch{}),
+	"folders":        reflect.TypeOf(folder{}),
+	"monitors":       reflect.TypeOf(monitor{}),
+	"parsers":        reflect.TypeOf(parser{}),
+}
+
+// SchemaComponents returns the names of the overlay components a
+// JSON Schema can be generated for
+func SchemaComponents() []string {
+	names := make([]string, 0, len(componentSchemaTypes)+1)
+	for name := range componentSchemaTypes {
+		names = append(names, name)
+	}
+	names = append(names, "init")
+	sort.Strings(names)
+
+	return names
+}
+
+// ComponentSchema returns the JSON Schema for a YAML file in the given
+// overlay component directory. Component files are maps keyed by the
+// object's name, so the object schema is used for every property. The
+// special component 'init' describes an overlay's init.yaml file
+func ComponentSchema(component string) ([]byte, error) {
+	var schema map[string]interface{}
+
+	if component == "init" {
+		schema = typeSchema(reflect.TypeOf(application{}))
+	} else {
+		t, ok := componentSchemaTypes[component]
+		if !ok {
+			return nil, fmt.Errorf("Unknown component '%s'. Expected one of: %s", component, strings.Join(SchemaComponents(), ", "))
+		}
+
+		schema = map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t),
+		}
+	}
+
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = fmt.Sprintf("Sumo Logic application %s", component)
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema builds the JSON Schema of a Go type as the YAML decoder
+// sees it. Struct properties are named after their yaml tags and fall
+// back to the lowercased field name, which is the yaml.v2 default
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem()),
+		}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name := yamlFieldName(field)
+			if name == "-" {
+				continue
+			}
+
+			properties[name] = typeSchema(field.Type)
+		}
+
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	}
+
+	//interface{} and anything else accepts any value
+	return map[string]interface{}{}
+}
+
+func yamlFieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("yaml")
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+
+	return strings.ToLower(field.Name)
+}
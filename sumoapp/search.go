@@ -3,9 +3,20 @@ package sumoapp
 import "github.com/imdario/mergo"
 
 func (s *savedSearch) Merge(search *savedSearch) error {
-	if err := mergo.Merge(s, search, mergo.WithOverride); err != nil {
+	newSearch := search.Copy()
+
+	if err := mergo.Merge(newSearch, s, mergo.WithOverride); err != nil {
+		return err
+	}
+
+	if err := mergo.Merge(s, newSearch, mergo.WithOverride); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (s *savedSearch) Copy() *savedSearch {
+	newSearch := *s
+	return &newSearch
+}
@@ -0,0 +1,351 @@
+package sumoapp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Search job states reported by the Search Job API
+const (
+	SearchJobNotStarted  = "NOT STARTED"
+	SearchJobGathering   = "GATHERING RESULTS"
+	SearchJobDone        = "DONE GATHERING RESULTS"
+	SearchJobCancelled   = "CANCELLED"
+	SearchJobForcePaused = "FORCE PAUSED"
+)
+
+// Result modes of a search. Messages are the raw log lines a search
+// matched, records are the rows an aggregating search produced
+const (
+	SearchModeAuto     = "auto"
+	SearchModeMessages = "messages"
+	SearchModeRecords  = "records"
+)
+
+// searchPageSize is the largest page the Search Job API returns
+const searchPageSize = 10000
+
+// searchPollInterval is how long to wait between search job status checks
+var searchPollInterval = 2 * time.Second
+
+// aggregateOperators are the search operators that turn messages into
+// records
+var aggregateOperators = []string{
+	"avg", "count", "count_distinct", "count_frequent", "fillmissing",
+	"first", "last", "least_recent", "max", "min", "most_recent", "pct",
+	"stddev", "sum", "top", "transpose",
+}
+
+var relativeTimePattern = regexp.MustCompile(`^-((\d+[smhdw])+)$`)
+var relativeTimePartPattern = regexp.MustCompile(`(\d+)([smhdw])`)
+
+// epochMillisPattern matches epoch milliseconds, which have 13 digits from
+// 2001 to 2286. Shorter numbers, such as a compact date like 20260101,
+// aren't taken for epoch times
+var epochMillisPattern = regexp.MustCompile(`^\d{13}$`)
+
+type searchJobRequest struct {
+	Query         string `json:"query"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	TimeZone      string `json:"timeZone"`
+	ByReceiptTime bool   `json:"byReceiptTime"`
+}
+
+type searchJobStatus struct {
+	State           string   `json:"state"`
+	MessageCount    int      `json:"messageCount"`
+	RecordCount     int      `json:"recordCount"`
+	PendingWarnings []string `json:"pendingWarnings"`
+	PendingErrors   []string `json:"pendingErrors"`
+}
+
+type searchJobField struct {
+	Name      string `json:"name"`
+	FieldType string `json:"fieldType"`
+	KeyField  bool   `json:"keyField"`
+}
+
+type searchJobRow struct {
+	Map map[string]string `json:"map"`
+}
+
+type searchJobPage struct {
+	Fields   []searchJobField `json:"fields"`
+	Messages []searchJobRow   `json:"messages"`
+	Records  []searchJobRow   `json:"records"`
+}
+
+// searchJob is a running search created with the Search Job API. Jobs hold
+// resources until they're deleted, so Delete must be called when done
+type searchJob struct {
+	Id       string `json:"id"`
+	Query    string `json:"-"`
+	Warnings []string
+	client   *APIClient
+}
+
+// NewSearchJob starts a search job for query over the time range from-to
+func NewSearchJob(ctx context.Context, a *APIClient, query string, from time.Time, to time.Time, byReceiptTime bool) (*searchJob, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("The search's start time (%s) must be before its end time (%s)", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	request := searchJobRequest{
+		Query:         query,
+		From:          strconv.FormatInt(from.UnixNano()/int64(time.Millisecond), 10),
+		To:            strconv.FormatInt(to.UnixNano()/int64(time.Millisecond), 10),
+		TimeZone:      "UTC",
+		ByReceiptTime: byReceiptTime,
+	}
+
+	job := &searchJob{
+		Query:  query,
+		client: a,
+	}
+
+	if err := a.requestJSON(ctx, "POST", "/v1/search/jobs", nil, request, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Status returns the job's state and how many results it has gathered
+func (j *searchJob) Status(ctx context.Context) (*searchJobStatus, error) {
+	status := &searchJobStatus{}
+	if err := j.client.requestJSON(ctx, "GET", "/v1/search/jobs/"+j.Id, nil, nil, status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// Page returns up to limit messages or records starting at offset
+func (j *searchJob) Page(ctx context.Context, mode string, offset int, limit int) (*searchJobPage, error) {
+	query := url.Values{}
+	query.Add("offset", strconv.Itoa(offset))
+	query.Add("limit", strconv.Itoa(limit))
+
+	page := &searchJobPage{}
+	if err := j.client.requestJSON(ctx, "GET", "/v1/search/jobs/"+j.Id+"/"+mode, query, nil, page); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Delete deletes the job. It doesn't take the search's context so a job
+// can still be deleted after the search is interrupted
+func (j *searchJob) Delete() error {
+	return j.client.requestJSON(context.Background(), "DELETE", "/v1/search/jobs/"+j.Id, nil, nil, nil)
+}
+
+// Stream polls the job until it's done and passes its results to handle as
+// they become available. Messages are streamed while the job gathers them.
+// Records are only final once the job is done, so they're passed to handle
+// at the end. With SearchModeAuto, aggregating queries return records and
+// other queries return messages. Streaming stops after limit results when
+// limit is above 0
+func (j *searchJob) Stream(ctx context.Context, mode string, limit int, handle func(fields []searchJobField, rows []map[string]string) error) error {
+	if mode == SearchModeAuto {
+		mode = SearchModeMessages
+		if IsAggregateQuery(j.Query) {
+			mode = SearchModeRecords
+		}
+	}
+
+	if mode != SearchModeMessages && mode != SearchModeRecords {
+		return fmt.Errorf("Unknown search mode '%s'. Expected one of: %s, %s, %s", mode, SearchModeAuto, SearchModeMessages, SearchModeRecords)
+	}
+
+	offset := 0
+	for {
+		status, err := j.poll(ctx)
+		if err != nil {
+			return err
+		}
+
+		done := status.State == SearchJobDone
+
+		available := status.MessageCount
+		if mode == SearchModeRecords {
+			available = status.RecordCount
+		}
+
+		if limit > 0 && available > limit {
+			available = limit
+		}
+
+		//Records can still change while the job is gathering results
+		if mode == SearchModeMessages || done {
+			for offset < available {
+				pageSize := available - offset
+				if pageSize > searchPageSize {
+					pageSize = searchPageSize
+				}
+
+				page, err := j.Page(ctx, mode, offset, pageSize)
+				if err != nil {
+					return err
+				}
+
+				rows := page.Messages
+				if mode == SearchModeRecords {
+					rows = page.Records
+				}
+
+				if len(rows) == 0 {
+					break
+				}
+
+				maps := make([]map[string]string, len(rows))
+				for i, row := range rows {
+					maps[i] = row.Map
+				}
+
+				if err := handle(page.Fields, maps); err != nil {
+					return err
+				}
+
+				offset += len(rows)
+			}
+		}
+
+		if done || (limit > 0 && offset >= limit) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(searchPollInterval):
+		}
+	}
+}
+
+// Wait polls the job until it's done and returns its final status
+func (j *searchJob) Wait(ctx context.Context) (*searchJobStatus, error) {
+	for {
+		status, err := j.poll(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if status.State == SearchJobDone {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(searchPollInterval):
+		}
+	}
+}
+
+// poll returns the job's status. The job's warnings are collected, and its
+// errors, or the job being stopped, are returned as an error
+func (j *searchJob) poll(ctx context.Context) (*searchJobStatus, error) {
+	status, err := j.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(status.PendingErrors) > 0 {
+		return nil, fmt.Errorf("Search failed: %s", strings.Join(status.PendingErrors, "; "))
+	}
+
+	j.Warnings = append(j.Warnings, status.PendingWarnings...)
+
+	switch status.State {
+	case SearchJobCancelled:
+		return nil, fmt.Errorf("The search job was cancelled")
+	case SearchJobForcePaused:
+		return nil, fmt.Errorf("The search job was paused because it found too many results. Narrow the query or time range")
+	}
+
+	return status, nil
+}
+
+// IsAggregateQuery reports whether a query has an aggregating stage, in
+// which case its results are records rather than messages
+func IsAggregateQuery(query string) bool {
+	stages := splitQueryStages(query)
+	if len(stages) < 2 {
+		return false
+	}
+
+	for _, stage := range stages[1:] {
+		fields := strings.Fields(strings.TrimPrefix(stage, "|"))
+		if len(fields) == 0 {
+			continue
+		}
+
+		operator := strings.ToLower(fields[0])
+		if i := strings.Index(operator, "("); i >= 0 {
+			operator = operator[:i]
+		}
+
+		if containsString(aggregateOperators, operator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseSearchTime parses the start or end of a search's time range. It
+// accepts "now", times relative to now such as -15m, -1h30m, -2d, and -1w,
+// RFC 3339 times, dates, and 13 digit epoch milliseconds
+func ParseSearchTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if value == "now" {
+		return now, nil
+	}
+
+	if relativeTimePattern.MatchString(value) {
+		var offset time.Duration
+		for _, part := range relativeTimePartPattern.FindAllStringSubmatch(value, -1) {
+			n, err := strconv.Atoi(part[1])
+			if err != nil {
+				return time.Time{}, err
+			}
+
+			unit := map[string]time.Duration{
+				"s": time.Second,
+				"m": time.Minute,
+				"h": time.Hour,
+				"d": 24 * time.Hour,
+				"w": 7 * 24 * time.Hour,
+			}[part[2]]
+
+			offset += time.Duration(n) * unit
+		}
+
+		return now.Add(-offset), nil
+	}
+
+	if epochMillisPattern.MatchString(value) {
+		millis, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(0, millis*int64(time.Millisecond)), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Unable to parse time '%s'. Use now, a relative time such as -15m, -1h, or -2d, an RFC 3339 time, a date such as 2006-01-02, or 13 digit epoch milliseconds", value)
+}
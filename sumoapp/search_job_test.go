@@ -0,0 +1,72 @@
+package sumoapp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsAggregateQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"_sourceCategory=prod error", false},
+		{"count", false},
+		{"error | count", true},
+		{"error | count by _sourceHost", true},
+		{"error | COUNT_DISTINCT(user)", true},
+		{"error | avg(latency) by host", true},
+		{"error | parse \"took *ms\" as ms | pct(ms, 95)", true},
+		{"error | parse \"a=*\" as a | where a > 1", false},
+		{"error | where msg = \"x | count\"", false},
+		{"error | fields count", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAggregateQuery(tt.query); got != tt.want {
+			t.Errorf("IsAggregateQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseSearchTime(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "now", want: now},
+		{value: " now ", want: now},
+		{value: "-15m", want: now.Add(-15 * time.Minute)},
+		{value: "-1h30m", want: now.Add(-90 * time.Minute)},
+		{value: "-2d", want: now.Add(-48 * time.Hour)},
+		{value: "-1w", want: now.Add(-7 * 24 * time.Hour)},
+		{value: "-30s", want: now.Add(-30 * time.Second)},
+		{value: "2026-10-19T10:00:00Z", want: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)},
+		{value: "2026-10-19T10:00:00+02:00", want: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)},
+		{value: "2026-10-19T10:00:00", want: time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)},
+		{value: "2026-10-19 10:00:00", want: time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)},
+		{value: "2026-10-19", want: time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)},
+		{value: "1792368000000", want: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{value: "20260101", wantErr: true},
+		{value: "20260101120000", wantErr: true},
+		{value: "15m", wantErr: true},
+		{value: "-15x", wantErr: true},
+		{value: "yesterday", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSearchTime(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSearchTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+
+		if err == nil && !got.Equal(tt.want) {
+			t.Errorf("ParseSearchTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
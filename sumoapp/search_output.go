@@ -0,0 +1,168 @@
+package sumoapp
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// SearchOutputFormats lists the formats search results can be written in
+var SearchOutputFormats = []string{"jsonl", "csv", "table"}
+
+// searchTimeFields hold epoch milliseconds. Tables show them as times
+var searchTimeFields = []string{"_messagetime", "_receipttime", "_timeslice"}
+
+// searchResultWriter writes pages of search results as they arrive. The
+// columns are taken from the first page unless they're chosen up front
+type searchResultWriter struct {
+	format  string
+	columns []string
+	csv     *csv.Writer
+	table   *tabwriter.Writer
+	w       io.Writer
+	started bool
+}
+
+// NewSearchResultWriter returns a writer for results in one of the
+// SearchOutputFormats. columns limits the output to those fields
+func NewSearchResultWriter(w io.Writer, format string, columns []string) (*searchResultWriter, error) {
+	s := &searchResultWriter{
+		format:  format,
+		columns: columns,
+		w:       w,
+	}
+
+	switch format {
+	case "jsonl":
+	case "csv":
+		s.csv = csv.NewWriter(w)
+	case "table":
+		s.table = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	default:
+		return nil, fmt.Errorf("Unknown output format '%s'. Expected one of: %s", format, strings.Join(SearchOutputFormats, ", "))
+	}
+
+	return s, nil
+}
+
+// Write writes a page of results
+func (s *searchResultWriter) Write(fields []searchJobField, rows []map[string]string) error {
+	if !s.started {
+		s.started = true
+
+		if len(s.columns) == 0 {
+			s.columns = s.defaultColumns(fields)
+		}
+
+		if err := s.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+		if err := s.writeRow(row); err != nil {
+			return err
+		}
+	}
+
+	//Flush every page so results show up as they arrive
+	return s.Flush()
+}
+
+// defaultColumns returns every field of the results, except for tables of
+// messages where the raw message is more readable than every parsed field
+func (s *searchResultWriter) defaultColumns(fields []searchJobField) []string {
+	columns := make([]string, 0, len(fields))
+	hasRaw := false
+
+	for _, f := range fields {
+		columns = append(columns, f.Name)
+		if f.Name == "_raw" {
+			hasRaw = true
+		}
+	}
+
+	if s.format == "table" && hasRaw {
+		return []string{"_messagetime", "_raw"}
+	}
+
+	return columns
+}
+
+func (s *searchResultWriter) writeHeader() error {
+	switch s.format {
+	case "csv":
+		return s.csv.Write(s.columns)
+	case "table":
+		_, err := fmt.Fprintln(s.table, strings.Join(s.columns, "\t"))
+		return err
+	}
+
+	return nil
+}
+
+func (s *searchResultWriter) writeRow(row map[string]string) error {
+	values := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		value, ok := row[column]
+		if !ok {
+			//Result maps are keyed by lower case field names
+			value = row[strings.ToLower(column)]
+		}
+		values[i] = value
+	}
+
+	switch s.format {
+	case "jsonl":
+		line := make(map[string]string, len(s.columns))
+		for i, column := range s.columns {
+			line[column] = values[i]
+		}
+
+		j, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(s.w, string(j))
+		return err
+	case "csv":
+		return s.csv.Write(values)
+	}
+
+	for i, column := range s.columns {
+		values[i] = tableValue(column, values[i])
+	}
+
+	_, err := fmt.Fprintln(s.table, strings.Join(values, "\t"))
+	return err
+}
+
+// tableValue fits a value on a single table row
+func tableValue(column string, value string) string {
+	if containsString(searchTimeFields, strings.ToLower(column)) {
+		if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(0, millis*int64(time.Millisecond)).Format("2006-01-02 15:04:05.000")
+		}
+	}
+
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// Flush writes any buffered results
+func (s *searchResultWriter) Flush() error {
+	switch s.format {
+	case "csv":
+		s.csv.Flush()
+		return s.csv.Error()
+	case "table":
+		return s.table.Flush()
+	}
+
+	return nil
+}
@@ -5,9 +5,13 @@ import "github.com/r3labs/diff"
 type childType string
 
 const (
-	FolderType      string = "FolderSyncDefinition"
-	DashboardType          = "DashboardV2SyncDefinition"
-	SavedSearchType        = "SavedSearchWithScheduleSyncDefinition"
+	FolderType        string = "FolderSyncDefinition"
+	DashboardType            = "DashboardV2SyncDefinition"
+	SavedSearchType          = "SavedSearchWithScheduleSyncDefinition"
+	MonitorType              = "MonitorsLibraryMonitorExport"
+	MonitorFolderType        = "MonitorsLibraryFolderExport"
+	ParserType               = "ParserSyncDefinition"
+	ParserFolderType         = "ParserFolderSyncDefinition"
 )
 
 type asyncAPIContent struct {
@@ -31,7 +35,7 @@ type timerange struct {
 type query struct {
 	QueryString      string `json:"queryString"`
 	QueryType        string `json:"queryType"`
-	QueryKey         string `json:"queryKey"`
+	QueryKey         string `json:"queryKey" diff:"QueryKey,identifier"`
 	MetricsQueryMode string `json:"metricsQueryMode,omitempty"`
 	MetricsQueryData string `json:"metricsQueryData,omitempty"`
 	TracesQueryData  string `json:"tracesQueryData,omitempty"`
@@ -42,7 +46,7 @@ type query struct {
 type queryParameter struct{}
 
 type layoutStructure struct {
-	Key       string `json:"key"`
+	Key       string `json:"key" diff:"Key,identifier"`
 	Structure string `json:"structure"`
 }
 
@@ -57,14 +61,18 @@ type search struct {
 }
 
 type application struct {
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	Version     string        `json:"version"`
-	Children    []interface{} `json:"children" yaml:"children,omitempty"`
-	Type        string        `json:"type" yaml:"type,omitempty"`
-	Items       map[string][]string
-	path        string
-	appOverlays []*appOverlay
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	Version       string        `json:"version"`
+	Children      []interface{} `json:"children" yaml:"children,omitempty"`
+	Type          string        `json:"type" yaml:"type,omitempty"`
+	Items         map[string][]string
+	Monitors      *monitorFolder      `json:"monitors,omitempty" yaml:"-"`
+	Parsers       *parserFolder       `json:"parsers,omitempty" yaml:"-"`
+	Permissions   []contentPermission `json:"permissions,omitempty" yaml:",omitempty"`
+	path          string
+	appOverlays   []*appOverlay
+	normalization *normalizationRules
 }
 
 type appOverlay struct {
@@ -79,18 +87,22 @@ type appOverlay struct {
 	Variables     map[string]*variable
 	Queries       map[string]*query
 	Folders       map[string]*folder
+	Monitors      map[string]*monitor
+	Parsers       map[string]*parser
 	RootFolder    *folder
+	version       string
 }
 
 type searchSchedule struct{}
 
 type folder struct {
-	Id            string        `json:"id,omitempty"`
+	Id            string        `json:"id,omitempty" yaml:"id,omitempty"`
 	Type          string        `json:"type" yaml:"type,omitempty"`
 	Name          string        `json:"name"`
 	Description   string        `json:"description"`
 	Children      []interface{} `json:"children" yaml:"children,omitempty"`
 	Items         map[string][]string
+	Permissions   []contentPermission    `json:"permissions,omitempty" yaml:",omitempty"`
 	folders       map[string]*folder     `diff:"-"`
 	dashboards    map[string]dashboard   `diff:"-"`
 	savedSearches map[string]savedSearch `diff:"-"`
@@ -104,6 +116,93 @@ type savedSearch struct {
 	//SearchSchedule searchSchedule `json:"searchSchedule"` TODO: Add this back in. The searchSchedule type needs to be defined first
 }
 
+type monitorQuery struct {
+	RowId string `json:"rowId" diff:"RowId,identifier"`
+	Query string `json:"query"`
+}
+
+type monitorTrigger struct {
+	TriggerType      string  `json:"triggerType" diff:"TriggerType,identifier"`
+	DetectionMethod  string  `json:"detectionMethod,omitempty" yaml:",omitempty"`
+	TimeRange        string  `json:"timeRange"`
+	Threshold        float64 `json:"threshold"`
+	ThresholdType    string  `json:"thresholdType"`
+	OccurrenceType   string  `json:"occurrenceType,omitempty" yaml:",omitempty"`
+	TriggerSource    string  `json:"triggerSource,omitempty" yaml:",omitempty"`
+	ResolutionWindow string  `json:"resolutionWindow,omitempty" yaml:",omitempty"`
+	MinDataPoints    int     `json:"minDataPoints,omitempty" yaml:",omitempty"`
+}
+
+type monitorAction struct {
+	ConnectionType  string   `json:"connectionType"`
+	ConnectionId    string   `json:"connectionId,omitempty" yaml:",omitempty"`
+	Recipients      []string `json:"recipients,omitempty" yaml:",omitempty"`
+	Subject         string   `json:"subject,omitempty" yaml:",omitempty"`
+	MessageBody     string   `json:"messageBody,omitempty" yaml:",omitempty"`
+	TimeZone        string   `json:"timeZone,omitempty" yaml:",omitempty"`
+	PayloadOverride string   `json:"payloadOverride,omitempty" yaml:",omitempty"`
+}
+
+type monitorNotification struct {
+	Notification       monitorAction `json:"notification"`
+	RunForTriggerTypes []string      `json:"runForTriggerTypes"`
+}
+
+// monitor is a monitor of the Monitors Library. Monitors live outside the
+// content library, so Folder holds the slash separated path of the folder
+// the monitor is in, relative to the application's monitors folder
+type monitor struct {
+	Type               string                `json:"type" yaml:"type,omitempty"`
+	Name               string                `json:"name"`
+	Description        string                `json:"description"`
+	Folder             string                `json:"-" yaml:"folder,omitempty"`
+	MonitorType        string                `json:"monitorType"`
+	EvaluationDelay    string                `json:"evaluationDelay,omitempty" yaml:",omitempty"`
+	Queries            []monitorQuery        `json:"queries"`
+	Triggers           []monitorTrigger      `json:"triggers"`
+	Notifications      []monitorNotification `json:"notifications"`
+	IsDisabled         bool                  `json:"isDisabled"`
+	GroupNotifications bool                  `json:"groupNotifications"`
+	Playbook           string                `json:"playbook,omitempty" yaml:",omitempty"`
+}
+
+// monitorFolder is a folder of the Monitors Library in the format the
+// Monitors API exports and imports. Children are *monitorFolder and
+// *monitor values
+type monitorFolder struct {
+	Type        string        `json:"type"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Children    []interface{} `json:"children"`
+}
+
+// parser is a log parser of the Parsers library. Code holds the parser's
+// configuration, a list of [stanzas] with KEY = value settings
+type parser struct {
+	Type        string `json:"type" yaml:"-"`
+	Name        string `json:"name"`
+	Description string `json:"description" yaml:",omitempty"`
+	Code        string `json:"code"`
+}
+
+// parserFolder is a folder of the Parsers library in the format the
+// Content Management API exports and imports
+type parserFolder struct {
+	Type        string    `json:"type"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Children    []*parser `json:"children"`
+}
+
+// contentPermission gives a role, named by its name, or a user, named by
+// their email, access to a folder or dashboard. Level is view, edit, or
+// manage, each of which includes the levels before it
+type contentPermission struct {
+	Role  string `json:"role,omitempty" yaml:",omitempty"`
+	User  string `json:"user,omitempty" yaml:",omitempty"`
+	Level string `json:"level"`
+}
+
 type labelMap struct {
 	Data map[string]string `json:"data,omitempty"`
 }
@@ -114,7 +213,7 @@ type dashboard struct {
 	Description      string      `json:"description"`
 	Title            string      `json:"title"`
 	Theme            string      `json:"theme"`
-	TopologyLabelMap labelMap    `json:"topologyLabelMap,omitempty", yaml:topologylabelmap,omitempty"`
+	TopologyLabelMap labelMap    `json:"topologyLabelMap,omitempty" yaml:"topologylabelmap,omitempty"`
 	RefreshInterval  int64       `json:"refreshInterval"`
 	TimeRange        *timerange  `json:"timeRange"`
 	Layout           layout      `json:"layout"`
@@ -122,6 +221,7 @@ type dashboard struct {
 	Variables        []*variable `json:"variables" yaml:"variables,omitempty" diff:"-"`
 	RootPanel        string      `json:"rootPanel,omitempty"`
 	IncludeVariables []string
+	Permissions      []contentPermission `json:"permissions,omitempty" yaml:",omitempty"`
 	key              string
 }
 
@@ -132,7 +232,7 @@ type layout struct {
 }
 
 type panel struct {
-	Id                                     string     `json:"id,omitempty"`
+	Id                                     string     `json:"id,omitempty" yaml:"id,omitempty"`
 	Key                                    string     `json:"key"`
 	Title                                  string     `json:"title"`
 	VisualSettings                         string     `json:"visualSettings"`
@@ -150,13 +250,13 @@ type panel struct {
 type sourceDefinition struct {
 	VariableSourceType string `json:"variableSourceType"`
 	Query              string `json:"query" yaml:"query,omitempty"`
-	Field              string `json:"field" yaml:"field",omitempty"`
+	Field              string `json:"field" yaml:"field,omitempty"`
 	Filter             string `json:"filter" yaml:"filter,omitempty"`
 	Key                string `json:"key" yaml:"key,omitempty"`
 	Values             string `json:"values" yaml:"values,omitempty"`
 }
 type variable struct {
-	Id               string           `json:"id,omitempty"`
+	Id               string           `json:"id,omitempty" yaml:"id,omitempty"`
 	Name             string           `json:"name"`
 	DisplayName      string           `json:"displayName"`
 	DefaultValue     string           `json:"defaultValue"`
@@ -173,4 +273,9 @@ type changeSet struct {
 	ChangelogSavedSearches diff.Changelog
 	ChangelogDashboard     diff.Changelog
 	ChangelogFolder        diff.Changelog
+	ChangelogMonitor       diff.Changelog
+	ChangelogParser        diff.Changelog
+	//containers maps "type/key" of each object to the "type/key" of the
+	//dashboard or folder it's in
+	containers map[string]string
 }